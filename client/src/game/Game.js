@@ -10,9 +10,11 @@ import { GameOverScreen } from '../ui/GameOverScreen.js';
 import { PlayerInput } from '../input/PlayerInput.js';
 import { TouchControls } from '../input/TouchControls.js';
 import { BuyZonePopup } from '../ui/BuyZonePopup.js';
+import { EventFeed } from '../ui/EventFeed.js';
 import { Leaderboard } from '../ui/Leaderboard.js';
 import { AuthService } from '../auth/AuthService.js';
 import { SoundManager } from '../audio/SoundManager.js';
+import { PLAYER_SHOOT_INTERVAL } from '../utils/constants.js';
 
 export class Game {
   constructor() {
@@ -28,11 +30,14 @@ export class Game {
     this.playerInput = null;
     this.touchControls = null;
     this.buyZonePopup = null;
+    this.eventFeed = null;
     this.leaderboard = null;
     this.authService = null;
     this.soundManager = null;
     this.isSpectating = false;
     this.lobbyStatusInterval = null;
+    this.inputSequence = 0;
+    this.lastShotTime = 0;
   }
 
   async init() {
@@ -76,6 +81,7 @@ export class Game {
     });
     this.buyZonePopup = new BuyZonePopup();
     this.buyZonePopup.setCamera(this.camera, this.renderer.getRenderer());
+    this.eventFeed = new EventFeed(this.gameState);
     this.leaderboard = new Leaderboard();
 
     // Fetch leaderboard on startup
@@ -134,14 +140,15 @@ export class Game {
 
     // Override error handler to use popup for buy zone errors
     this.messageHandler.on('error', (payload) => {
-      console.error('Server error:', payload.message);
+      console.error('Server error:', payload.message, payload.code || '', payload.messageType || '');
       this.showBuyZoneError(payload.message);
     });
 
     // Add custom handler for game start
     this.messageHandler.on('game_start', (payload) => {
       console.log('Game started:', payload);
-      this.gameState.setPlayerInfo(payload.playerId, payload.gameId);
+      this.gameState.setPlayerInfo(payload.playerId, payload.gameId, payload.seat);
+      this.gameState.clearSnapshots();
 
       // Store map definition if provided
       if (payload.map) {
@@ -175,12 +182,20 @@ export class Game {
 
     // Add custom handler for game updates
     this.messageHandler.on('game_update', (payload) => {
-      if (payload.state) {
-        this.gameState.update(payload.state);
+      if (this.gameState.applyUpdate(payload)) {
+        // Acknowledge the snapshot so the server can send later updates as deltas against it
+        if (payload.snapshot) {
+          this.ws.send('state_ack', { snapshot: payload.snapshot });
+        }
         this.hud.update();
       }
     });
 
+    // Kills, claims and destroyed structures the server tells us about
+    this.messageHandler.on('game_events', (payload) => {
+      this.eventFeed.show(payload.events);
+    });
+
     // Add custom handler for game over
     this.messageHandler.on('game_over', (payload) => {
       console.log('Game over:', payload);
@@ -214,6 +229,7 @@ export class Game {
     this.messageHandler.on('spectate_start', (payload) => {
       console.log('Spectating game:', payload.gameId);
       this.isSpectating = true;
+      this.gameState.clearSnapshots();
 
       // Enable spectator mode on game loop
       this.gameLoop.setSpectatorMode(true);
@@ -345,6 +361,7 @@ export class Game {
     this.gameLoop.setSpectatorMode(false);
     this.gameState.clear();
     this.gameLoop.reset();
+    this.eventFeed.clear();
 
     // Hide spectator HUD, show queue screen
     document.getElementById('spectator-hud').classList.add('hidden');
@@ -418,21 +435,37 @@ export class Game {
       if (playVsAIButton) playVsAIButton.disabled = false;
     } else {
       statusElement.className = 'disconnected';
-      statusText.textContent = 'Disconnected';
+      statusText.textContent = this.ws.isOutdated ? 'Out of date - reload the page' : 'Disconnected';
       joinButton.disabled = true;
       if (playVsAIButton) playVsAIButton.disabled = true;
     }
   }
 
+  // Number each move and shoot input, so input acks can say which the server has processed
+  nextInput() {
+    this.inputSequence++;
+    return { sequence: this.inputSequence, clientTime: Date.now() };
+  }
+
   sendPlayerMove(direction) {
     if (this.ws.isConnected() && this.gameState.gameStatus === 'playing') {
-      this.ws.send('player_move', { direction });
+      this.ws.send('player_move', { direction, ...this.nextInput() });
     }
   }
 
   sendPlayerShoot(targetX, targetZ) {
     if (this.ws.isConnected() && this.gameState.gameStatus === 'playing') {
-      this.ws.send('player_shoot', { targetX, targetZ });
+      // Held buttons and auto-fire ask to shoot more often than the player can
+      const now = performance.now();
+      if (now - this.lastShotTime < PLAYER_SHOOT_INTERVAL) return;
+      this.lastShotTime = now;
+
+      this.ws.send('player_shoot', {
+        targetX,
+        targetZ,
+        viewSnapshot: this.gameState.snapshot,
+        ...this.nextInput(),
+      });
     }
   }
 
@@ -480,6 +513,7 @@ export class Game {
 
     // Reset the game loop (clears all meshes and resets flags)
     this.gameLoop.reset();
+    this.eventFeed.clear();
 
     // Hide game over screen
     this.gameOverScreen.hide();
@@ -424,11 +424,12 @@ export class GameLoop {
     const stateUnits = this.gameState.units || [];
     const currentUnitIDs = new Set(stateUnits.map(u => u.id));
 
-    // Detect destroyed units and create explosions (but not for players who are respawning)
+    // Detect destroyed units and create explosions (but not for players who are respawning,
+    // or units that just went out of sight)
     for (const [unitID, unitObj] of this.unitMeshes.entries()) {
       if (!currentUnitIDs.has(unitID)) {
         // Unit was destroyed - create explosion at its position
-        if (unitObj.mesh) {
+        if (unitObj.mesh && !this.gameState.hiddenUnits.has(unitID)) {
           const position = unitObj.mesh.position.clone();
           this.createExplosion(position);
 
@@ -465,6 +466,7 @@ export class GameLoop {
 
     // Update previous IDs for next frame
     this.previousUnitIDs = currentUnitIDs;
+    this.gameState.hiddenUnits.clear();
   }
 
   syncProjectiles() {
@@ -540,7 +542,7 @@ export class GameLoop {
     } else if (unit.type === 'rocket_launcher') {
       unitObj = new RocketLauncher(this.scene.getScene(), unit, color);
     } else if (unit.type === 'player') {
-      const isLocalPlayer = !this.isSpectating && unit.ownerId === this.gameState.playerId;
+      const isLocalPlayer = !this.isSpectating && this.gameState.isMyPlayerUnit(unit);
       // Get display name from player data
       const playerData = this.gameState.players[unit.ownerId];
       const displayName = playerData ? playerData.displayName : null;
@@ -23,16 +23,19 @@ export class MessageHandler {
   setupDefaultHandlers() {
     this.on('game_start', (payload) => {
       console.log('Game started:', payload);
-      this.gameState.setPlayerInfo(payload.playerId, payload.gameId);
+      this.gameState.setPlayerInfo(payload.playerId, payload.gameId, payload.seat);
       if (payload.state) {
         this.gameState.update(payload.state);
       }
     });
 
     this.on('game_update', (payload) => {
-      if (payload.state) {
-        this.gameState.update(payload.state);
-      }
+      this.gameState.applyUpdate(payload);
+    });
+
+    // Our own inputs and movement, sent straight after each game_update
+    this.on('input_ack', (payload) => {
+      this.gameState.inputAck = payload;
     });
 
     this.on('game_over', (payload) => {
@@ -42,10 +45,14 @@ export class MessageHandler {
     });
 
     this.on('error', (payload) => {
-      console.error('Server error:', payload.message);
+      console.error('Server error:', payload.message, payload.code || '', payload.messageType || '');
       // Default handler just logs - Game.js overrides this for popup display
     });
 
+    this.on('welcome', (payload) => {
+      console.log(`Server build ${payload.serverBuild} (protocol ${payload.protocolVersion}, ${payload.encoding})`);
+    });
+
     this.on('info', (payload) => {
       console.log('Server info:', payload);
     });
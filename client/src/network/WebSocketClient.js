@@ -1,4 +1,13 @@
-import { WS_URL } from '../utils/constants.js';
+import {
+  WS_URL,
+  WS_PROTOCOLS,
+  WS_ENCODINGS,
+  PROTOCOL_VERSION,
+  CLIENT_BUILD,
+  CLIENT_FEATURES,
+  WS_CLOSE_CLIENT_TOO_OLD
+} from '../utils/constants.js';
+import { decodeMsgpack } from './msgpack.js';
 
 export class WebSocketClient {
   constructor(onMessage) {
@@ -9,6 +18,7 @@ export class WebSocketClient {
     this.maxReconnectDelay = 30000; // Cap at 30 seconds
     this.isIntentionallyClosed = false;
     this.reconnectTimeout = null;
+    this.isOutdated = false;
   }
 
   connect() {
@@ -20,11 +30,21 @@ export class WebSocketClient {
       this.reconnectTimeout = null;
     }
 
-    this.ws = new WebSocket(WS_URL);
+    this.ws = new WebSocket(WS_URL, WS_PROTOCOLS);
+    this.ws.binaryType = 'arraybuffer';
 
     this.ws.onopen = () => {
       console.log('Connected to server');
       this.reconnectAttempts = 0;
+
+      // Say hello before anything else so the server knows what this client supports
+      this.send('hello', {
+        protocolVersion: PROTOCOL_VERSION,
+        clientBuild: CLIENT_BUILD,
+        encodings: WS_ENCODINGS,
+        features: CLIENT_FEATURES
+      });
+
       if (this.onConnectionChange) {
         this.onConnectionChange(true);
       }
@@ -32,7 +52,10 @@ export class WebSocketClient {
 
     this.ws.onmessage = (event) => {
       try {
-        const message = JSON.parse(event.data);
+        // Binary frames are MessagePack; text frames are JSON
+        const message = event.data instanceof ArrayBuffer
+          ? decodeMsgpack(event.data)
+          : JSON.parse(event.data);
         if (this.onMessage) {
           this.onMessage(message);
         }
@@ -45,8 +68,16 @@ export class WebSocketClient {
       console.error('WebSocket error:', error);
     };
 
-    this.ws.onclose = () => {
+    this.ws.onclose = (event) => {
       console.log('Disconnected from server');
+
+      // Reconnecting won't help a client the server is too new for
+      if (event.code === WS_CLOSE_CLIENT_TOO_OLD) {
+        console.warn('Client is out of date:', event.reason);
+        this.isOutdated = true;
+        this.isIntentionallyClosed = true;
+      }
+
       if (this.onConnectionChange) {
         this.onConnectionChange(false);
       }
@@ -103,8 +134,8 @@ export class WebSocketClient {
 
   // Force an immediate reconnection attempt (used when user takes action)
   forceReconnect() {
-    if (this.isConnected()) {
-      return; // Already connected
+    if (this.isConnected() || this.isOutdated) {
+      return; // Already connected, or the page needs reloading
     }
     console.log('Force reconnecting...');
     this.reconnectAttempts = 0;
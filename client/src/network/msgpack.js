@@ -0,0 +1,84 @@
+// Minimal MessagePack decoder for binary messages from the server
+
+const textDecoder = new TextDecoder();
+
+export function decodeMsgpack(buffer) {
+  const view = new DataView(buffer);
+  const bytes = new Uint8Array(buffer);
+  let offset = 0;
+
+  const readString = (length) => {
+    const value = textDecoder.decode(bytes.subarray(offset, offset + length));
+    offset += length;
+    return value;
+  };
+
+  const readArray = (length) => {
+    const array = new Array(length);
+    for (let i = 0; i < length; i++) {
+      array[i] = read();
+    }
+    return array;
+  };
+
+  const readMap = (length) => {
+    const map = {};
+    for (let i = 0; i < length; i++) {
+      const key = read();
+      map[key] = read();
+    }
+    return map;
+  };
+
+  const readBinary = (length) => {
+    const value = bytes.slice(offset, offset + length);
+    offset += length;
+    return value;
+  };
+
+  // Advances past a fixed-size value that has just been read
+  const take = (size, value) => {
+    offset += size;
+    return value;
+  };
+
+  const read = () => {
+    const type = bytes[offset++];
+
+    if (type <= 0x7f) return type; // Positive fixint
+    if (type >= 0xe0) return type - 0x100; // Negative fixint
+    if ((type & 0xf0) === 0x80) return readMap(type & 0x0f);
+    if ((type & 0xf0) === 0x90) return readArray(type & 0x0f);
+    if ((type & 0xe0) === 0xa0) return readString(type & 0x1f);
+
+    switch (type) {
+      case 0xc0: return null;
+      case 0xc2: return false;
+      case 0xc3: return true;
+      case 0xc4: return readBinary(take(1, view.getUint8(offset)));
+      case 0xc5: return readBinary(take(2, view.getUint16(offset)));
+      case 0xc6: return readBinary(take(4, view.getUint32(offset)));
+      case 0xca: return take(4, view.getFloat32(offset));
+      case 0xcb: return take(8, view.getFloat64(offset));
+      case 0xcc: return take(1, view.getUint8(offset));
+      case 0xcd: return take(2, view.getUint16(offset));
+      case 0xce: return take(4, view.getUint32(offset));
+      case 0xcf: return take(8, Number(view.getBigUint64(offset)));
+      case 0xd0: return take(1, view.getInt8(offset));
+      case 0xd1: return take(2, view.getInt16(offset));
+      case 0xd2: return take(4, view.getInt32(offset));
+      case 0xd3: return take(8, Number(view.getBigInt64(offset)));
+      case 0xd9: return readString(take(1, view.getUint8(offset)));
+      case 0xda: return readString(take(2, view.getUint16(offset)));
+      case 0xdb: return readString(take(4, view.getUint32(offset)));
+      case 0xdc: return readArray(take(2, view.getUint16(offset)));
+      case 0xdd: return readArray(take(4, view.getUint32(offset)));
+      case 0xde: return readMap(take(2, view.getUint16(offset)));
+      case 0xdf: return readMap(take(4, view.getUint32(offset)));
+      default:
+        throw new Error(`Unsupported MessagePack type 0x${type.toString(16)}`);
+    }
+  };
+
+  return read();
+}
@@ -1,5 +1,27 @@
 // Client-side game state management
 
+// How far behind the newest snapshot we keep old ones for the server to send deltas against
+const SNAPSHOT_HISTORY = 64;
+
+// Apply entity patches (new entities whole, changed ones as JSON merge patches keyed by id) and removals
+function patchEntities(entities, patches, removed) {
+  if (!patches && !removed) return entities;
+
+  const byId = new Map(entities.map(entity => [entity.id, entity]));
+  for (const id of removed || []) {
+    byId.delete(id);
+  }
+  for (const patch of patches || []) {
+    // Build a new object - earlier snapshots still share the old one
+    const entity = { ...byId.get(patch.id), ...patch };
+    for (const key of Object.keys(patch)) {
+      if (patch[key] === null) delete entity[key];
+    }
+    byId.set(patch.id, entity);
+  }
+  return [...byId.values()];
+}
+
 export class GameState {
   constructor() {
     this.timestamp = 0;
@@ -15,8 +37,13 @@ export class GameState {
     this.gameStatus = 'waiting'; // 'waiting', 'playing', 'finished'
     this.winner = null;
     this.playerId = null;
+    this.seat = null; // Seat we control, which picks out our player unit in team games
     this.gameId = null;
     this.mapDefinition = null; // Map configuration from server
+    this.snapshots = new Map(); // Snapshot number -> full state, for applying deltas
+    this.snapshot = 0; // Newest snapshot applied, so shots are resolved against what we saw
+    this.hiddenUnits = new Set(); // Units that went out of sight (fog of war) since the scene last synced
+    this.inputAck = null; // Newest of our inputs the server has processed, with our authoritative movement
   }
 
   update(newState) {
@@ -32,10 +59,69 @@ export class GameState {
     this.pendingSpawns = newState.pendingSpawns || [];
     this.gameStatus = newState.gameStatus || this.gameStatus;
     this.winner = newState.winner !== undefined ? newState.winner : this.winner;
+    for (const id of newState.hiddenUnits || []) {
+      this.hiddenUnits.add(id);
+    }
+  }
+
+  // Apply a game_update, which is either a keyframe (full state) or a delta against a snapshot
+  // we acknowledged. Returns false if the delta's base snapshot is unknown.
+  applyUpdate(payload) {
+    let state = payload.state;
+    if (!state && payload.delta) {
+      const base = this.snapshots.get(payload.baseSnapshot);
+      if (!base) return false;
+      state = this.applyDelta(base, payload.timestamp, payload.delta);
+    }
+    if (!state) return false;
+
+    this.update(state);
+
+    if (payload.snapshot) {
+      this.snapshot = payload.snapshot;
+      this.snapshots.set(payload.snapshot, state);
+
+      // The server only sends deltas against the newest snapshot we acknowledged
+      const oldest = Math.max(payload.baseSnapshot || 0, payload.snapshot - SNAPSHOT_HISTORY);
+      for (const snapshot of this.snapshots.keys()) {
+        if (snapshot < oldest) this.snapshots.delete(snapshot);
+      }
+    }
+    return true;
   }
 
-  setPlayerInfo(playerId, gameId) {
+  // Rebuild a full state from a base snapshot and a delta
+  applyDelta(base, timestamp, delta) {
+    return {
+      ...base,
+      timestamp,
+      players: delta.players,
+      units: patchEntities(base.units || [], delta.units, delta.removedUnits),
+      hiddenUnits: delta.hiddenUnits,
+      projectiles: patchEntities(base.projectiles || [], delta.projectiles, delta.removedProjectiles),
+      healthPacks: patchEntities(base.healthPacks || [], delta.healthPacks, delta.removedHealthPacks),
+      buyZones: patchEntities(base.buyZones || [], delta.buyZones),
+      turrets: patchEntities(base.turrets || [], delta.turrets),
+      barracks: patchEntities(base.barracks || [], delta.barracks),
+      bases: patchEntities(base.bases || [], delta.bases),
+      hills: patchEntities(base.hills || [], delta.hills),
+      flags: delta.flags || base.flags,
+      pendingSpawns: delta.pendingSpawns,
+      gameStatus: delta.gameStatus,
+      winner: delta.winner,
+      timeRemaining: delta.timeRemaining,
+      suddenDeath: delta.suddenDeath,
+      objectiveScores: delta.objectiveScores,
+    };
+  }
+
+  clearSnapshots() {
+    this.snapshots.clear();
+  }
+
+  setPlayerInfo(playerId, gameId, seat = playerId) {
     this.playerId = playerId;
+    this.seat = seat;
     this.gameId = gameId;
   }
 
@@ -63,7 +149,13 @@ export class GameState {
 
   getMyPlayerUnit() {
     if (this.playerId === null) return null;
-    return this.units.find(unit => unit.type === 'player' && unit.ownerId === this.playerId) || null;
+    return this.units.find(unit => this.isMyPlayerUnit(unit)) || null;
+  }
+
+  // Teammates' player units share our ownerId, so ours is the one for our seat
+  isMyPlayerUnit(unit) {
+    if (this.seat === null || unit.type !== 'player') return false;
+    return (unit.seat || 0) === this.seat; // Seat 0 is left out of the JSON
   }
 
   clear() {
@@ -80,8 +172,13 @@ export class GameState {
     this.gameStatus = 'waiting';
     this.winner = null;
     this.playerId = null;
+    this.seat = null;
     this.gameId = null;
     this.mapDefinition = null;
+    this.snapshots.clear();
+    this.snapshot = 0;
+    this.hiddenUnits.clear();
+    this.inputAck = null;
   }
 
   // Get pending spawns for a specific player
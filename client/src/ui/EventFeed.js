@@ -0,0 +1,75 @@
+import { UNIT_NAMES } from '../utils/constants.js';
+
+// Display names of the other things events can be about
+const EVENT_TARGET_NAMES = {
+  'player': 'Player',
+  'turret': 'Turret',
+  'barracks': 'Barracks',
+  'buy_zone': 'Forward Base',
+  'base': 'Base'
+};
+
+// How many events are shown at once, and for how long (ms)
+const MAX_FEED_ENTRIES = 5;
+const FEED_ENTRY_DURATION = 5000;
+
+// Lists the notable moments of a match (kills, claims, destroyed structures) sent in game_events
+export class EventFeed {
+  constructor(gameState) {
+    this.gameState = gameState;
+    this.container = document.getElementById('event-feed');
+  }
+
+  show(events) {
+    if (!this.container) return;
+
+    for (const event of events) {
+      const text = this.describe(event);
+      if (!text) continue;
+
+      const entry = document.createElement('div');
+      entry.className = 'event-feed-entry';
+      entry.textContent = text;
+      this.container.appendChild(entry);
+      setTimeout(() => entry.remove(), FEED_ENTRY_DURATION);
+    }
+
+    while (this.container.children.length > MAX_FEED_ENTRIES) {
+      this.container.firstChild.remove();
+    }
+  }
+
+  clear() {
+    if (this.container) {
+      this.container.replaceChildren();
+    }
+  }
+
+  // Returns the text for an event, or null for events that aren't worth listing
+  describe(event) {
+    const target = `${this.teamName(event.targetPlayerId)} ${this.name(event.targetType)}`;
+    switch (event.type) {
+      case 'unit_killed':
+        return `${this.teamName(event.playerId)} ${this.name(event.unitType)} killed ${target}`;
+      case 'structure_destroyed':
+      case 'base_destroyed':
+        return `${target} destroyed`;
+      case 'structure_claimed':
+        return `${this.teamName(event.playerId)} team claimed a ${this.name(event.targetType)}`;
+      case 'barracks_scattered':
+        return `${event.count} infantry scattered from a Barracks`;
+      default:
+        return null;
+    }
+  }
+
+  teamName(playerId) {
+    if (playerId === -1) return 'Neutral';
+    if (this.gameState.playerId === null) return `Player ${playerId + 1}`;
+    return playerId === this.gameState.playerId ? 'Your' : 'Enemy';
+  }
+
+  name(type) {
+    return UNIT_NAMES[type] || EVENT_TARGET_NAMES[type] || type;
+  }
+}
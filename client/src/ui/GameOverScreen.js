@@ -1,3 +1,17 @@
+import { UNIT_NAMES } from '../utils/constants.js';
+
+// Breakdown stats shown for each side: element ID suffix -> stat
+const BREAKDOWN_STATS = {
+  'deaths': 'deaths',
+  'damage-dealt': 'damageDealt',
+  'money-earned': 'moneyEarned',
+  'money-spent': 'moneySpent',
+  'turrets-claimed': 'turretsClaimed',
+  'buy-zones-claimed': 'buyZonesClaimed',
+  'barracks-claimed': 'barracksClaimed',
+  'health-packs': 'healthPacksCollected'
+};
+
 export class GameOverScreen {
   constructor(gameState, onPlayAgain) {
     this.gameState = gameState;
@@ -37,13 +51,18 @@ export class GameOverScreen {
   }
 
   show(winnerId, reason, matchDuration, stats) {
-    // Determine if this player won
+    // Determine if this player won (a winner of -1 means the match was a draw)
     const myPlayerId = this.gameState.playerId;
+    const isDraw = winnerId === -1;
     const didWin = winnerId === myPlayerId;
 
-    console.log('Game over:', { winnerId, myPlayerId, didWin, reason, matchDuration, stats });
+    console.log('Game over:', { winnerId, myPlayerId, isDraw, didWin, reason, matchDuration, stats });
 
-    if (didWin) {
+    if (isDraw) {
+      this.title.textContent = 'Draw';
+      this.title.className = 'draw';
+      this.message.textContent = reason || 'The game ended in a draw.';
+    } else if (didWin) {
       this.title.textContent = 'Victory!';
       this.title.className = 'victory';
       this.message.textContent = reason || 'You won the game!';
@@ -75,6 +94,7 @@ export class GameOverScreen {
         this.yourTurretKills.textContent = myStats.turretKills || 0;
         this.yourBarracksKills.textContent = myStats.barracksKills || 0;
         this.yourPlayerKills.textContent = myStats.playerKills || 0;
+        this.showBreakdown('your', myStats);
       }
 
       // Enemy stats
@@ -87,12 +107,24 @@ export class GameOverScreen {
         this.enemyTurretKills.textContent = theirStats.turretKills || 0;
         this.enemyBarracksKills.textContent = theirStats.barracksKills || 0;
         this.enemyPlayerKills.textContent = theirStats.playerKills || 0;
+        this.showBreakdown('enemy', theirStats);
       }
     }
 
     this.screen.classList.remove('hidden');
   }
 
+  // Show the rest of a side's match stats: damage, economy, claims and units bought
+  showBreakdown(side, stats) {
+    for (const [id, stat] of Object.entries(BREAKDOWN_STATS)) {
+      document.getElementById(`${side}-${id}`).textContent = stats[stat] || 0;
+    }
+
+    const purchased = Object.entries(stats.unitsPurchased || {})
+      .map(([unitType, count]) => `${UNIT_NAMES[unitType] || unitType} x${count}`);
+    document.getElementById(`${side}-units-purchased`).textContent = purchased.join(', ') || 'None';
+  }
+
   hide() {
     this.screen.classList.add('hidden');
   }
@@ -1,3 +1,5 @@
+import { UNIT_NAMES } from '../utils/constants.js';
+
 export class HUD {
   constructor(gameState, gameLoop, soundManager = null) {
     this.gameState = gameState;
@@ -185,15 +187,7 @@ export class HUD {
 
     // Priority: owned buy zones first, then claimable zones, then turrets, then barracks
     if (nearbyZone && myPlayer) {
-      const unitNames = {
-        'tank': 'Tank',
-        'airplane': 'Helicopter',
-        'super_tank': 'Super Tank',
-        'super_helicopter': 'Super Helicopter',
-        'sniper': 'Sniper',
-        'rocket_launcher': 'Rocket Launcher'
-      };
-      const unitName = unitNames[nearbyZone.unitType] || nearbyZone.unitType;
+      const unitName = UNIT_NAMES[nearbyZone.unitType] || nearbyZone.unitType;
       const cost = nearbyZone.cost;
       const canAfford = myPlayer.money >= cost;
       const pendingCount = pendingCounts[nearbyZone.unitType] || 0;
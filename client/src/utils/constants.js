@@ -6,6 +6,10 @@ export const BASE_SIZE = 25; // Size of base area (matches server)
 export const STARTING_MONEY = 100;
 export const PASSIVE_INCOME_PER_SECOND = 10;
 
+// Milliseconds between player shots (matches server PlayerUnitAttackSpeed) - the server flags
+// clients that shoot faster
+export const PLAYER_SHOOT_INTERVAL = 500;
+
 // Unit constants
 export const UNITS = {
   tank: {
@@ -42,3 +46,31 @@ const wsProtocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
 const wsHost = isProduction ? window.location.host : 'localhost:3000';
 
 export const WS_URL = `${wsProtocol}//${wsHost}/ws`;
+
+// Message protocol version (must match the server's ProtocolVersion)
+export const PROTOCOL_VERSION = 2;
+
+// Client build, sent to the server in the hello handshake
+export const CLIENT_BUILD = import.meta.env.VITE_CLIENT_BUILD || 'dev';
+
+// Encodings the client can decode, most preferred first (the server falls back to JSON)
+export const WS_ENCODINGS = ['msgpack', 'json'];
+
+// WebSocket subprotocols selecting each encoding
+export const WS_PROTOCOLS = WS_ENCODINGS.map((encoding) => `arena.v${PROTOCOL_VERSION}.${encoding}`);
+
+// Optional server messages the client handles
+export const CLIENT_FEATURES = ['delta_updates', 'game_events'];
+
+// Display names of the unit types
+export const UNIT_NAMES = {
+  'tank': 'Tank',
+  'airplane': 'Helicopter',
+  'super_tank': 'Super Tank',
+  'super_helicopter': 'Super Helicopter',
+  'sniper': 'Sniper',
+  'rocket_launcher': 'Rocket Launcher'
+};
+
+// Close code the server disconnects clients that are too old with
+export const WS_CLOSE_CLIENT_TOO_OLD = 4000;
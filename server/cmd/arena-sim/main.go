@@ -0,0 +1,311 @@
+// arena-sim runs AI vs AI matches headlessly, as fast as the CPU allows,
+// and prints win rates, match durations, kill stats and purchases for balance testing.
+//
+// Usage:
+//
+//	go run ./cmd/arena-sim -matches 200 -p1 hard -p2 hard
+//	go run ./cmd/arena-sim -map the_divide -parallel 4 -seed 42
+//	go run ./cmd/arena-sim -team-size 2 -mode capture_the_flag
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tombuildsstuff/web-arena-game/server/internal/game"
+	"github.com/tombuildsstuff/web-arena-game/server/internal/game/maps"
+	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
+)
+
+// matchResult is the outcome of a single simulated match
+type matchResult struct {
+	mapID    string
+	winner   int // -1 if the match hit the tick limit
+	reason   string
+	duration int // Match duration in seconds
+	stats    [2]types.PlayerStats
+}
+
+// purchasedUnitTypes are the unit types in the purchase breakdown, in report order
+var purchasedUnitTypes = []string{"tank", "super_tank", "airplane", "super_helicopter", "sniper", "rocket_launcher"}
+
+// unitNames are the report names of the unit types
+var unitNames = map[string]string{
+	"tank":             "Tanks",
+	"super_tank":       "Super tanks",
+	"airplane":         "Airplanes",
+	"super_helicopter": "Super helicopters",
+	"sniper":           "Snipers",
+	"rocket_launcher":  "Rocket launchers",
+}
+
+// matchJob describes a single match to simulate
+type matchJob struct {
+	mapDef *types.MapDefinition
+	seed   int64
+}
+
+// resultConnection captures the game_over payload of a headless match
+type resultConnection struct {
+	gameOver *types.GameOverPayload
+}
+
+// SendMessage keeps the game_over payload and discards everything else
+func (c *resultConnection) SendMessage(msgType string, payload interface{}) {
+	if msgType == "game_over" {
+		if gameOver, ok := payload.(types.GameOverPayload); ok {
+			c.gameOver = &gameOver
+		}
+	}
+}
+
+func main() {
+	mapID := flag.String("map", "", "Map ID to simulate (default: every map in the registry)")
+	matches := flag.Int("matches", 100, "Number of matches to run per map")
+	parallel := flag.Int("parallel", runtime.NumCPU(), "Number of matches to run at once")
+	p1Difficulty := flag.String("p1", "hard", "AI difficulty for player 1 (easy, medium, hard)")
+	p2Difficulty := flag.String("p2", "hard", "AI difficulty for player 2 (easy, medium, hard)")
+	gameMode := flag.String("mode", "siege", "Game mode (siege, king_of_the_hill, capture_the_flag)")
+	teamSize := flag.Int("team-size", 1, "AI players per team (1 = 1v1, 2 = 2v2)")
+	timeLimit := flag.Float64("time-limit", -1, "Override the map's match time limit in seconds (0 = no limit)")
+	maxDuration := flag.Int("max-duration", 1800, "Simulated seconds before a match is called a draw")
+	seed := flag.Int64("seed", 0, "Seed for the first match, incremented per match (default: current time)")
+	verbose := flag.Bool("verbose", false, "Show the game server's log output")
+	flag.Parse()
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	if *parallel < 1 {
+		*parallel = 1
+	}
+
+	if *teamSize < 1 {
+		fmt.Fprintf(os.Stderr, "team size must be at least 1\n")
+		os.Exit(1)
+	}
+
+	if !game.IsValidGameMode(*gameMode) {
+		fmt.Fprintf(os.Stderr, "unknown game mode %q\n", *gameMode)
+		os.Exit(1)
+	}
+
+	mapDefs, err := selectMaps(*mapID)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	maxTicks := int64(time.Duration(*maxDuration) * time.Second / types.TickDuration)
+
+	// Queue every match up front, then fan them out to the workers
+	jobs := make(chan matchJob)
+	go func() {
+		nextSeed := *seed
+		for _, mapDef := range mapDefs {
+			for i := 0; i < *matches; i++ {
+				jobs <- matchJob{mapDef: mapDef, seed: nextSeed}
+				nextSeed++
+			}
+		}
+		close(jobs)
+	}()
+
+	results := make(chan matchResult)
+	var wg sync.WaitGroup
+	for i := 0; i < *parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				results <- runMatch(job, *gameMode, *teamSize, *p1Difficulty, *p2Difficulty, *timeLimit, maxTicks)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	started := time.Now()
+	resultsByMap := make(map[string][]matchResult)
+	for result := range results {
+		resultsByMap[result.mapID] = append(resultsByMap[result.mapID], result)
+	}
+
+	fmt.Printf("Simulated %d %dv%d %s matches (P1 %s vs P2 %s, first seed %d) in %s\n\n",
+		len(mapDefs)**matches, *teamSize, *teamSize, *gameMode, *p1Difficulty, *p2Difficulty, *seed, time.Since(started).Round(time.Millisecond))
+	for _, mapDef := range mapDefs {
+		printReport(mapDef, resultsByMap[mapDef.ID])
+	}
+}
+
+// selectMaps returns the requested map, or every registered map sorted by ID
+func selectMaps(mapID string) ([]*types.MapDefinition, error) {
+	if mapID != "" {
+		mapDef, err := maps.Get(mapID)
+		if err != nil {
+			return nil, fmt.Errorf("%v (available: %s)", err, strings.Join(maps.List(), ", "))
+		}
+		return []*types.MapDefinition{mapDef}, nil
+	}
+
+	ids := maps.List()
+	sort.Strings(ids)
+	mapDefs := make([]*types.MapDefinition, 0, len(ids))
+	for _, id := range ids {
+		mapDef, _ := maps.Get(id)
+		mapDefs = append(mapDefs, mapDef)
+	}
+	return mapDefs, nil
+}
+
+// runMatch plays a single AI vs AI match to completion or the tick limit
+func runMatch(job matchJob, gameMode string, teamSize int, p1Difficulty, p2Difficulty string, timeLimit float64, maxTicks int64) matchResult {
+	gameID := fmt.Sprintf("sim-%s-%d", job.mapDef.ID, job.seed)
+	difficulties := [2]string{p1Difficulty, p2Difficulty}
+
+	var teams [2][]game.SeatConfig
+	for seat := 0; seat < 2*teamSize; seat++ {
+		team := game.TeamForSeat(seat)
+		teams[team] = append(teams[team], game.SeatConfig{
+			ClientID:    fmt.Sprintf("ai-%d-%s", seat, gameID),
+			DisplayName: "AI (" + difficulties[team] + ")",
+		})
+	}
+	room := game.NewTeamGameRoomWithMap(gameID, job.mapDef, job.seed, teams)
+
+	room.SetGameMode(gameMode)
+
+	// Seat 0 captures the result, the rest discard their messages
+	conn := &resultConnection{}
+	for seat := 0; seat < 2*teamSize; seat++ {
+		if seat == 0 {
+			room.SetClientConnection(seat, conn)
+		} else {
+			room.SetClientConnection(seat, &game.AIClientConnection{})
+		}
+		room.SetAIController(game.NewAIController(seat, difficulties[game.TeamForSeat(seat)]))
+	}
+
+	if timeLimit >= 0 {
+		rules := job.mapDef.MatchRules()
+		rules.TimeLimit = timeLimit
+		room.SetMatchRules(rules)
+	}
+
+	for room.State.Tick < maxTicks && conn.gameOver == nil {
+		room.Step()
+	}
+
+	result := matchResult{
+		mapID: job.mapDef.ID,
+	}
+	if conn.gameOver == nil {
+		// Nobody won in time - report it as a draw with the stats so far
+		result.winner = -1
+		result.reason = "Tick limit reached"
+		result.duration = room.State.GetMatchDuration()
+		result.stats = [2]types.PlayerStats{room.State.Players[0].GetStats(), room.State.Players[1].GetStats()}
+		return result
+	}
+
+	result.winner = conn.gameOver.Winner
+	result.reason = conn.gameOver.Reason
+	result.duration = conn.gameOver.MatchDuration
+	result.stats = [2]types.PlayerStats{conn.gameOver.Stats.Player1Stats, conn.gameOver.Stats.Player2Stats}
+	return result
+}
+
+// printReport prints the aggregated results for one map
+func printReport(mapDef *types.MapDefinition, results []matchResult) {
+	fmt.Printf("== %s (%s) ==\n", mapDef.Name, mapDef.ID)
+	if len(results) == 0 {
+		fmt.Printf("No matches\n\n")
+		return
+	}
+
+	var wins [2]int
+	var draws, totalDuration int
+	var kills [2]types.PlayerStats
+	purchases := [2]map[string]int{make(map[string]int), make(map[string]int)}
+	reasons := make(map[string]int)
+	for _, result := range results {
+		if result.winner == 0 || result.winner == 1 {
+			wins[result.winner]++
+		} else {
+			draws++
+		}
+		totalDuration += result.duration
+		reasons[result.reason]++
+
+		for side := 0; side < 2; side++ {
+			kills[side].TankKills += result.stats[side].TankKills
+			kills[side].SuperTankKills += result.stats[side].SuperTankKills
+			kills[side].AirplaneKills += result.stats[side].AirplaneKills
+			kills[side].SniperKills += result.stats[side].SniperKills
+			kills[side].RocketLauncherKills += result.stats[side].RocketLauncherKills
+			kills[side].TurretKills += result.stats[side].TurretKills
+			kills[side].BarracksKills += result.stats[side].BarracksKills
+			kills[side].PlayerKills += result.stats[side].PlayerKills
+			kills[side].TotalPoints += result.stats[side].TotalPoints
+
+			for unitType, count := range result.stats[side].UnitsPurchased {
+				purchases[side][unitType] += count
+			}
+		}
+	}
+
+	total := float64(len(results))
+	fmt.Printf("Matches: %d  P1 wins: %d (%.1f%%)  P2 wins: %d (%.1f%%)  Draws: %d (%.1f%%)\n",
+		len(results),
+		wins[0], 100*float64(wins[0])/total,
+		wins[1], 100*float64(wins[1])/total,
+		draws, 100*float64(draws)/total)
+	fmt.Printf("Average match duration: %s\n", time.Duration(float64(totalDuration)/total*float64(time.Second)).Round(time.Second))
+
+	reasonNames := make([]string, 0, len(reasons))
+	for reason := range reasons {
+		reasonNames = append(reasonNames, reason)
+	}
+	sort.Strings(reasonNames)
+	for _, reason := range reasonNames {
+		fmt.Printf("  %-30s %d\n", reason, reasons[reason])
+	}
+
+	// Average kills per match, by the type of unit destroyed
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "Avg kills per match\tP1\tP2\t")
+	row := func(name string, p1, p2 int) {
+		fmt.Fprintf(w, "%s\t%.2f\t%.2f\t\n", name, float64(p1)/total, float64(p2)/total)
+	}
+	row("Tanks", kills[0].TankKills-kills[0].SuperTankKills, kills[1].TankKills-kills[1].SuperTankKills)
+	row("Super tanks", kills[0].SuperTankKills, kills[1].SuperTankKills)
+	row("Airplanes", kills[0].AirplaneKills, kills[1].AirplaneKills)
+	row("Snipers", kills[0].SniperKills, kills[1].SniperKills)
+	row("Rocket launchers", kills[0].RocketLauncherKills, kills[1].RocketLauncherKills)
+	row("Turrets", kills[0].TurretKills, kills[1].TurretKills)
+	row("Barracks", kills[0].BarracksKills, kills[1].BarracksKills)
+	row("Players", kills[0].PlayerKills, kills[1].PlayerKills)
+	row("Points", kills[0].TotalPoints, kills[1].TotalPoints)
+
+	// Average units bought per match, by type
+	fmt.Fprintln(w, "Avg units bought per match\tP1\tP2\t")
+	for _, unitType := range purchasedUnitTypes {
+		row(unitNames[unitType], purchases[0][unitType], purchases[1][unitType])
+	}
+	w.Flush()
+	fmt.Println()
+}
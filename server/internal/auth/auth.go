@@ -16,10 +16,18 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/tombuildsstuff/web-arena-game/server/internal/storage"
+	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/github"
 )
 
+// How long sign-in sessions last, in seconds
+const (
+	authSessionMaxAge  = 604800  // 7 days
+	guestSessionMaxAge = 2592000 // 30 days
+)
+
 // Config holds OAuth configuration
 type Config struct {
 	GitHubClientID     string
@@ -40,10 +48,17 @@ type Handler struct {
 	config      *Config
 	oauthConfig *oauth2.Config
 
-	// Server-side session storage: authToken -> UserInfo
-	sessions   map[string]*UserInfo
+	// Server-side session storage: authToken -> session
+	// Cached from the store, which keeps sessions across restarts
+	sessions   map[string]*session
 	sessionsMu sync.RWMutex
 
+	// Guests who haven't played yet: userID -> token. Their sessions are only kept in memory
+	// until they play, so visitors who never do don't fill the store.
+	unsavedGuests map[string]string
+
+	store storage.Store
+
 	// GitHub ID to UserID mapping for consistent user IDs across logins
 	githubToUserID   map[int64]string
 	githubToUserIDMu sync.RWMutex
@@ -51,6 +66,15 @@ type Handler struct {
 	// BlueSky DID to UserID mapping for consistent user IDs across logins
 	blueskyToUserID   map[string]string
 	blueskyToUserIDMu sync.RWMutex
+
+	// Looks up a signed-in user's achievements for /api/me (nil = not shown)
+	achievementLookup func(userID string) *types.UserAchievements
+}
+
+// meResponse is the current user, along with their achievements if they're signed in
+type meResponse struct {
+	*UserInfo
+	Achievements *types.UserAchievements `json:"achievements,omitempty"`
 }
 
 // LoadConfig loads OAuth configuration from environment variables
@@ -67,8 +91,8 @@ func LoadConfig() *Config {
 	}
 }
 
-// NewHandler creates a new auth handler
-func NewHandler(cfg *Config) *Handler {
+// NewHandler creates a new auth handler, restoring the sessions saved in the store
+func NewHandler(cfg *Config, store storage.Store) *Handler {
 	var oauthConfig *oauth2.Config
 
 	// Only configure OAuth if credentials are provided
@@ -82,12 +106,167 @@ func NewHandler(cfg *Config) *Handler {
 		}
 	}
 
-	return &Handler{
+	h := &Handler{
 		config:          cfg,
 		oauthConfig:     oauthConfig,
-		sessions:        make(map[string]*UserInfo),
+		sessions:        make(map[string]*session),
+		unsavedGuests:   make(map[string]string),
 		githubToUserID:  make(map[int64]string),
 		blueskyToUserID: make(map[string]string),
+		store:           store,
+	}
+	h.loadSessions()
+	return h
+}
+
+// session is a signed-in or guest user's session
+type session struct {
+	user      *UserInfo
+	expiresAt int64 // Unix timestamp, matching the cookie's expiry
+}
+
+// storedSession is a session as saved in the store
+type storedSession struct {
+	User      UserInfo `json:"user"`
+	ExpiresAt int64    `json:"expiresAt"` // Unix timestamp, matching the cookie's expiry
+}
+
+// storeSession saves a session in memory and in the store. Guest sessions are only saved to the
+// store once the guest plays (see SaveGuestSession).
+func (h *Handler) storeSession(token string, userInfo *UserInfo, maxAge int) {
+	s := &session{
+		user:      userInfo,
+		expiresAt: time.Now().Add(time.Duration(maxAge) * time.Second).Unix(),
+	}
+
+	h.sessionsMu.Lock()
+	h.sessions[token] = s
+	if userInfo.IsGuest {
+		h.unsavedGuests[userInfo.UserID] = token
+	}
+	h.sessionsMu.Unlock()
+
+	if !userInfo.IsGuest {
+		h.saveSession(token, s)
+	}
+}
+
+// saveSession writes a session to the store
+func (h *Handler) saveSession(token string, s *session) {
+	stored := storedSession{
+		User:      *s.user,
+		ExpiresAt: s.expiresAt,
+	}
+	if err := storage.PutJSON(h.store, storage.BucketSessions, token, stored); err != nil {
+		log.Printf("Error saving session for %s: %v", s.user.DisplayName, err)
+	}
+}
+
+// SaveGuestSession saves a guest's session to the store the first time they play, so they keep
+// their guest identity (and match history) across restarts
+func (h *Handler) SaveGuestSession(userID string) {
+	h.sessionsMu.Lock()
+	token, unsaved := h.unsavedGuests[userID]
+	s := h.sessions[token]
+	delete(h.unsavedGuests, userID)
+	h.sessionsMu.Unlock()
+
+	if unsaved && s != nil {
+		h.saveSession(token, s)
+	}
+}
+
+// deleteSession removes a session from memory and from the store
+func (h *Handler) deleteSession(token string) {
+	h.sessionsMu.Lock()
+	if s, exists := h.sessions[token]; exists {
+		delete(h.unsavedGuests, s.user.UserID)
+	}
+	delete(h.sessions, token)
+	h.sessionsMu.Unlock()
+
+	if err := h.store.Delete(storage.BucketSessions, token); err != nil {
+		log.Printf("Error deleting session: %v", err)
+	}
+}
+
+// lookupSession returns the user of an unexpired session, or nil
+func (h *Handler) lookupSession(token string) *UserInfo {
+	h.sessionsMu.RLock()
+	defer h.sessionsMu.RUnlock()
+
+	s, exists := h.sessions[token]
+	if !exists || s.expiresAt < time.Now().Unix() {
+		return nil
+	}
+	return s.user
+}
+
+// loadSessions restores unexpired sessions from the store and deletes expired ones
+func (h *Handler) loadSessions() {
+	now := time.Now().Unix()
+	var expired []string
+	err := h.store.ForEach(storage.BucketSessions, func(token string, value []byte) error {
+		var stored storedSession
+		if err := json.Unmarshal(value, &stored); err != nil || stored.ExpiresAt < now {
+			expired = append(expired, token)
+			return nil
+		}
+		userInfo := stored.User
+		h.sessions[token] = &session{user: &userInfo, expiresAt: stored.ExpiresAt}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error loading sessions: %v", err)
+	}
+
+	h.deleteStoredSessions(expired)
+	log.Printf("Restored %d sessions (%d expired)", len(h.sessions), len(expired))
+}
+
+// deleteStoredSessions removes sessions from the store in one transaction
+func (h *Handler) deleteStoredSessions(tokens []string) {
+	err := h.store.Update(func(tx storage.Tx) error {
+		for _, token := range tokens {
+			if err := tx.Delete(storage.BucketSessions, token); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error deleting expired sessions: %v", err)
+	}
+}
+
+// RunSessionSweeper periodically removes expired sessions from memory and the store.
+// Runs until the process exits.
+func (h *Handler) RunSessionSweeper() {
+	ticker := time.NewTicker(types.SessionSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		<-ticker.C
+		h.sweepSessions(time.Now())
+	}
+}
+
+// sweepSessions removes the sessions that have expired
+func (h *Handler) sweepSessions(now time.Time) {
+	var expired []string
+	h.sessionsMu.Lock()
+	for token, s := range h.sessions {
+		if s.expiresAt < now.Unix() {
+			expired = append(expired, token)
+			delete(h.unsavedGuests, s.user.UserID)
+			delete(h.sessions, token)
+		}
+	}
+	h.sessionsMu.Unlock()
+
+	if len(expired) > 0 {
+		h.deleteStoredSessions(expired)
+		log.Printf("Removed %d expired sessions", len(expired))
 	}
 }
 
@@ -158,9 +337,7 @@ func (h *Handler) HandleCallback(w http.ResponseWriter, r *http.Request) {
 
 	// Generate auth token and store session
 	authToken := uuid.New().String()
-	h.sessionsMu.Lock()
-	h.sessions[authToken] = userInfo
-	h.sessionsMu.Unlock()
+	h.storeSession(authToken, userInfo, authSessionMaxAge)
 
 	// Set auth token cookie
 	http.SetCookie(w, &http.Cookie{
@@ -169,7 +346,7 @@ func (h *Handler) HandleCallback(w http.ResponseWriter, r *http.Request) {
 		Path:     "/",
 		HttpOnly: true,
 		Secure:   false, // Set to true in production with HTTPS
-		MaxAge:   604800, // 7 days
+		MaxAge:   authSessionMaxAge,
 		SameSite: http.SameSiteLaxMode,
 	})
 
@@ -181,16 +358,12 @@ func (h *Handler) HandleCallback(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) HandleLogout(w http.ResponseWriter, r *http.Request) {
 	// Remove auth session from server
 	if cookie, err := r.Cookie("auth_token"); err == nil {
-		h.sessionsMu.Lock()
-		delete(h.sessions, cookie.Value)
-		h.sessionsMu.Unlock()
+		h.deleteSession(cookie.Value)
 	}
 
 	// Remove guest session from server
 	if cookie, err := r.Cookie("guest_token"); err == nil {
-		h.sessionsMu.Lock()
-		delete(h.sessions, cookie.Value)
-		h.sessionsMu.Unlock()
+		h.deleteSession(cookie.Value)
 	}
 
 	// Clear auth cookie
@@ -213,13 +386,22 @@ func (h *Handler) HandleLogout(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(`{"success": true}`))
 }
 
+// SetAchievementLookup sets how /api/me looks up a signed-in user's achievements
+func (h *Handler) SetAchievementLookup(lookup func(userID string) *types.UserAchievements) {
+	h.achievementLookup = lookup
+}
+
 // HandleMe returns the current user info (creates guest session if needed)
 func (h *Handler) HandleMe(w http.ResponseWriter, r *http.Request) {
 	// First check for authenticated user
 	userInfo := h.GetUserFromRequest(r)
 	if userInfo != nil {
+		response := meResponse{UserInfo: userInfo}
+		if h.achievementLookup != nil && !userInfo.IsGuest {
+			response.Achievements = h.achievementLookup(userInfo.UserID)
+		}
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(userInfo)
+		json.NewEncoder(w).Encode(response)
 		return
 	}
 
@@ -239,17 +421,9 @@ func (h *Handler) GetUserFromRequest(r *http.Request) *UserInfo {
 	return h.GetUserFromToken(cookie.Value)
 }
 
-// GetUserFromToken looks up user info from auth token
+// GetUserFromToken looks up user info from auth token (nil if the session has expired)
 func (h *Handler) GetUserFromToken(authToken string) *UserInfo {
-	h.sessionsMu.RLock()
-	defer h.sessionsMu.RUnlock()
-
-	userInfo, exists := h.sessions[authToken]
-	if !exists {
-		return nil
-	}
-
-	return userInfo
+	return h.lookupSession(authToken)
 }
 
 // GenerateGuestName creates a random guest name
@@ -271,11 +445,7 @@ func (h *Handler) GetOrCreateGuestUser(r *http.Request, w http.ResponseWriter) *
 	// Check for existing guest token
 	cookie, err := r.Cookie("guest_token")
 	if err == nil && cookie.Value != "" {
-		h.sessionsMu.RLock()
-		userInfo, exists := h.sessions[cookie.Value]
-		h.sessionsMu.RUnlock()
-
-		if exists && userInfo.IsGuest {
+		if userInfo := h.lookupSession(cookie.Value); userInfo != nil && userInfo.IsGuest {
 			return userInfo
 		}
 	}
@@ -285,18 +455,16 @@ func (h *Handler) GetOrCreateGuestUser(r *http.Request, w http.ResponseWriter) *
 
 	// Generate guest token and store session
 	guestToken := uuid.New().String()
-	h.sessionsMu.Lock()
-	h.sessions[guestToken] = guestUser
-	h.sessionsMu.Unlock()
+	h.storeSession(guestToken, guestUser, guestSessionMaxAge)
 
-	// Set guest token cookie (persists for 30 days)
+	// Set guest token cookie
 	http.SetCookie(w, &http.Cookie{
 		Name:     "guest_token",
 		Value:    guestToken,
 		Path:     "/",
 		HttpOnly: true,
 		Secure:   false, // Set to true in production with HTTPS
-		MaxAge:   2592000, // 30 days
+		MaxAge:   guestSessionMaxAge,
 		SameSite: http.SameSiteLaxMode,
 	})
 
@@ -312,11 +480,8 @@ func (h *Handler) GetGuestFromRequest(r *http.Request) *UserInfo {
 		return nil
 	}
 
-	h.sessionsMu.RLock()
-	defer h.sessionsMu.RUnlock()
-
-	userInfo, exists := h.sessions[cookie.Value]
-	if !exists || !userInfo.IsGuest {
+	userInfo := h.lookupSession(cookie.Value)
+	if userInfo == nil || !userInfo.IsGuest {
 		return nil
 	}
 
@@ -367,9 +532,7 @@ func (h *Handler) HandleBlueSkyLogin(w http.ResponseWriter, r *http.Request) {
 
 	// Generate auth token and store session
 	authToken := uuid.New().String()
-	h.sessionsMu.Lock()
-	h.sessions[authToken] = userInfo
-	h.sessionsMu.Unlock()
+	h.storeSession(authToken, userInfo, authSessionMaxAge)
 
 	// Set auth token cookie
 	http.SetCookie(w, &http.Cookie{
@@ -378,7 +541,7 @@ func (h *Handler) HandleBlueSkyLogin(w http.ResponseWriter, r *http.Request) {
 		Path:     "/",
 		HttpOnly: true,
 		Secure:   false, // Set to true in production with HTTPS
-		MaxAge:   604800, // 7 days
+		MaxAge:   authSessionMaxAge,
 		SameSite: http.SameSiteLaxMode,
 	})
 
@@ -521,7 +684,7 @@ func (h *Handler) getOrCreateBlueSkyUserID(did string) string {
 		return userID
 	}
 
-	userID := uuid.New().String()
+	userID := h.loadOrSaveIdentity("bluesky:" + did)
 	h.blueskyToUserID[did] = userID
 	return userID
 }
@@ -562,11 +725,35 @@ func (h *Handler) getOrCreateUserID(githubID int64) string {
 		return userID
 	}
 
-	userID := uuid.New().String()
+	userID := h.loadOrSaveIdentity(fmt.Sprintf("github:%d", githubID))
 	h.githubToUserID[githubID] = userID
 	return userID
 }
 
+// loadOrSaveIdentity returns the UserID saved for an account's provider ID, saving a new one
+// if the account hasn't signed in before
+func (h *Handler) loadOrSaveIdentity(providerID string) string {
+	userID, err := h.store.Get(storage.BucketIdentities, providerID)
+	if err == nil {
+		return string(userID)
+	}
+	if err != storage.ErrNotFound {
+		log.Printf("Error reading identity %s: %v", providerID, err)
+	}
+
+	newUserID := stableUserID(providerID)
+	if err := h.store.Put(storage.BucketIdentities, providerID, []byte(newUserID)); err != nil {
+		log.Printf("Error saving identity %s: %v", providerID, err)
+	}
+	return newUserID
+}
+
+// stableUserID derives a UserID from the account's provider ID, so it's the same after a server
+// restart and stats keyed by it (such as the leaderboard) stay with the account
+func stableUserID(providerID string) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(providerID)).String()
+}
+
 // generateRandomString creates a random hex string
 func generateRandomString(length int) string {
 	bytes := make([]byte, length)
@@ -0,0 +1,378 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/tombuildsstuff/web-arena-game/server/internal/storage"
+	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
+)
+
+// achievementRule tracks one team's progress towards an achievement during a match
+type achievementRule interface {
+	// observe updates the progress with a game event
+	observe(event types.GameEvent)
+
+	// earned returns true if the team earned the achievement (asked once the match has ended)
+	earned() bool
+}
+
+// achievementDefinition is an achievement together with the rule that awards it
+type achievementDefinition struct {
+	types.Achievement
+	newRule func(playerID int) achievementRule
+}
+
+// achievementDefinitions are every achievement that can be unlocked
+var achievementDefinitions = []achievementDefinition{
+	{
+		Achievement: types.Achievement{
+			ID:          "first_victory",
+			Name:        "First Victory",
+			Description: "Win a match",
+		},
+		newRule: func(playerID int) achievementRule {
+			return &winRule{playerID: playerID}
+		},
+	},
+	{
+		Achievement: types.Achievement{
+			ID:          "flawless_defence",
+			Name:        "Flawless Defence",
+			Description: "Win a match after claiming a turret, without losing a turret",
+			Color:       "#eab308", // Gold
+		},
+		newRule: func(playerID int) achievementRule {
+			return &flawlessDefenceRule{playerID: playerID}
+		},
+	},
+	{
+		Achievement: types.Achievement{
+			ID:          "marksman",
+			Name:        "Marksman",
+			Description: "Get 10 kills with snipers in one match",
+			Color:       "#22c55e", // Green
+		},
+		newRule: func(playerID int) achievementRule {
+			return &killsByUnitRule{playerID: playerID, unitType: "sniper", target: 10}
+		},
+	},
+	{
+		Achievement: types.Achievement{
+			ID:          "heavy_metal",
+			Name:        "Heavy Metal",
+			Description: "Destroy the enemy base with a super tank",
+			Color:       "#a855f7", // Purple
+		},
+		newRule: func(playerID int) achievementRule {
+			return &baseDestroyedByRule{playerID: playerID, unitType: "super_tank"}
+		},
+	},
+}
+
+// ListAchievements returns every achievement that can be unlocked
+func ListAchievements() []types.Achievement {
+	list := make([]types.Achievement, len(achievementDefinitions))
+	for i, definition := range achievementDefinitions {
+		list[i] = definition.Achievement
+	}
+	return list
+}
+
+// findAchievement returns an achievement's definition by ID, or nil if there isn't one
+func findAchievement(id string) *achievementDefinition {
+	for i := range achievementDefinitions {
+		if achievementDefinitions[i].ID == id {
+			return &achievementDefinitions[i]
+		}
+	}
+	return nil
+}
+
+// winRule is earned by winning the match
+type winRule struct {
+	playerID int
+	won      bool
+}
+
+func (r *winRule) observe(event types.GameEvent) {
+	if event.Type == types.GameEventMatchEnded && event.PlayerID == r.playerID {
+		r.won = true
+	}
+}
+
+func (r *winRule) earned() bool {
+	return r.won
+}
+
+// flawlessDefenceRule is earned by winning after claiming at least one turret and never losing one
+type flawlessDefenceRule struct {
+	playerID int
+	claimed  bool
+	lost     bool
+	won      bool
+}
+
+func (r *flawlessDefenceRule) observe(event types.GameEvent) {
+	switch event.Type {
+	case types.GameEventStructureClaimed:
+		if event.PlayerID == r.playerID && event.TargetType == "turret" {
+			r.claimed = true
+		}
+	case types.GameEventStructureDestroyed:
+		if event.TargetPlayerID == r.playerID && event.TargetType == "turret" {
+			r.lost = true
+		}
+	case types.GameEventMatchEnded:
+		r.won = event.PlayerID == r.playerID
+	}
+}
+
+func (r *flawlessDefenceRule) earned() bool {
+	return r.won && r.claimed && !r.lost
+}
+
+// killsByUnitRule is earned by killing enough enemy units with one unit type
+type killsByUnitRule struct {
+	playerID int
+	unitType string
+	target   int
+	kills    int
+}
+
+func (r *killsByUnitRule) observe(event types.GameEvent) {
+	if event.Type == types.GameEventUnitKilled && event.PlayerID == r.playerID && event.UnitType == r.unitType {
+		r.kills++
+	}
+}
+
+func (r *killsByUnitRule) earned() bool {
+	return r.kills >= r.target
+}
+
+// baseDestroyedByRule is earned by landing the shot that destroys the enemy base with one unit type
+type baseDestroyedByRule struct {
+	playerID  int
+	unitType  string
+	destroyed bool
+}
+
+func (r *baseDestroyedByRule) observe(event types.GameEvent) {
+	if event.Type == types.GameEventBaseDestroyed && event.PlayerID == r.playerID && event.UnitType == r.unitType {
+		r.destroyed = true
+	}
+}
+
+func (r *baseDestroyedByRule) earned() bool {
+	return r.destroyed
+}
+
+// trackedRule is a team's progress towards one achievement
+type trackedRule struct {
+	achievementID string
+	rule          achievementRule
+}
+
+// AchievementTracker watches a room's game events and unlocks the achievements its
+// signed-in players earn once the match ends
+type AchievementTracker struct {
+	matchID      string
+	userIDs      [2][]string // Signed-in (non-guest, human) players on each team
+	rules        [2][]trackedRule
+	achievements *Achievements
+}
+
+// NewAchievementTracker creates a tracker for the players in a room. Subscribe it to the room.
+func NewAchievementTracker(room *GameRoom, achievements *Achievements) *AchievementTracker {
+	t := &AchievementTracker{
+		matchID:      room.ID,
+		achievements: achievements,
+	}
+	for playerID, player := range room.State.Players {
+		for _, member := range player.Members {
+			if member.UserID != "" && !member.IsGuest {
+				t.userIDs[playerID] = append(t.userIDs[playerID], member.UserID)
+			}
+		}
+		for _, definition := range achievementDefinitions {
+			t.rules[playerID] = append(t.rules[playerID], trackedRule{
+				achievementID: definition.ID,
+				rule:          definition.newRule(playerID),
+			})
+		}
+	}
+	return t
+}
+
+// OnGameEvent updates each team's progress, and unlocks what was earned when the match ends
+func (t *AchievementTracker) OnGameEvent(event types.GameEvent) {
+	for _, rules := range t.rules {
+		for _, tracked := range rules {
+			tracked.rule.observe(event)
+		}
+	}
+
+	if event.Type != types.GameEventMatchEnded {
+		return
+	}
+
+	now := time.Now()
+	for playerID, rules := range t.rules {
+		var earned []string
+		for _, tracked := range rules {
+			if tracked.rule.earned() {
+				earned = append(earned, tracked.achievementID)
+			}
+		}
+		if len(earned) == 0 {
+			continue
+		}
+		for _, userID := range t.userIDs[playerID] {
+			t.achievements.Unlock(userID, earned, t.matchID, now)
+		}
+	}
+}
+
+// ErrColorLocked is returned when a player picks a colour they haven't unlocked
+var ErrColorLocked = errors.New("colour not unlocked")
+
+// storedAchievements is a player's unlocks as saved in the store
+type storedAchievements struct {
+	Unlocked []types.UnlockedAchievement `json:"unlocked"`
+	Color    string                      `json:"color,omitempty"`
+}
+
+// Achievements keeps each player's unlocked achievements and chosen cosmetics in the store.
+// Each change reads and writes a player's record in one transaction, so unlocks saved in the
+// background never overwrite a colour chosen at the same time (or the other way round).
+type Achievements struct {
+	store  storage.Store
+	writer *storage.Writer // Saves unlocks without holding up the room that awarded them
+}
+
+// NewAchievements creates the achievement records backed by the store, saving unlocks through writer
+func NewAchievements(store storage.Store, writer *storage.Writer) *Achievements {
+	return &Achievements{
+		store:  store,
+		writer: writer,
+	}
+}
+
+// Get returns a player's unlocks and cosmetics (empty for players who haven't unlocked anything)
+func (a *Achievements) Get(userID string) *types.UserAchievements {
+	stored := a.loadUnlocked(userID)
+	result := &types.UserAchievements{
+		Unlocked: stored.Unlocked,
+		Colors:   unlockedColors(stored.Unlocked),
+		Color:    stored.Color,
+	}
+	if result.Unlocked == nil {
+		result.Unlocked = []types.UnlockedAchievement{}
+	}
+	return result
+}
+
+// Color returns the player colour a player has chosen, or an empty string for the map's colour
+func (a *Achievements) Color(userID string) string {
+	if userID == "" {
+		return ""
+	}
+	return a.loadUnlocked(userID).Color
+}
+
+// SetColor chooses the player colour used in a player's future matches. The colour must have
+// been unlocked, or be empty to go back to the map's colour.
+func (a *Achievements) SetColor(userID string, color string) error {
+	err := a.store.Update(func(tx storage.Tx) error {
+		stored, err := loadUnlockedTx(tx, userID)
+		if err != nil {
+			return err
+		}
+		if color != "" {
+			unlocked := false
+			for _, c := range unlockedColors(stored.Unlocked) {
+				unlocked = unlocked || c == color
+			}
+			if !unlocked {
+				return ErrColorLocked
+			}
+		}
+
+		stored.Color = color
+		return storage.PutJSONTx(tx, storage.BucketAchievements, userID, stored)
+	})
+	if err != nil && err != ErrColorLocked {
+		return fmt.Errorf("saving colour: %w", err)
+	}
+	return err
+}
+
+// Unlock records the achievements a player earned in a match, ignoring any they already have.
+// It's called while a room is locked, so the unlocks are saved in the background.
+func (a *Achievements) Unlock(userID string, achievementIDs []string, matchID string, now time.Time) {
+	a.writer.Queue("achievements for "+userID, func(tx storage.Tx) error {
+		stored, err := loadUnlockedTx(tx, userID)
+		if err != nil {
+			return err
+		}
+		have := make(map[string]bool, len(stored.Unlocked))
+		for _, unlocked := range stored.Unlocked {
+			have[unlocked.ID] = true
+		}
+
+		var added []string
+		for _, id := range achievementIDs {
+			if have[id] {
+				continue
+			}
+			stored.Unlocked = append(stored.Unlocked, types.UnlockedAchievement{
+				ID:         id,
+				MatchID:    matchID,
+				UnlockedAt: now.Unix(),
+			})
+			added = append(added, id)
+		}
+		if len(added) == 0 {
+			return nil
+		}
+
+		if err := storage.PutJSONTx(tx, storage.BucketAchievements, userID, stored); err != nil {
+			return err
+		}
+		log.Printf("Player %s unlocked %v", userID, added)
+		return nil
+	})
+}
+
+// loadUnlocked reads a player's record from the store
+func (a *Achievements) loadUnlocked(userID string) storedAchievements {
+	var stored storedAchievements
+	err := storage.GetJSON(a.store, storage.BucketAchievements, userID, &stored)
+	if err != nil && err != storage.ErrNotFound {
+		log.Printf("Error reading achievements for %s: %v", userID, err)
+	}
+	return stored
+}
+
+// loadUnlockedTx reads a player's record within a transaction
+func loadUnlockedTx(tx storage.Tx, userID string) (storedAchievements, error) {
+	var stored storedAchievements
+	err := storage.GetJSONTx(tx, storage.BucketAchievements, userID, &stored)
+	if err != nil && err != storage.ErrNotFound {
+		return stored, fmt.Errorf("reading achievements for %s: %w", userID, err)
+	}
+	return stored, nil
+}
+
+// unlockedColors returns the player colours unlocked by a set of achievements
+func unlockedColors(unlocked []types.UnlockedAchievement) []string {
+	colors := make([]string, 0)
+	for _, achievement := range unlocked {
+		if definition := findAchievement(achievement.ID); definition != nil && definition.Color != "" {
+			colors = append(colors, definition.Color)
+		}
+	}
+	return colors
+}
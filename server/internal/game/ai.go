@@ -2,45 +2,43 @@ package game
 
 import (
 	"math"
-	"math/rand"
-	"time"
 
 	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
 )
 
 // AIController manages AI decision-making for a computer player
 type AIController struct {
-	playerID       int
+	seat           int    // Seat whose PlayerUnit this controller drives
+	playerID       int    // Team the seat plays for
 	difficulty     string // "easy", "medium", "hard"
-	lastDecision   time.Time
-	decisionDelay  time.Duration
-	lastPurchase   time.Time
-	purchaseDelay  time.Duration
+	lastDecision   int64  // Simulation time in millis (0 until the first update)
+	decisionDelay  int64  // Milliseconds
+	lastPurchase   int64  // Simulation time in millis (0 until the first update)
+	purchaseDelay  int64  // Milliseconds
 	currentTarget  *types.Vector3
-	targetUpdateAt time.Time
+	waypoints      []types.Vector3 // Path to currentTarget around obstacles
+	targetUpdateAt int64 // Simulation time in millis
 }
 
-// NewAIController creates a new AI controller for a player
-func NewAIController(playerID int, difficulty string) *AIController {
+// NewAIController creates a new AI controller for a seat (the player ID in 1v1)
+func NewAIController(seat int, difficulty string) *AIController {
 	ai := &AIController{
-		playerID:      playerID,
-		difficulty:    difficulty,
-		lastDecision:  time.Now(),
-		lastPurchase:  time.Now(),
-		targetUpdateAt: time.Now(),
+		seat:       seat,
+		playerID:   TeamForSeat(seat),
+		difficulty: difficulty,
 	}
 
 	// Set delays based on difficulty
 	switch difficulty {
 	case "easy":
-		ai.decisionDelay = 500 * time.Millisecond
-		ai.purchaseDelay = 5 * time.Second
+		ai.decisionDelay = 500
+		ai.purchaseDelay = 5000
 	case "hard":
-		ai.decisionDelay = 100 * time.Millisecond
-		ai.purchaseDelay = 2 * time.Second
+		ai.decisionDelay = 100
+		ai.purchaseDelay = 2000
 	default: // medium
-		ai.decisionDelay = 250 * time.Millisecond
-		ai.purchaseDelay = 3 * time.Second
+		ai.decisionDelay = 250
+		ai.purchaseDelay = 3000
 	}
 
 	return ai
@@ -48,16 +46,22 @@ func NewAIController(playerID int, difficulty string) *AIController {
 
 // Update runs AI decision-making for one tick
 func (ai *AIController) Update(state *State, room *GameRoom) {
-	now := time.Now()
+	now := state.Now()
+
+	// Start the decision timers from the first tick of the match
+	if ai.lastDecision == 0 {
+		ai.lastDecision = now
+		ai.lastPurchase = now
+	}
 
 	// Check if it's time to make decisions
-	if now.Sub(ai.lastDecision) < ai.decisionDelay {
+	if now-ai.lastDecision < ai.decisionDelay {
 		return
 	}
 	ai.lastDecision = now
 
 	// Get AI's player unit
-	playerUnit := state.GetPlayerUnit(ai.playerID)
+	playerUnit := state.GetSeatUnit(ai.seat)
 	if playerUnit == nil {
 		return
 	}
@@ -81,41 +85,41 @@ func (ai *AIController) Update(state *State, room *GameRoom) {
 }
 
 // decidePurchases handles AI economy decisions
-func (ai *AIController) decidePurchases(state *State, room *GameRoom, now time.Time) {
-	if now.Sub(ai.lastPurchase) < ai.purchaseDelay {
+func (ai *AIController) decidePurchases(state *State, room *GameRoom, now int64) {
+	if now-ai.lastPurchase < ai.purchaseDelay {
 		return
 	}
 
-	player := state.GetPlayer(ai.playerID)
-	if player == nil {
+	wallet := state.WalletFor(ai.seat)
+	if wallet == nil {
 		return
 	}
 
 	// Priority: Buy tanks if we can afford them
-	if player.CanAfford(types.TankCost) {
+	if wallet.CanAfford(types.TankCost) {
 		// Randomly choose between base and owned forward zones
 		var zoneID string
 		ownedZones := ai.getOwnedBuyZones(state)
 
-		if len(ownedZones) > 0 && rand.Float32() < 0.5 {
+		if len(ownedZones) > 0 && state.Rand.Float32() < 0.5 {
 			// Buy from a forward zone
-			zone := ownedZones[rand.Intn(len(ownedZones))]
+			zone := ownedZones[state.Rand.Intn(len(ownedZones))]
 			zoneID = zone.ID
 		}
 
 		if zoneID != "" {
 			// Buy from zone
-			ai.buyFromZone(state, room, player, zoneID)
+			ai.buyFromZone(state, room, wallet, zoneID)
 		} else {
 			// Buy from base
-			ai.purchaseFromBase(state, room, player, "tank")
+			ai.purchaseFromBase(state, room, wallet, "tank")
 		}
 		ai.lastPurchase = now
 	}
 }
 
 // purchaseFromBase purchases a unit from the player's base
-func (ai *AIController) purchaseFromBase(state *State, room *GameRoom, player *Player, unitType string) {
+func (ai *AIController) purchaseFromBase(state *State, room *GameRoom, wallet Wallet, unitType string) {
 	var cost int
 	switch unitType {
 	case "tank":
@@ -130,13 +134,14 @@ func (ai *AIController) purchaseFromBase(state *State, room *GameRoom, player *P
 		return
 	}
 
-	if !player.CanAfford(cost) {
+	if !wallet.CanAfford(cost) {
 		return
 	}
 
-	player.Spend(cost)
+	state.Spend(ai.seat, cost)
+	state.RecordPurchase(ai.seat, unitType, 1)
 
-	spawnPos := player.BasePosition
+	spawnPos := state.Players[ai.playerID].BasePosition
 	targetPos := state.Players[1-ai.playerID].BasePosition
 
 	var unit Unit
@@ -161,7 +166,7 @@ func (ai *AIController) purchaseFromBase(state *State, room *GameRoom, player *P
 }
 
 // buyFromZone purchases from a forward buy zone
-func (ai *AIController) buyFromZone(state *State, room *GameRoom, player *Player, zoneID string) {
+func (ai *AIController) buyFromZone(state *State, room *GameRoom, wallet Wallet, zoneID string) {
 	var zone *BuyZone
 	for _, z := range state.BuyZones {
 		if z.ID == zoneID && z.OwnerID == ai.playerID {
@@ -170,7 +175,7 @@ func (ai *AIController) buyFromZone(state *State, room *GameRoom, player *Player
 		}
 	}
 
-	if zone == nil || !player.CanAfford(zone.Cost) {
+	if zone == nil || !wallet.CanAfford(zone.Cost) {
 		return
 	}
 
@@ -181,7 +186,8 @@ func (ai *AIController) buyFromZone(state *State, room *GameRoom, player *Player
 		}
 	}
 
-	player.Spend(zone.Cost)
+	state.Spend(ai.seat, zone.Cost)
+	state.RecordPurchase(ai.seat, zone.UnitType, 1)
 
 	spawnPos := zone.Position
 	targetPos := state.Players[1-ai.playerID].BasePosition
@@ -193,7 +199,7 @@ func (ai *AIController) buyFromZone(state *State, room *GameRoom, player *Player
 		spawnPos.Y = types.AirplaneYPosition
 	}
 
-	state.SpawnQueue.Add(zone.UnitType, ai.playerID, spawnPos, targetPos, zoneID)
+	state.SpawnQueue.Add(zone.UnitType, ai.playerID, spawnPos, targetPos, zoneID, state.Now())
 }
 
 // getOwnedBuyZones returns buy zones owned by the AI (only unit purchase zones, not base zones)
@@ -216,27 +222,29 @@ func (ai *AIController) decideClaimActions(state *State, room *GameRoom, playerU
 	for _, turret := range state.Turrets {
 		if turret.CanBeClaimed(ai.playerID) && turret.IsPlayerInRange(pos) {
 			turret.Claim(ai.playerID)
-			// Reward AI for claiming turret
+			state.recordClaim(ai.playerID, ai.seat, "player", "turret", turret.ID)
+			// Reward AI's team for claiming turret
 			player := state.GetPlayer(ai.playerID)
 			if player != nil {
-				player.Money += types.TurretClaimReward
+				player.AddMoney(types.TurretClaimReward)
 			}
 			return // One action per decision cycle
 		}
 	}
 
 	// Check for claimable buy zones (forward bases) nearby
-	player := state.GetPlayer(ai.playerID)
-	if player == nil {
+	wallet := state.WalletFor(ai.seat)
+	if wallet == nil {
 		return
 	}
 
 	for _, zone := range state.BuyZones {
 		if zone.CanBeClaimed(ai.playerID) && zone.IsPlayerInRange(pos) {
 			// Check if AI can afford the claim cost
-			if player.Money >= zone.ClaimCost {
-				player.Money -= zone.ClaimCost
+			if wallet.CanAfford(zone.ClaimCost) {
+				state.Spend(ai.seat, zone.ClaimCost)
 				zone.Claim(ai.playerID)
+				state.recordClaim(ai.playerID, ai.seat, "player", "buy_zone", zone.ID)
 
 				// If this is a forward base, also claim all child zones
 				if zone.UnitType == "" && zone.IsClaimable {
@@ -258,7 +266,7 @@ func (ai *AIController) decideShootAction(state *State, room *GameRoom, playerUn
 	attackRange := playerUnit.GetAttackRange()
 
 	// Check attack cooldown
-	now := time.Now().UnixMilli()
+	now := state.Now()
 	timeSinceLastAttack := now - playerUnit.GetLastAttackTime()
 	attackCooldown := int64(1000.0 / playerUnit.GetAttackSpeed())
 	if timeSinceLastAttack < attackCooldown {
@@ -301,8 +309,21 @@ func (ai *AIController) decideShootAction(state *State, room *GameRoom, playerUn
 		}
 	}
 
+	// Fall back to sieging the enemy base
+	var enemyBase *Base
+	if closestTurret == nil && closestEnemy == nil {
+		base := state.Bases[1-ai.playerID]
+		if base.IsAlive() && base.IsInAttackRange(pos, attackRange) {
+			enemyBase = base
+		}
+	}
+
 	// Shoot at target
-	if closestTurret != nil {
+	if enemyBase != nil {
+		projectile := NewProjectileToBase(playerUnit, enemyBase, now)
+		state.AddProjectile(projectile)
+		playerUnit.SetLastAttackTime(now)
+	} else if closestTurret != nil {
 		projectile := NewProjectileFromPlayerToTurret(playerUnit, closestTurret, now)
 		state.AddProjectile(projectile)
 		playerUnit.SetLastAttackTime(now)
@@ -310,11 +331,11 @@ func (ai *AIController) decideShootAction(state *State, room *GameRoom, playerUn
 		// Add some inaccuracy based on difficulty
 		targetPos := closestEnemy.GetPosition()
 		if ai.difficulty == "easy" {
-			targetPos.X += (rand.Float64() - 0.5) * 4
-			targetPos.Z += (rand.Float64() - 0.5) * 4
+			targetPos.X += (state.Rand.Float64() - 0.5) * 4
+			targetPos.Z += (state.Rand.Float64() - 0.5) * 4
 		} else if ai.difficulty == "medium" {
-			targetPos.X += (rand.Float64() - 0.5) * 2
-			targetPos.Z += (rand.Float64() - 0.5) * 2
+			targetPos.X += (state.Rand.Float64() - 0.5) * 2
+			targetPos.Z += (state.Rand.Float64() - 0.5) * 2
 		}
 
 		projectile := NewProjectileFromPlayer(playerUnit, targetPos, closestEnemy, now)
@@ -326,12 +347,16 @@ func (ai *AIController) decideShootAction(state *State, room *GameRoom, playerUn
 // decideMovement decides where the AI player should move
 func (ai *AIController) decideMovement(state *State, room *GameRoom, playerUnit *PlayerUnit) {
 	pos := playerUnit.GetPosition()
-	now := time.Now()
+	now := state.Now()
 
 	// Update target periodically or if no target
-	if ai.currentTarget == nil || now.After(ai.targetUpdateAt) {
+	if ai.currentTarget == nil || now > ai.targetUpdateAt {
 		ai.currentTarget = ai.selectMovementTarget(state, pos)
-		ai.targetUpdateAt = now.Add(2 * time.Second)
+		ai.targetUpdateAt = now + 2000
+		ai.waypoints = nil
+		if ai.currentTarget != nil {
+			ai.waypoints = room.pathfindingSystem.FindPath(pos, *ai.currentTarget)
+		}
 	}
 
 	if ai.currentTarget == nil {
@@ -347,11 +372,20 @@ func (ai *AIController) decideMovement(state *State, room *GameRoom, playerUnit
 		return
 	}
 
+	// Follow the path around obstacles, dropping waypoints as they're reached
+	for len(ai.waypoints) > 0 && calculateDistance(pos, ai.waypoints[0]) < 3.0 {
+		ai.waypoints = ai.waypoints[1:]
+	}
+	next := *ai.currentTarget
+	if len(ai.waypoints) > 0 {
+		next = ai.waypoints[0]
+	}
+
 	// Move toward target
 	dir := types.Vector3{
-		X: ai.currentTarget.X - pos.X,
+		X: next.X - pos.X,
 		Y: 0,
-		Z: ai.currentTarget.Z - pos.Z,
+		Z: next.Z - pos.Z,
 	}
 
 	// Normalize
@@ -366,6 +400,11 @@ func (ai *AIController) decideMovement(state *State, room *GameRoom, playerUnit
 
 // selectMovementTarget chooses where the AI should move
 func (ai *AIController) selectMovementTarget(state *State, currentPos types.Vector3) *types.Vector3 {
+	// Priority 0: Play the game mode's objective (hills, flags)
+	if objective := state.Mode.ObjectiveFor(state, ai.seat, currentPos); objective != nil {
+		return objective
+	}
+
 	// Priority 1: Claim nearby neutral turrets
 	for _, turret := range state.Turrets {
 		if turret.CanBeClaimed(ai.playerID) {
@@ -408,9 +447,9 @@ func (ai *AIController) selectMovementTarget(state *State, currentPos types.Vect
 	// Priority 4: Patrol around mid-field area
 	// Random position in the middle area of the map
 	target := types.Vector3{
-		X: (rand.Float64() - 0.5) * 60, // -30 to 30
+		X: (state.Rand.Float64() - 0.5) * 60, // -30 to 30
 		Y: 0,
-		Z: (rand.Float64() - 0.5) * 80, // -40 to 40
+		Z: (state.Rand.Float64() - 0.5) * 80, // -40 to 40
 	}
 	return &target
 }
@@ -21,20 +21,23 @@ type Barracks struct {
 	// Pending scatter - units that need to be scattered after destruction
 	// Populated when barracks is destroyed, cleared by room.go after processing
 	PendingScatter []string
+	ScatterOwnerID int // Who owned the barracks when it was destroyed
+	DestroyedByID  int // Team whose shot destroyed the barracks, credited with the scatter damage (-1 = nobody)
 }
 
 // NewBarracks creates a new barracks
 func NewBarracks(id string, position types.Vector3) *Barracks {
 	return &Barracks{
-		ID:          id,
-		Position:    position,
-		OwnerID:     -1, // Start neutral
-		Health:      types.BarracksHealth,
-		MaxHealth:   types.BarracksHealth,
-		IsDestroyed: false,
-		RespawnTime: 0,
-		ClaimRadius: types.BarracksClaimRadius,
-		Occupants:   make(map[string]float64),
+		ID:            id,
+		Position:      position,
+		OwnerID:       -1, // Start neutral
+		DestroyedByID: -1,
+		Health:        types.BarracksHealth,
+		MaxHealth:     types.BarracksHealth,
+		IsDestroyed:   false,
+		RespawnTime:   0,
+		ClaimRadius:   types.BarracksClaimRadius,
+		Occupants:     make(map[string]float64),
 	}
 }
 
@@ -91,11 +94,13 @@ func (b *Barracks) TakeDamage(amount int) {
 		b.Health = 0
 		b.IsDestroyed = true
 		b.RespawnTime = types.BarracksRespawnTime
-		b.OwnerID = -1 // Reset to neutral when destroyed
 
 		// Store occupants for scatter - room.go will process this
 		b.PendingScatter = b.GetOccupantIDs()
+		b.ScatterOwnerID = b.OwnerID
 		b.ClearOccupants()
+
+		b.OwnerID = -1 // Reset to neutral when destroyed
 	}
 }
 
@@ -173,14 +178,15 @@ func (b *Barracks) ClearOccupants() {
 	b.Occupants = make(map[string]float64)
 }
 
-// GetAndClearPendingScatter returns units that need to scatter and clears the list
-func (b *Barracks) GetAndClearPendingScatter() []string {
+// GetAndClearPendingScatter returns units that need to scatter, and who owned the barracks
+// when it was destroyed, and clears the list
+func (b *Barracks) GetAndClearPendingScatter() ([]string, int) {
 	if len(b.PendingScatter) == 0 {
-		return nil
+		return nil, -1
 	}
 	scattered := b.PendingScatter
 	b.PendingScatter = nil
-	return scattered
+	return scattered, b.ScatterOwnerID
 }
 
 // GetBarracksFromMap creates barracks from a map definition
@@ -0,0 +1,89 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
+)
+
+// Base represents a player's home base, which loses the match when destroyed
+type Base struct {
+	ID        string
+	OwnerID   int
+	Position  types.Vector3
+	Radius    float64
+	Health    int
+	MaxHealth int
+	Armor     int // Flat damage reduction per hit
+}
+
+// NewBaseFromMap creates a player's base using the map's player configuration
+func NewBaseFromMap(ownerID int, mapDef *types.MapDefinition) *Base {
+	playerConfig := mapDef.Players[ownerID]
+
+	health := playerConfig.BaseHealth
+	if health <= 0 {
+		health = types.BaseHealth
+	}
+	armor := playerConfig.BaseArmor
+	if armor <= 0 {
+		armor = types.BaseArmor
+	}
+
+	return &Base{
+		ID:        fmt.Sprintf("base_%d", ownerID),
+		OwnerID:   ownerID,
+		Position:  playerConfig.BasePosition,
+		Radius:    types.BaseRadius,
+		Health:    health,
+		MaxHealth: health,
+		Armor:     armor,
+	}
+}
+
+// ToType converts Base to types.Base for JSON serialization
+func (b *Base) ToType() types.Base {
+	return types.Base{
+		ID:        b.ID,
+		OwnerID:   b.OwnerID,
+		Position:  b.Position,
+		Radius:    b.Radius,
+		Health:    b.Health,
+		MaxHealth: b.MaxHealth,
+		Armor:     b.Armor,
+	}
+}
+
+// IsAlive returns true if the base has not been destroyed
+func (b *Base) IsAlive() bool {
+	return b.Health > 0
+}
+
+// TakeDamage applies siege damage to the base, reduced by its armour (minimum 1 per hit)
+func (b *Base) TakeDamage(amount int) {
+	if !b.IsAlive() {
+		return
+	}
+	damage := amount - b.Armor
+	if damage < 1 {
+		damage = 1
+	}
+	b.Health -= damage
+	if b.Health < 0 {
+		b.Health = 0
+	}
+}
+
+// IsInAttackRange checks if an attacker at pos can hit the base's outer wall
+func (b *Base) IsInAttackRange(pos types.Vector3, attackRange float64) bool {
+	return calculateDistance(pos, b.Position) <= attackRange+b.Radius
+}
+
+// canSiege returns true if a unit type deals siege damage to bases
+func canSiege(unitType string) bool {
+	switch unitType {
+	case "tank", "super_tank", "rocket_launcher", "player":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package game
+
+import (
+	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
+)
+
+// Flag is a Capture the Flag flag, which the enemy PlayerUnit can carry back to its own base
+type Flag struct {
+	OwnerID       int
+	StandPosition types.Vector3
+	Position      types.Vector3
+	CarrierID     string // ID of the PlayerUnit carrying the flag (empty if not carried)
+	DroppedAt     int64  // Simulation time the carrier dropped the flag (0 if at its stand or carried)
+}
+
+// NewFlag creates a flag at its stand
+func NewFlag(ownerID int, standPosition types.Vector3) *Flag {
+	return &Flag{
+		OwnerID:       ownerID,
+		StandPosition: standPosition,
+		Position:      standPosition,
+	}
+}
+
+// ToType converts Flag to types.Flag for JSON serialization
+func (f *Flag) ToType() types.Flag {
+	return types.Flag{
+		OwnerID:       f.OwnerID,
+		StandPosition: f.StandPosition,
+		Position:      f.Position,
+		CarrierID:     f.CarrierID,
+		IsDropped:     f.IsDropped(),
+	}
+}
+
+// IsCarried returns true if a player is carrying the flag
+func (f *Flag) IsCarried() bool {
+	return f.CarrierID != ""
+}
+
+// IsDropped returns true if the flag is lying where its carrier died
+func (f *Flag) IsDropped() bool {
+	return f.DroppedAt != 0
+}
+
+// ReturnToStand puts the flag back on its stand
+func (f *Flag) ReturnToStand() {
+	f.Position = f.StandPosition
+	f.CarrierID = ""
+	f.DroppedAt = 0
+}
+
+// isInReach checks if a position is close enough to pick up or return the flag
+func (f *Flag) isInReach(pos types.Vector3) bool {
+	dx := pos.X - f.Position.X
+	dz := pos.Z - f.Position.Z
+	return dx*dx+dz*dz <= types.FlagPickupRadius*types.FlagPickupRadius
+}
+
+// CaptureTheFlagMode scores a capture each time a player carries the enemy flag back to their base
+type CaptureTheFlagMode struct{}
+
+// Name returns the mode's ID
+func (m *CaptureTheFlagMode) Name() string {
+	return types.GameModeCaptureTheFlag
+}
+
+// Setup places each player's flag on the map's flag stand (or at their base if the map doesn't declare one)
+func (m *CaptureTheFlagMode) Setup(state *State) {
+	for playerID := range state.Flags {
+		standPosition := state.Players[playerID].BasePosition
+		for _, stand := range state.MapDefinition.FlagStands {
+			if stand.OwnerID == playerID {
+				standPosition = stand.Position
+				break
+			}
+		}
+		state.Flags[playerID] = NewFlag(playerID, standPosition)
+	}
+}
+
+// Update handles picking up, carrying, dropping, returning and capturing flags
+func (m *CaptureTheFlagMode) Update(state *State, deltaTime float64) {
+	now := state.Now()
+	returnTime := int64(types.FlagReturnTime * 1000)
+
+	for _, flag := range state.Flags {
+		enemyID := 1 - flag.OwnerID
+
+		if flag.IsCarried() {
+			// The carrier drops the flag where they died
+			carrier, _ := state.GetUnitByID(flag.CarrierID).(*PlayerUnit)
+			if carrier == nil || !carrier.IsAlive() {
+				flag.CarrierID = ""
+				flag.DroppedAt = now
+				continue
+			}
+
+			flag.Position = carrier.GetPosition()
+
+			// Bringing the flag home scores a capture
+			if calculateDistance(flag.Position, state.Players[enemyID].BasePosition) <= types.FlagCaptureRadius {
+				state.ObjectiveScores[enemyID]++
+				flag.ReturnToStand()
+			}
+			continue
+		}
+
+		// Any enemy player picks the flag up (from its stand or where it was dropped)
+		for _, enemyUnit := range state.GetPlayerUnits(enemyID) {
+			if enemyUnit.IsAlive() && flag.isInReach(enemyUnit.GetPosition()) {
+				flag.CarrierID = enemyUnit.GetID()
+				flag.DroppedAt = 0
+				break
+			}
+		}
+
+		if !flag.IsDropped() {
+			continue
+		}
+
+		// A dropped flag goes home when one of its owners touches it or after a while
+		returned := now-flag.DroppedAt >= returnTime
+		for _, ownerUnit := range state.GetPlayerUnits(flag.OwnerID) {
+			if ownerUnit.IsAlive() && flag.isInReach(ownerUnit.GetPosition()) {
+				returned = true
+				break
+			}
+		}
+		if returned {
+			flag.ReturnToStand()
+		}
+	}
+}
+
+// Check returns the first player to reach the capture limit
+func (m *CaptureTheFlagMode) Check(state *State) (bool, int, string) {
+	for playerID, score := range state.ObjectiveScores {
+		if score >= types.CaptureTheFlagCapturesToWin {
+			return true, playerID, "Captured the flag"
+		}
+	}
+	return false, -1, ""
+}
+
+// ScoreToWin returns the number of captures needed to win
+func (m *CaptureTheFlagMode) ScoreToWin() float64 {
+	return types.CaptureTheFlagCapturesToWin
+}
+
+// ObjectiveFor takes a carrier home, sends the player after a dropped or stolen flag of their own,
+// and otherwise goes for the enemy flag
+func (m *CaptureTheFlagMode) ObjectiveFor(state *State, seat int, currentPos types.Vector3) *types.Vector3 {
+	playerID := TeamForSeat(seat)
+	enemyFlag := state.Flags[1-playerID]
+	ownFlag := state.Flags[playerID]
+	playerUnit := state.GetSeatUnit(seat)
+
+	if playerUnit != nil && enemyFlag.CarrierID == playerUnit.GetID() {
+		home := state.Players[playerID].BasePosition
+		return &home
+	}
+	if ownFlag.IsCarried() || ownFlag.IsDropped() {
+		position := ownFlag.Position
+		return &position
+	}
+	position := enemyFlag.Position
+	return &position
+}
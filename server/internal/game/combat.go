@@ -1,9 +1,5 @@
 package game
 
-import (
-	"time"
-)
-
 // CombatSystem handles unit-to-unit combat
 type CombatSystem struct {
 	LOSSystem        *LOSSystem
@@ -20,7 +16,7 @@ func NewCombatSystem(losSystem *LOSSystem) *CombatSystem {
 
 // Update processes combat between units
 func (s *CombatSystem) Update(state *State, deltaTime float64) {
-	now := time.Now().UnixMilli()
+	now := state.Now()
 
 	// Check each unit against all other units
 	for i := range state.Units {
@@ -36,6 +32,7 @@ func (s *CombatSystem) Update(state *State, deltaTime float64) {
 		}
 
 		// Find enemies in range
+		attacked := false
 		for j := range state.Units {
 			if i == j {
 				continue
@@ -70,11 +67,17 @@ func (s *CombatSystem) Update(state *State, deltaTime float64) {
 					projectile := NewProjectile(attacker, target, now)
 					state.AddProjectile(projectile)
 					attacker.SetLastAttackTime(now)
+					attacked = true
 
 					break // Only attack one target per tick
 				}
 			}
 		}
+
+		// Siege units attack the enemy base when there are no units to fight
+		if !attacked && canSiege(attacker.GetType()) {
+			s.attackBase(state, attacker, now)
+		}
 	}
 
 	// Update projectiles
@@ -84,6 +87,22 @@ func (s *CombatSystem) Update(state *State, deltaTime float64) {
 	s.removeDeadUnits(state)
 }
 
+// attackBase fires at the enemy base if it is in range and the attacker is off cooldown
+func (s *CombatSystem) attackBase(state *State, attacker Unit, now int64) {
+	enemyBase := state.Bases[1-attacker.GetOwnerID()]
+	if !enemyBase.IsAlive() || !enemyBase.IsInAttackRange(attacker.GetPosition(), attacker.GetAttackRange()) {
+		return
+	}
+
+	attackCooldown := int64(1000.0 / attacker.GetAttackSpeed())
+	if now-attacker.GetLastAttackTime() < attackCooldown {
+		return
+	}
+
+	state.AddProjectile(NewProjectileToBase(attacker, enemyBase, now))
+	attacker.SetLastAttackTime(now)
+}
+
 // removeDeadUnits removes units with health <= 0 (except players who respawn)
 func (s *CombatSystem) removeDeadUnits(state *State) {
 	aliveUnits := make([]Unit, 0, len(state.Units))
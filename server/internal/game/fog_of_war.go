@@ -0,0 +1,290 @@
+package game
+
+import (
+	"math"
+
+	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
+)
+
+// stateView is whose eyes a client sees the match through
+type stateView int
+
+const (
+	viewEverything stateView = iota // No fog of war
+	viewTeam0                       // What team 0 can see
+	viewTeam1                       // What team 1 can see
+	viewBothTeams                   // What either team can see
+)
+
+// teamView returns the view of a team's players
+func teamView(playerID int) stateView {
+	return viewTeam0 + stateView(playerID)
+}
+
+// playerView returns the view of the player in a seat
+// Note: Caller must hold the lock
+func (r *GameRoom) playerView(seat int) stateView {
+	if r.fogOfWar == nil {
+		return viewEverything
+	}
+	return teamView(TeamForSeat(seat))
+}
+
+// spectatorView returns the view of the room's spectators
+// Note: Caller must hold the lock
+func (r *GameRoom) spectatorView() stateView {
+	if r.fogOfWar == nil || !r.fogOfWar.LimitSpectators {
+		return viewEverything
+	}
+	return viewBothTeams
+}
+
+// sightSource is something that lets a team see around it
+type sightSource struct {
+	position         types.Vector3
+	radius           float64
+	ignoresObstacles bool
+}
+
+// teamSight is what each team can see at one moment
+type teamSight struct {
+	visible [2]map[string]bool // IDs of the units, projectiles and health packs each team can see
+}
+
+// computeTeamSight works out what each team's units and structures can see. Teams always see
+// their own units; everything else needs to be within sight radius and line of sight of a source.
+// Note: Caller must hold the lock
+func (r *GameRoom) computeTeamSight() *teamSight {
+	sight := &teamSight{}
+	for team := range sight.visible {
+		sources := newSightGrid(r.sightSources(team))
+		visible := make(map[string]bool)
+
+		for _, unit := range r.State.Units {
+			if unit.GetOwnerID() == team || r.canSee(sources, unit.GetPosition()) {
+				visible[unit.GetID()] = true
+			}
+		}
+
+		for _, projectile := range r.State.Projectiles {
+			if r.shooterTeam(projectile.ShooterID) == team || r.canSee(sources, projectile.Position) {
+				visible[projectile.ID] = true
+			}
+		}
+
+		for _, pack := range r.State.HealthPacks {
+			if r.canSee(sources, pack.Position) {
+				visible[pack.ID] = true
+			}
+		}
+
+		sight.visible[team] = visible
+	}
+	return sight
+}
+
+// sightSources returns the living units, base and claimed turrets a team sees with
+// Note: Caller must hold the lock
+func (r *GameRoom) sightSources(team int) []sightSource {
+	sources := []sightSource{{
+		position: r.State.Bases[team].Position,
+		radius:   types.StructureSightRadius,
+	}}
+
+	for _, unit := range r.State.Units {
+		if unit.GetOwnerID() != team || !unit.IsAlive() {
+			continue
+		}
+		sources = append(sources, sightSource{
+			position:         unit.GetPosition(),
+			radius:           max(unit.GetAttackRange()+types.SightRangeMargin, types.MinSightRadius),
+			ignoresObstacles: ignoresObstacles(unit.GetType()),
+		})
+	}
+
+	for _, turret := range r.State.Turrets {
+		if turret.OwnerID == team && !turret.IsDestroyed {
+			sources = append(sources, sightSource{
+				position: turret.Position,
+				radius:   types.StructureSightRadius,
+			})
+		}
+	}
+
+	return sources
+}
+
+// sightGrid buckets a team's sight sources by where they are, like SpatialGrid does for obstacles,
+// so a position is only checked against the sources close enough to see it. Cells are at least as
+// wide as the longest sight radius, so those sources are all in the position's cell or the 8
+// around it.
+type sightGrid struct {
+	cellSize float64
+	cells    map[GridKey][]sightSource
+}
+
+// newSightGrid buckets sight sources into cells
+func newSightGrid(sources []sightSource) *sightGrid {
+	grid := &sightGrid{
+		cellSize: SpatialGridCellSize,
+		cells:    make(map[GridKey][]sightSource),
+	}
+	for _, source := range sources {
+		grid.cellSize = max(grid.cellSize, source.radius)
+	}
+	for _, source := range sources {
+		key := grid.cellKey(source.position)
+		grid.cells[key] = append(grid.cells[key], source)
+	}
+	return grid
+}
+
+// cellKey returns the cell containing a position
+func (g *sightGrid) cellKey(pos types.Vector3) GridKey {
+	return GridKey{
+		X: int(math.Floor(pos.X / g.cellSize)),
+		Z: int(math.Floor(pos.Z / g.cellSize)),
+	}
+}
+
+// canSee returns true if any of the sources can see a position. Only the sources within their
+// sight radius are checked for line of sight.
+// Note: Caller must hold the lock
+func (r *GameRoom) canSee(sources *sightGrid, pos types.Vector3) bool {
+	center := sources.cellKey(pos)
+	for x := center.X - 1; x <= center.X+1; x++ {
+		for z := center.Z - 1; z <= center.Z+1; z++ {
+			for _, source := range sources.cells[GridKey{X: x, Z: z}] {
+				dx := pos.X - source.position.X
+				dz := pos.Z - source.position.Z
+				if dx*dx+dz*dz > source.radius*source.radius {
+					continue
+				}
+				if r.losSystem.HasLineOfSight(source.position, pos, source.ignoresObstacles) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// shooterTeam returns the team of the unit or turret that fired a projectile, or -1 if it's
+// neutral or gone
+// Note: Caller must hold the lock
+func (r *GameRoom) shooterTeam(shooterID string) int {
+	if unit := r.State.GetUnitByID(shooterID); unit != nil {
+		return unit.GetOwnerID()
+	}
+	if turret := r.State.GetTurretByID(shooterID); turret != nil {
+		return turret.OwnerID
+	}
+	return -1
+}
+
+// sees returns true if an entity is visible from a view
+func (s *teamSight) sees(view stateView, id string) bool {
+	switch view {
+	case viewTeam0, viewTeam1:
+		return s.visible[view-viewTeam0][id]
+	case viewBothTeams:
+		return s.visible[0][id] || s.visible[1][id]
+	}
+	return true
+}
+
+// seesPendingSpawns returns true if a team's queued units are visible from a view
+func (s *teamSight) seesPendingSpawns(view stateView, playerID int) bool {
+	if view == viewTeam0 || view == viewTeam1 {
+		return view == teamView(playerID)
+	}
+	return true
+}
+
+// applyFogOfWar removes what a view can't see from a state
+func (s *teamSight) applyFogOfWar(state *types.GameState, view stateView) {
+	if view == viewEverything {
+		return
+	}
+
+	state.Units = filterVisible(state.Units, func(u types.Unit) bool { return s.sees(view, u.ID) })
+	state.Projectiles = filterVisible(state.Projectiles, func(p types.Projectile) bool { return s.sees(view, p.ID) })
+	state.HealthPacks = filterVisible(state.HealthPacks, func(h types.HealthPack) bool { return s.sees(view, h.ID) })
+	state.PendingSpawns = filterVisible(state.PendingSpawns, func(p types.PendingSpawn) bool { return s.seesPendingSpawns(view, p.OwnerID) })
+}
+
+// eventSight is what each team could see when a tick's events were dispatched
+type eventSight struct {
+	sources [2]*sightGrid
+}
+
+// computeEventSight finds what each team sees with for the events being dispatched
+// Note: Caller must hold the lock
+func (r *GameRoom) computeEventSight() *eventSight {
+	return &eventSight{
+		sources: [2]*sightGrid{newSightGrid(r.sightSources(0)), newSightGrid(r.sightSources(1))},
+	}
+}
+
+// visibleEvents returns the events a view can see, in the order they happened
+// Note: Caller must hold the lock
+func (r *GameRoom) visibleEvents(events []emittedEvent, view stateView, sight *eventSight) []types.GameEvent {
+	visible := make([]types.GameEvent, 0, len(events))
+	for _, emitted := range events {
+		switch view {
+		case viewTeam0, viewTeam1:
+			if !r.teamSeesEvent(int(view-viewTeam0), emitted, sight) {
+				continue
+			}
+		case viewBothTeams:
+			if !r.teamSeesEvent(0, emitted, sight) && !r.teamSeesEvent(1, emitted, sight) {
+				continue
+			}
+		}
+		visible = append(visible, emitted.event)
+	}
+	return visible
+}
+
+// teamSeesEvent returns true if a team can see an event. Teams always see what they did and what
+// happened to them; they never see the other team's purchases (like their pending spawns), and
+// only see other events that happened somewhere one of their sight sources can see.
+// Note: Caller must hold the lock
+func (r *GameRoom) teamSeesEvent(team int, emitted emittedEvent, sight *eventSight) bool {
+	event := emitted.event
+	if event.PlayerID == team || event.TargetPlayerID == team {
+		return true
+	}
+	if event.Type == types.GameEventUnitPurchased {
+		return false
+	}
+	if emitted.position == nil {
+		return true
+	}
+	return r.canSee(sight.sources[team], *emitted.position)
+}
+
+// filterVisible returns the entities that are visible, in their original order
+func filterVisible[T any](entities []T, visible func(T) bool) []T {
+	filtered := make([]T, 0, len(entities))
+	for _, entity := range entities {
+		if visible(entity) {
+			filtered = append(filtered, entity)
+		}
+	}
+	return filtered
+}
+
+// visibleState returns the current state as a view sees it, computing what each team can see
+// unless it's given
+// Note: Caller must hold the lock
+func (r *GameRoom) visibleState(view stateView, sight *teamSight) types.GameState {
+	state := r.State.ToType()
+	if view != viewEverything {
+		if sight == nil {
+			sight = r.computeTeamSight()
+		}
+		sight.applyFogOfWar(&state, view)
+	}
+	return state
+}
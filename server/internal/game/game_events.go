@@ -0,0 +1,59 @@
+package game
+
+import "github.com/tombuildsstuff/web-arena-game/server/internal/types"
+
+// GameEventObserver is notified of the events a game room emits, such as kills, claims and
+// purchases, once per tick in the order they happened.
+// Observers are called with the room locked, so they mustn't call back into the room.
+type GameEventObserver interface {
+	OnGameEvent(event types.GameEvent)
+}
+
+// Subscribe adds an observer for the room's game events
+func (r *GameRoom) Subscribe(observer GameEventObserver) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.observers = append(r.observers, observer)
+}
+
+// dispatchEvents hands the events emitted since the last dispatch to the observers, and sends
+// the players and spectators the ones their view can see as a game_events message
+// Note: Caller must hold the lock
+func (r *GameRoom) dispatchEvents() {
+	events := r.State.takeEvents()
+	if len(events) == 0 {
+		return
+	}
+
+	for _, emitted := range events {
+		for _, observer := range r.observers {
+			observer.OnGameEvent(emitted.event)
+		}
+	}
+
+	var sight *eventSight
+	if r.fogOfWar != nil {
+		sight = r.computeEventSight()
+	}
+	payloads := make(map[stateView]*types.GameEventsPayload)
+	payloadFor := func(view stateView) *types.GameEventsPayload {
+		if payloads[view] == nil {
+			payloads[view] = &types.GameEventsPayload{
+				Timestamp: r.State.Now(),
+				Events:    r.visibleEvents(events, view, sight),
+			}
+		}
+		return payloads[view]
+	}
+
+	for seat, conn := range r.clientConnections {
+		if payload := payloadFor(r.playerView(seat)); len(payload.Events) > 0 {
+			conn.SendMessage("game_events", *payload)
+		}
+	}
+	for _, conn := range r.spectators {
+		if payload := payloadFor(r.spectatorView()); len(payload.Events) > 0 {
+			conn.SendMessage("game_events", *payload)
+		}
+	}
+}
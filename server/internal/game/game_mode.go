@@ -0,0 +1,78 @@
+package game
+
+import (
+	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
+)
+
+// GameMode adds a match objective on top of the base destruction and time limit
+// checks in WinConditionSystem
+type GameMode interface {
+	// Name returns the mode's ID, e.g. types.GameModeKingOfTheHill
+	Name() string
+
+	// Setup creates the mode's objects (hills, flags) from the state's map definition
+	Setup(state *State)
+
+	// Update advances the objective by one tick
+	Update(state *State, deltaTime float64)
+
+	// Check returns (hasWinner, winnerID, reason) once a player has completed the objective
+	Check(state *State) (bool, int, string)
+
+	// ScoreToWin returns the objective score needed to win (0 if the mode has no score)
+	ScoreToWin() float64
+
+	// ObjectiveFor returns where a seat's player should head to play the objective (nil if there is nothing to do)
+	// Used by the AI controller
+	ObjectiveFor(state *State, seat int, currentPos types.Vector3) *types.Vector3
+}
+
+// IsValidGameMode returns true if the mode ID is one the server supports
+func IsValidGameMode(mode string) bool {
+	switch mode {
+	case types.GameModeSiege, types.GameModeKingOfTheHill, types.GameModeCaptureTheFlag:
+		return true
+	}
+	return false
+}
+
+// NewGameMode creates the game mode for a mode ID, falling back to siege
+func NewGameMode(mode string) GameMode {
+	switch mode {
+	case types.GameModeKingOfTheHill:
+		return &KingOfTheHillMode{}
+	case types.GameModeCaptureTheFlag:
+		return &CaptureTheFlagMode{}
+	default:
+		return &SiegeMode{}
+	}
+}
+
+// SiegeMode is the default mode - the only way to win is to destroy the enemy base
+type SiegeMode struct{}
+
+// Name returns the mode's ID
+func (m *SiegeMode) Name() string {
+	return types.GameModeSiege
+}
+
+// Setup does nothing - siege has no extra objects
+func (m *SiegeMode) Setup(state *State) {}
+
+// Update does nothing - siege has no extra objective
+func (m *SiegeMode) Update(state *State, deltaTime float64) {}
+
+// Check never declares a winner - base destruction is checked by WinConditionSystem
+func (m *SiegeMode) Check(state *State) (bool, int, string) {
+	return false, -1, ""
+}
+
+// ScoreToWin returns 0 - siege has no score
+func (m *SiegeMode) ScoreToWin() float64 {
+	return 0
+}
+
+// ObjectiveFor returns nil - the AI's default behaviour already plays for the enemy base
+func (m *SiegeMode) ObjectiveFor(state *State, seat int, currentPos types.Vector3) *types.Vector3 {
+	return nil
+}
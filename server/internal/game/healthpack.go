@@ -3,7 +3,6 @@ package game
 import (
 	"fmt"
 	"math/rand"
-	"time"
 
 	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
 )
@@ -18,13 +17,13 @@ type HealthPack struct {
 }
 
 // NewHealthPack creates a new health pack at the given position
-func NewHealthPack(id string, position types.Vector3) *HealthPack {
+func NewHealthPack(id string, position types.Vector3, spawnedAt int64) *HealthPack {
 	return &HealthPack{
 		ID:         id,
 		Position:   position,
 		HealAmount: types.HealthPackHealAmount,
 		Radius:     types.HealthPackRadius,
-		SpawnedAt:  time.Now().UnixMilli(),
+		SpawnedAt:  spawnedAt,
 	}
 }
 
@@ -46,7 +45,7 @@ func (h *HealthPack) IsPlayerInRange(pos types.Vector3) bool {
 
 // HealthPackSystem manages health pack spawning and collection
 type HealthPackSystem struct {
-	lastSpawnTime  int64
+	lastSpawnTime  int64 // 0 until the first update schedules the first spawn
 	nextSpawnDelay int64 // milliseconds until next spawn
 	idCounter      int
 }
@@ -54,22 +53,28 @@ type HealthPackSystem struct {
 // NewHealthPackSystem creates a new health pack system
 func NewHealthPackSystem() *HealthPackSystem {
 	return &HealthPackSystem{
-		lastSpawnTime:  time.Now().UnixMilli(),
-		nextSpawnDelay: randomSpawnDelay(),
+		lastSpawnTime:  0,
+		nextSpawnDelay: 0,
 		idCounter:      0,
 	}
 }
 
 // randomSpawnDelay returns a random delay between spawns (15-45 seconds)
-func randomSpawnDelay() int64 {
+func randomSpawnDelay(rng *rand.Rand) int64 {
 	minDelay := int64(types.HealthPackSpawnMinSeconds * 1000)
 	maxDelay := int64(types.HealthPackSpawnMaxSeconds * 1000)
-	return minDelay + rand.Int63n(maxDelay-minDelay)
+	return minDelay + rng.Int63n(maxDelay-minDelay)
 }
 
 // Update checks for spawning new health packs and handles collection
 func (s *HealthPackSystem) Update(state *State) {
-	now := time.Now().UnixMilli()
+	now := state.Now()
+
+	// Schedule the first spawn from the start of the match
+	if s.lastSpawnTime == 0 {
+		s.lastSpawnTime = now
+		s.nextSpawnDelay = randomSpawnDelay(state.Rand)
+	}
 
 	// Check if it's time to spawn a new health pack
 	if now-s.lastSpawnTime >= s.nextSpawnDelay {
@@ -78,7 +83,7 @@ func (s *HealthPackSystem) Update(state *State) {
 			s.spawnHealthPack(state)
 		}
 		s.lastSpawnTime = now
-		s.nextSpawnDelay = randomSpawnDelay()
+		s.nextSpawnDelay = randomSpawnDelay(state.Rand)
 	}
 
 	// Check for collection by player units
@@ -93,8 +98,8 @@ func (s *HealthPackSystem) spawnHealthPack(state *State) {
 	// Try to find a valid spawn position
 	for attempts := 0; attempts < 20; attempts++ {
 		// Random position within the arena (avoiding edges and bases)
-		x := (rand.Float64() - 0.5) * 140 // -70 to 70
-		z := (rand.Float64() - 0.5) * 160 // -80 to 80
+		x := (state.Rand.Float64() - 0.5) * 140 // -70 to 70
+		z := (state.Rand.Float64() - 0.5) * 160 // -80 to 80
 
 		pos := types.Vector3{X: x, Y: 1.0, Z: z}
 
@@ -109,7 +114,7 @@ func (s *HealthPackSystem) spawnHealthPack(state *State) {
 
 		// Create the health pack
 		s.idCounter++
-		pack := NewHealthPack(fmt.Sprintf("healthpack_%d", s.idCounter), pos)
+		pack := NewHealthPack(fmt.Sprintf("healthpack_%d", s.idCounter), pos, state.Now())
 		state.HealthPacks = append(state.HealthPacks, pack)
 		return
 	}
@@ -142,6 +147,18 @@ func (s *HealthPackSystem) checkCollection(state *State) {
 					newHealth = maxHealth
 				}
 				playerUnit.SetHealth(newHealth)
+				if player := state.GetPlayer(playerUnit.GetOwnerID()); player != nil {
+					player.HealthPacksCollected++
+				}
+				state.emitAt(types.GameEvent{
+					Type:           types.GameEventHealthPackCollected,
+					PlayerID:       playerUnit.GetOwnerID(),
+					Seat:           playerUnit.Seat,
+					UnitType:       "player",
+					TargetID:       pack.ID,
+					TargetType:     "health_pack",
+					TargetPlayerID: -1,
+				}, &pack.Position)
 
 				// Mark pack for removal
 				packsToRemove = append(packsToRemove, pack.ID)
@@ -0,0 +1,36 @@
+package game
+
+import (
+	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
+)
+
+// acknowledgeInput records the newest numbered input processed for a seat. Inputs are handled
+// between ticks, so the next game update includes their effect.
+// Note: Caller must hold the lock
+func (r *GameRoom) acknowledgeInput(seat int, input types.InputSequence) {
+	member := r.State.GetMember(seat)
+	if member == nil || input.Sequence <= member.LastInput.Sequence {
+		return
+	}
+	member.LastInput = input
+}
+
+// playerInputAck returns what a seat is told about their own inputs and player unit after a game
+// update, or nil if the seat has no player unit
+// Note: Caller must hold the lock
+func (r *GameRoom) playerInputAck(seat int) *types.PlayerInputAck {
+	member := r.State.GetMember(seat)
+	playerUnit := r.State.GetSeatUnit(seat)
+	if member == nil || playerUnit == nil {
+		return nil
+	}
+
+	return &types.PlayerInputAck{
+		Snapshot:        r.snapshotSequence,
+		InputSequence:   member.LastInput,
+		Position:        roundVector(playerUnit.Position),
+		Velocity:        roundVector(playerUnit.Velocity),
+		ShootCooldown:   roundFloat(playerUnit.ShootCooldownRemaining(r.State.Now())),
+		RespawnCooldown: roundFloat(max(playerUnit.GetRespawnTimeRemaining(), 0)),
+	}
+}
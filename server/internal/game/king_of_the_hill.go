@@ -0,0 +1,140 @@
+package game
+
+import (
+	"math"
+
+	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
+)
+
+// Hill is a King of the Hill zone that scores for whoever holds it alone
+type Hill struct {
+	ID           string
+	Position     types.Vector3
+	Radius       float64
+	ControllerID int  // -1 = nobody, 0 = player 1, 1 = player 2
+	IsContested  bool // Both players have units inside
+}
+
+// NewHill creates a new hill zone
+func NewHill(id string, position types.Vector3, radius float64) *Hill {
+	if radius <= 0 {
+		radius = types.HillRadius
+	}
+	return &Hill{
+		ID:           id,
+		Position:     position,
+		Radius:       radius,
+		ControllerID: -1,
+	}
+}
+
+// ToType converts Hill to types.Hill for JSON serialization
+func (h *Hill) ToType() types.Hill {
+	return types.Hill{
+		ID:           h.ID,
+		Position:     h.Position,
+		Radius:       h.Radius,
+		ControllerID: h.ControllerID,
+		IsContested:  h.IsContested,
+	}
+}
+
+// Contains checks if a position is inside the hill
+func (h *Hill) Contains(pos types.Vector3) bool {
+	dx := pos.X - h.Position.X
+	dz := pos.Z - h.Position.Z
+	return dx*dx+dz*dz <= h.Radius*h.Radius
+}
+
+// KingOfTheHillMode scores a point per second for each hill a player holds uncontested
+type KingOfTheHillMode struct{}
+
+// Name returns the mode's ID
+func (m *KingOfTheHillMode) Name() string {
+	return types.GameModeKingOfTheHill
+}
+
+// Setup creates the map's hill zones (or a single hill in the centre if the map doesn't declare any)
+func (m *KingOfTheHillMode) Setup(state *State) {
+	state.Hills = make([]*Hill, 0, len(state.MapDefinition.HillZones))
+	for _, zone := range state.MapDefinition.HillZones {
+		state.Hills = append(state.Hills, NewHill(zone.ID, zone.Position, zone.Radius))
+	}
+	if len(state.Hills) == 0 {
+		state.Hills = append(state.Hills, NewHill("hill_center", types.Vector3{X: 0, Y: 0, Z: 0}, types.HillRadius))
+	}
+}
+
+// Update works out who holds each hill and accrues their score
+func (m *KingOfTheHillMode) Update(state *State, deltaTime float64) {
+	for _, hill := range state.Hills {
+		var present [2]bool
+		for _, unit := range state.Units {
+			if !unit.IsAlive() {
+				continue
+			}
+			ownerID := unit.GetOwnerID()
+			if ownerID < 0 || ownerID > 1 {
+				continue
+			}
+			if hill.Contains(unit.GetPosition()) {
+				present[ownerID] = true
+			}
+		}
+
+		hill.IsContested = present[0] && present[1]
+		switch {
+		case hill.IsContested:
+			hill.ControllerID = -1
+		case present[0]:
+			hill.ControllerID = 0
+		case present[1]:
+			hill.ControllerID = 1
+		default:
+			hill.ControllerID = -1
+		}
+
+		if hill.ControllerID >= 0 {
+			state.ObjectiveScores[hill.ControllerID] += deltaTime
+		}
+	}
+}
+
+// Check returns the first player to hold the hills for long enough
+func (m *KingOfTheHillMode) Check(state *State) (bool, int, string) {
+	for playerID, score := range state.ObjectiveScores {
+		if score >= types.KingOfTheHillScoreToWin {
+			return true, playerID, "Held the hill"
+		}
+	}
+	return false, -1, ""
+}
+
+// ScoreToWin returns the seconds of hill control needed to win
+func (m *KingOfTheHillMode) ScoreToWin() float64 {
+	return types.KingOfTheHillScoreToWin
+}
+
+// ObjectiveFor returns the closest hill the player doesn't hold alone,
+// or the closest hill to defend if they already hold them all
+func (m *KingOfTheHillMode) ObjectiveFor(state *State, seat int, currentPos types.Vector3) *types.Vector3 {
+	playerID := TeamForSeat(seat)
+	var target, fallback *types.Vector3
+	closestDist, fallbackDist := math.MaxFloat64, math.MaxFloat64
+	for _, hill := range state.Hills {
+		position := hill.Position
+		dist := calculateDistance(currentPos, hill.Position)
+		if hill.ControllerID != playerID && dist < closestDist {
+			target = &position
+			closestDist = dist
+		}
+		if dist < fallbackDist {
+			fallback = &position
+			fallbackDist = dist
+		}
+	}
+	if target == nil {
+		return fallback
+	}
+	return target
+}
@@ -0,0 +1,122 @@
+package game
+
+import (
+	"time"
+
+	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
+)
+
+// positionFrame is where every unit was in one broadcast game_update
+type positionFrame struct {
+	snapshot  int64
+	time      int64 // Simulation time of the update
+	positions map[string]types.Vector3
+}
+
+// positionHistory is a ring buffer of the unit positions in the most recent game updates,
+// so player shots can be resolved against what the shooter was looking at
+type positionHistory struct {
+	frames []positionFrame
+	next   int // Index the next frame is written to
+	count  int
+}
+
+// newPositionHistory creates a history holding enough frames to rewind by maxRewind
+func newPositionHistory(maxRewind time.Duration) *positionHistory {
+	if maxRewind <= 0 {
+		return &positionHistory{}
+	}
+	size := int((maxRewind+types.TickDuration-1)/types.TickDuration) + 1
+	return &positionHistory{frames: make([]positionFrame, size)}
+}
+
+// record keeps the unit positions broadcast in a snapshot, overwriting the oldest frame once full
+func (h *positionHistory) record(snapshot, now int64, units []Unit) {
+	if len(h.frames) == 0 {
+		return
+	}
+
+	frame := &h.frames[h.next]
+	if frame.positions == nil {
+		frame.positions = make(map[string]types.Vector3, len(units))
+	}
+	clear(frame.positions)
+	frame.snapshot = snapshot
+	frame.time = now
+	for _, unit := range units {
+		frame.positions[unit.GetID()] = unit.GetPosition()
+	}
+
+	h.next = (h.next + 1) % len(h.frames)
+	h.count = min(h.count+1, len(h.frames))
+}
+
+// find returns the newest frame matching, or nil if none do
+func (h *positionHistory) find(matches func(*positionFrame) bool) *positionFrame {
+	for i := 1; i <= h.count; i++ {
+		frame := &h.frames[(h.next-i+len(h.frames))%len(h.frames)]
+		if matches(frame) {
+			return frame
+		}
+	}
+	return nil
+}
+
+// oldest returns the oldest frame kept, or nil if there are none
+func (h *positionHistory) oldest() *positionFrame {
+	if h.count == 0 {
+		return nil
+	}
+	return &h.frames[(h.next-h.count+len(h.frames))%len(h.frames)]
+}
+
+// shotRewind works out how many milliseconds to rewind a player's shot by: back to the game update
+// they had applied when they fired, limited to the room's maximum rewind. Shots from clients that
+// don't say which update they saw, or name one the server hasn't sent, aren't rewound.
+// Note: Caller must hold the lock
+func (r *GameRoom) shotRewind(viewSnapshot int64) int64 {
+	if viewSnapshot <= 0 || viewSnapshot > r.snapshotSequence {
+		return 0
+	}
+
+	now := r.State.Now()
+	maxRewind := r.maxRewind.Milliseconds()
+	frame := r.positionHistory.find(func(f *positionFrame) bool {
+		return f.snapshot <= viewSnapshot
+	})
+	if frame == nil {
+		// Older than anything kept, so rewind as far as allowed
+		frame = r.positionHistory.oldest()
+	}
+	if frame == nil {
+		return 0
+	}
+	return min(max(now-frame.time, 0), maxRewind)
+}
+
+// rewoundPositions returns where units were a number of milliseconds ago, or nil to use where they
+// are now. Units that weren't in that update are where they are now.
+// Note: Caller must hold the lock
+func (r *GameRoom) rewoundPositions(rewind int64) map[string]types.Vector3 {
+	if rewind <= 0 {
+		return nil
+	}
+
+	viewTime := r.State.Now() - rewind
+	frame := r.positionHistory.find(func(f *positionFrame) bool {
+		return f.time <= viewTime
+	})
+	if frame == nil {
+		return nil
+	}
+	return frame.positions
+}
+
+// SetMaxRewind sets how far back player shots can be resolved to make up for their latency
+// (0 turns lag compensation off)
+func (r *GameRoom) SetMaxRewind(maxRewind time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maxRewind = maxRewind
+	r.positionHistory = newPositionHistory(maxRewind)
+}
@@ -4,114 +4,181 @@ import (
 	"encoding/json"
 	"log"
 	"os"
-	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/tombuildsstuff/web-arena-game/server/internal/storage"
 	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
 )
 
 // LeaderboardEntry represents a player's all-time statistics
 type LeaderboardEntry struct {
-	PlayerName    string `json:"playerName"`
-	TankKills     int    `json:"tankKills"`
-	AirplaneKills int    `json:"airplaneKills"`
-	TurretKills   int    `json:"turretKills"`
-	PlayerKills   int    `json:"playerKills"`
-	TotalPoints   int    `json:"totalPoints"`
-	GamesPlayed   int    `json:"gamesPlayed"`
-	GamesWon      int    `json:"gamesWon"`
-	TotalPlayTime int    `json:"totalPlayTime"` // in seconds
-	LastPlayed    int64  `json:"lastPlayed"`    // Unix timestamp
+	UserID               string         `json:"userId"`              // Stable user ID the entry is keyed by (empty for legacy entries)
+	PlayerName           string         `json:"playerName"`          // Display name as of the player's latest match
+	AvatarURL            string         `json:"avatarUrl,omitempty"` // Avatar as of the player's latest match
+	TankKills            int            `json:"tankKills"`
+	AirplaneKills        int            `json:"airplaneKills"`
+	SniperKills          int            `json:"sniperKills"`
+	RocketLauncherKills  int            `json:"rocketLauncherKills"`
+	TurretKills          int            `json:"turretKills"`
+	BarracksKills        int            `json:"barracksKills"`
+	PlayerKills          int            `json:"playerKills"`
+	TotalPoints          int            `json:"totalPoints"`
+	Deaths               int            `json:"deaths"`
+	DamageDealt          int            `json:"damageDealt"`
+	MoneyEarned          int            `json:"moneyEarned"`
+	MoneySpent           int            `json:"moneySpent"`
+	UnitsPurchased       map[string]int `json:"unitsPurchased,omitempty"` // Unit type -> number bought
+	TurretsClaimed       int            `json:"turretsClaimed"`
+	BuyZonesClaimed      int            `json:"buyZonesClaimed"`
+	BarracksClaimed      int            `json:"barracksClaimed"`
+	HealthPacksCollected int            `json:"healthPacksCollected"`
+	GamesPlayed          int            `json:"gamesPlayed"`
+	GamesWon             int            `json:"gamesWon"`
+	GamesDrawn           int            `json:"gamesDrawn"`
+	WinsByReason         map[string]int `json:"winsByReason,omitempty"` // Win reason -> number of wins
+	TotalPlayTime        int            `json:"totalPlayTime"`          // in seconds
+	LastPlayed           int64          `json:"lastPlayed"`             // Unix timestamp
+	Rating               Rating         `json:"rating"`                 // Skill rating from matches against other players
+	AIRating             Rating         `json:"aiRating"`               // Skill rating from matches against AI opponents
 }
 
 // Leaderboard manages player statistics
 type Leaderboard struct {
-	entries      map[string]*LeaderboardEntry
+	entries      map[string]*LeaderboardEntry // userID -> entry
+	legacy       map[string]*LeaderboardEntry // display name -> read-only entry saved before entries were keyed by user ID
 	totalMatches int
 	mu           sync.RWMutex
-	filePath     string
+	store        storage.Store
+	writer       *storage.Writer // Saves match results without holding up the room that reported them
 }
 
-// NewLeaderboard creates a new leaderboard, loading from file if exists
-func NewLeaderboard(filePath string) *Leaderboard {
+// NewLeaderboard creates a leaderboard backed by the store. The first time it runs against a
+// store, the entries in importFile (the old leaderboard.json) are imported. Results are saved
+// through writer.
+func NewLeaderboard(store storage.Store, writer *storage.Writer, importFile string) *Leaderboard {
 	lb := &Leaderboard{
-		entries:  make(map[string]*LeaderboardEntry),
-		filePath: filePath,
+		entries: make(map[string]*LeaderboardEntry),
+		legacy:  make(map[string]*LeaderboardEntry),
+		store:   store,
+		writer:  writer,
 	}
+	lb.importFile(importFile)
 	lb.load()
 	return lb
 }
 
-// RecordGameResult records the result of a game for both players
-func (lb *Leaderboard) RecordGameResult(player1Name, player2Name string, winner int, matchDuration int, p1Stats, p2Stats types.PlayerStats) {
+// RecordGameResult records the result of a 1v1 game and updates the players' ratings.
+// Only signed-in players have leaderboard entries - guests and AI players aren't recorded. Games
+// between two signed-in players are rated, a signed-in player against an AI is rated in the separate
+// vs-AI pool, and games against guests are unrated. Returns the rating changes indexed by player ID.
+// The entries are updated in memory straight away and saved to the store in the background.
+func (lb *Leaderboard) RecordGameResult(players [2]MatchResultPlayer, winner int, reason string, matchDuration int) []*types.RatingChange {
 	lb.mu.Lock()
 	defer lb.mu.Unlock()
 
 	now := time.Now().Unix()
 
-	// Update player 1 stats
-	entry1 := lb.getOrCreateEntry(player1Name)
-	entry1.TankKills += p1Stats.TankKills
-	entry1.AirplaneKills += p1Stats.AirplaneKills
-	entry1.TurretKills += p1Stats.TurretKills
-	entry1.PlayerKills += p1Stats.PlayerKills
-	entry1.TotalPoints += p1Stats.TotalPoints
-	entry1.GamesPlayed++
-	entry1.TotalPlayTime += matchDuration
-	entry1.LastPlayed = now
-	if winner == 0 {
-		entry1.GamesWon++
-	}
-
-	// Update player 2 stats
-	entry2 := lb.getOrCreateEntry(player2Name)
-	entry2.TankKills += p2Stats.TankKills
-	entry2.AirplaneKills += p2Stats.AirplaneKills
-	entry2.TurretKills += p2Stats.TurretKills
-	entry2.PlayerKills += p2Stats.PlayerKills
-	entry2.TotalPoints += p2Stats.TotalPoints
-	entry2.GamesPlayed++
-	entry2.TotalPlayTime += matchDuration
-	entry2.LastPlayed = now
-	if winner == 1 {
-		entry2.GamesWon++
-	}
-
-	// Save to file
-	lb.saveUnlocked()
-}
-
-// GetTopPlayers returns the top N players by points
-func (lb *Leaderboard) GetTopPlayers(limit int) []LeaderboardEntry {
-	lb.mu.RLock()
-	defer lb.mu.RUnlock()
+	var entries [2]*LeaderboardEntry
+	for playerID, player := range players {
+		if player.UserID == "" || player.IsGuest || player.AIDifficulty != "" {
+			continue
+		}
 
-	// Convert map to slice
-	entries := make([]LeaderboardEntry, 0, len(lb.entries))
-	for _, entry := range lb.entries {
-		entries = append(entries, *entry)
+		entry := lb.getOrCreateEntry(player.UserID, player.DisplayName)
+		entry.PlayerName = player.DisplayName
+		entry.AvatarURL = player.AvatarURL
+		entry.addStats(player.Stats)
+		entry.GamesPlayed++
+		entry.TotalPlayTime += matchDuration
+		entry.LastPlayed = now
+		if winner == playerID {
+			entry.recordWin(reason)
+		} else if winner != 0 && winner != 1 {
+			// Neither player won (the match was drawn on time)
+			entry.GamesDrawn++
+		}
+		entries[playerID] = entry
 	}
 
-	// Sort by total points descending
-	sort.Slice(entries, func(i, j int) bool {
-		return entries[i].TotalPoints > entries[j].TotalPoints
-	})
+	if entries[0] == nil && entries[1] == nil {
+		return nil
+	}
 
-	// Limit results
-	if limit > 0 && len(entries) > limit {
-		entries = entries[:limit]
+	// Update ratings, using both players' pre-match ratings
+	ratings := make([]*types.RatingChange, 2)
+	switch {
+	case entries[0] != nil && entries[1] != nil:
+		p1Rating := entries[0].Rating.Value
+		p2Rating := entries[1].Rating.Value
+		entries[0].Rating.apply(p2Rating, matchScore(0, winner))
+		entries[1].Rating.apply(p1Rating, matchScore(1, winner))
+		ratings[0] = entries[0].Rating.toChange(false)
+		ratings[1] = entries[1].Rating.toChange(false)
+	case entries[0] != nil && players[1].AIDifficulty != "":
+		entries[0].AIRating.apply(aiRating(players[1].AIDifficulty), matchScore(0, winner))
+		ratings[0] = entries[0].AIRating.toChange(true)
+	case entries[1] != nil && players[0].AIDifficulty != "":
+		entries[1].AIRating.apply(aiRating(players[0].AIDifficulty), matchScore(1, winner))
+		ratings[1] = entries[1].AIRating.toChange(true)
 	}
 
-	return entries
+	lb.saveEntriesUnlocked(entries[:])
+
+	return ratings
+}
+
+// addStats adds one match's stats to the entry's totals
+func (e *LeaderboardEntry) addStats(stats types.PlayerStats) {
+	e.TankKills += stats.TankKills
+	e.AirplaneKills += stats.AirplaneKills
+	e.SniperKills += stats.SniperKills
+	e.RocketLauncherKills += stats.RocketLauncherKills
+	e.TurretKills += stats.TurretKills
+	e.BarracksKills += stats.BarracksKills
+	e.PlayerKills += stats.PlayerKills
+	e.TotalPoints += stats.TotalPoints
+	e.Deaths += stats.Deaths
+	e.DamageDealt += stats.DamageDealt
+	e.MoneyEarned += stats.MoneyEarned
+	e.MoneySpent += stats.MoneySpent
+	e.TurretsClaimed += stats.TurretsClaimed
+	e.BuyZonesClaimed += stats.BuyZonesClaimed
+	e.BarracksClaimed += stats.BarracksClaimed
+	e.HealthPacksCollected += stats.HealthPacksCollected
+	for unitType, count := range stats.UnitsPurchased {
+		if e.UnitsPurchased == nil {
+			e.UnitsPurchased = make(map[string]int)
+		}
+		e.UnitsPurchased[unitType] += count
+	}
+}
+
+// recordWin counts a win and how it was won
+func (e *LeaderboardEntry) recordWin(reason string) {
+	e.GamesWon++
+	if e.WinsByReason == nil {
+		e.WinsByReason = make(map[string]int)
+	}
+	e.WinsByReason[reason]++
+}
+
+// GetTopPlayers returns one page of the all-time leaderboard sorted by sortBy (see LeaderboardQuery),
+// along with the total number of entries
+func (lb *Leaderboard) GetTopPlayers(sortBy string, offset, limit int) ([]LeaderboardRow, int) {
+	lb.mu.RLock()
+	defer lb.mu.RUnlock()
+
+	return rankEntries(lb.allEntriesUnlocked(), sortBy, offset, limit)
 }
 
 // GetPlayerStats returns stats for a specific player
-func (lb *Leaderboard) GetPlayerStats(playerName string) *LeaderboardEntry {
+func (lb *Leaderboard) GetPlayerStats(userID string) *LeaderboardEntry {
 	lb.mu.RLock()
 	defer lb.mu.RUnlock()
 
-	if entry, exists := lb.entries[playerName]; exists {
+	if entry, exists := lb.entries[userID]; exists {
 		// Return a copy
 		entryCopy := *entry
 		return &entryCopy
@@ -119,6 +186,17 @@ func (lb *Leaderboard) GetPlayerStats(playerName string) *LeaderboardEntry {
 	return nil
 }
 
+// GetRating returns a player's rating against other players, used for matchmaking
+func (lb *Leaderboard) GetRating(userID string) float64 {
+	lb.mu.RLock()
+	defer lb.mu.RUnlock()
+
+	if entry, exists := lb.entries[userID]; exists {
+		return entry.Rating.Value
+	}
+	return types.DefaultRating
+}
+
 // GetTotalMatches returns the total number of matches played
 func (lb *Leaderboard) GetTotalMatches() int {
 	lb.mu.RLock()
@@ -131,84 +209,180 @@ func (lb *Leaderboard) IncrementTotalMatches() {
 	lb.mu.Lock()
 	defer lb.mu.Unlock()
 	lb.totalMatches++
-	lb.saveUnlocked()
+
+	// Saved in the background, as it's called while matchmaking and lobby locks are held
+	totalMatches := lb.totalMatches
+	lb.writer.Queue("total matches", func(tx storage.Tx) error {
+		return storage.PutJSONTx(tx, storage.BucketMeta, metaTotalMatches, totalMatches)
+	})
 }
 
 // getOrCreateEntry gets or creates an entry for a player (must hold write lock)
-func (lb *Leaderboard) getOrCreateEntry(playerName string) *LeaderboardEntry {
-	if entry, exists := lb.entries[playerName]; exists {
+// Legacy entries are never claimed: they only have a display name, which doesn't say which
+// provider's account it was (or stop someone else signing in with the same name), so they stay
+// on the leaderboard as read-only rows.
+func (lb *Leaderboard) getOrCreateEntry(userID string, displayName string) *LeaderboardEntry {
+	if entry, exists := lb.entries[userID]; exists {
 		return entry
 	}
 
 	entry := &LeaderboardEntry{
-		PlayerName: playerName,
+		UserID:     userID,
+		PlayerName: displayName,
+		Rating:     NewRating(),
+		AIRating:   NewRating(),
 	}
-	lb.entries[playerName] = entry
+	lb.entries[userID] = entry
 	return entry
 }
 
-// leaderboardData is the structure for JSON persistence
-type leaderboardData struct {
-	TotalMatches int                `json:"totalMatches"`
-	Entries      []LeaderboardEntry `json:"entries"`
+// allEntriesUnlocked returns copies of every entry, including legacy ones (must hold lock)
+func (lb *Leaderboard) allEntriesUnlocked() []LeaderboardEntry {
+	entries := make([]LeaderboardEntry, 0, len(lb.entries)+len(lb.legacy))
+	for _, entry := range lb.entries {
+		entries = append(entries, *entry)
+	}
+	for _, entry := range lb.legacy {
+		entries = append(entries, *entry)
+	}
+	return entries
 }
 
-// load reads the leaderboard from the file
+const (
+	// Keys in the store's meta bucket
+	metaTotalMatches        = "total_matches"
+	metaLeaderboardImported = "leaderboard_imported"
+
+	// Prefix of the leaderboard bucket keys for entries saved before entries were keyed by user ID
+	legacyKeyPrefix = "legacy:"
+)
+
+// saveEntriesUnlocked queues writing changed entries to the store in one transaction. The entries
+// are encoded straight away, so later changes aren't raced by the write (must hold lock).
+func (lb *Leaderboard) saveEntriesUnlocked(entries []*LeaderboardEntry) {
+	encoded := make(map[string][]byte, len(entries))
+	for _, entry := range entries {
+		if entry == nil {
+			continue
+		}
+		data, err := json.Marshal(entry)
+		if err != nil {
+			log.Printf("Error encoding leaderboard entry %s: %v", entry.UserID, err)
+			continue
+		}
+		encoded[entry.UserID] = data
+	}
+
+	lb.writer.Queue("leaderboard entries", func(tx storage.Tx) error {
+		for userID, data := range encoded {
+			if err := tx.Put(storage.BucketLeaderboard, userID, data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// load reads the leaderboard from the store
 func (lb *Leaderboard) load() {
 	lb.mu.Lock()
 	defer lb.mu.Unlock()
 
-	data, err := os.ReadFile(lb.filePath)
+	if err := storage.GetJSON(lb.store, storage.BucketMeta, metaTotalMatches, &lb.totalMatches); err != nil && err != storage.ErrNotFound {
+		log.Printf("Error reading total matches: %v", err)
+	}
+
+	err := lb.store.ForEach(storage.BucketLeaderboard, func(key string, value []byte) error {
+		var entry LeaderboardEntry
+		if err := json.Unmarshal(value, &entry); err != nil {
+			log.Printf("Error parsing leaderboard entry %s: %v", key, err)
+			return nil
+		}
+		if entry.UserID == "" {
+			lb.legacy[entry.PlayerName] = &entry
+		} else {
+			lb.entries[entry.UserID] = &entry
+		}
+		return nil
+	})
 	if err != nil {
-		if os.IsNotExist(err) {
-			log.Printf("Leaderboard file not found, starting fresh")
-			return
+		log.Printf("Error reading leaderboard: %v", err)
+	}
+
+	log.Printf("Loaded %d leaderboard entries (%d legacy), %d total matches", len(lb.entries)+len(lb.legacy), len(lb.legacy), lb.totalMatches)
+}
+
+// leaderboardData is the structure of the old leaderboard.json file
+type leaderboardData struct {
+	TotalMatches int                `json:"totalMatches"`
+	Entries      []LeaderboardEntry `json:"entries"`
+}
+
+// importFile copies the entries from the old leaderboard.json into the store, once.
+// The file itself is left alone.
+func (lb *Leaderboard) importFile(filePath string) {
+	if _, err := lb.store.Get(storage.BucketMeta, metaLeaderboardImported); err != storage.ErrNotFound {
+		if err != nil {
+			log.Printf("Error checking leaderboard import: %v", err)
 		}
-		log.Printf("Error reading leaderboard file: %v", err)
 		return
 	}
 
 	var lbData leaderboardData
-	if err := json.Unmarshal(data, &lbData); err != nil {
-		log.Printf("Error parsing leaderboard file: %v", err)
+	data, err := os.ReadFile(filePath)
+	switch {
+	case os.IsNotExist(err):
+		log.Printf("Leaderboard file not found, nothing to import")
+	case err != nil:
+		// Try again next start rather than marking the import done
+		log.Printf("Error reading leaderboard file: %v", err)
 		return
+	default:
+		if err := json.Unmarshal(data, &lbData); err != nil {
+			log.Printf("Error parsing leaderboard file: %v", err)
+			return
+		}
 	}
 
-	lb.totalMatches = lbData.TotalMatches
-	for _, entry := range lbData.Entries {
-		entryCopy := entry
-		lb.entries[entry.PlayerName] = &entryCopy
-	}
-	log.Printf("Loaded %d leaderboard entries, %d total matches", len(lb.entries), lb.totalMatches)
-}
+	imported, dropped := 0, 0
+	err = lb.store.Update(func(tx storage.Tx) error {
+		for _, entry := range lbData.Entries {
+			// Entries saved before ratings existed start at the default rating
+			if entry.Rating.Value == 0 {
+				entry.Rating = NewRating()
+			}
+			if entry.AIRating.Value == 0 {
+				entry.AIRating = NewRating()
+			}
 
-// saveUnlocked saves the leaderboard to file (must hold lock)
-func (lb *Leaderboard) saveUnlocked() {
-	entries := make([]LeaderboardEntry, 0, len(lb.entries))
-	for _, entry := range lb.entries {
-		entries = append(entries, *entry)
-	}
+			key := entry.UserID
+			if key == "" {
+				// Entries saved before the leaderboard was keyed by user ID only have a display name.
+				// Guest and AI entries are dropped; the rest are kept as read-only rows.
+				if strings.HasPrefix(entry.PlayerName, "Guest_") || strings.HasPrefix(entry.PlayerName, "AI (") {
+					dropped++
+					continue
+				}
+				key = legacyKeyPrefix + entry.PlayerName
+			}
 
-	lbData := leaderboardData{
-		TotalMatches: lb.totalMatches,
-		Entries:      entries,
-	}
+			if err := storage.PutJSONTx(tx, storage.BucketLeaderboard, key, entry); err != nil {
+				return err
+			}
+			imported++
+		}
 
-	data, err := json.MarshalIndent(lbData, "", "  ")
+		if err := storage.PutJSONTx(tx, storage.BucketMeta, metaTotalMatches, lbData.TotalMatches); err != nil {
+			return err
+		}
+		return tx.Put(storage.BucketMeta, metaLeaderboardImported, []byte(filePath))
+	})
 	if err != nil {
-		log.Printf("Error marshaling leaderboard: %v", err)
+		log.Printf("Error importing leaderboard file: %v", err)
 		return
 	}
 
-	if err := os.WriteFile(lb.filePath, data, 0644); err != nil {
-		log.Printf("Error writing leaderboard file: %v", err)
-		return
+	if imported > 0 || dropped > 0 {
+		log.Printf("Imported %d leaderboard entries from %s (%d guest/AI entries dropped)", imported, filePath, dropped)
 	}
 }
-
-// Save explicitly saves the leaderboard (for graceful shutdown)
-func (lb *Leaderboard) Save() {
-	lb.mu.Lock()
-	defer lb.mu.Unlock()
-	lb.saveUnlocked()
-}
@@ -0,0 +1,237 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
+)
+
+// LeaderboardQuery selects and orders one page of the leaderboard
+type LeaderboardQuery struct {
+	Window   string // "all" (default), "day", "week", "month" or "season"
+	SeasonID string // Season shown by the "season" window (empty = the current season)
+	MapID    string // Only count matches played on this map (empty = every map)
+	SortBy   string // "points" (default), "rating", "winRate", "gamesWon" or "killsPerMinute"
+	Offset   int
+	Limit    int // Entries per page (0 = LeaderboardPageSize)
+}
+
+// LeaderboardRow is a leaderboard entry with its position and derived stats
+type LeaderboardRow struct {
+	Rank int `json:"rank"` // 1-based position across all pages
+	LeaderboardEntry
+	WinRate        float64 `json:"winRate"` // 0-1
+	KillsPerMinute float64 `json:"killsPerMinute"`
+}
+
+// LeaderboardPage is one page of leaderboard results
+type LeaderboardPage struct {
+	Window       string           `json:"window"`
+	Season       *types.Season    `json:"season,omitempty"` // Set for the "season" window
+	MapID        string           `json:"mapId,omitempty"`
+	SortBy       string           `json:"sortBy"`
+	Total        int              `json:"total"` // Entries across every page
+	Offset       int              `json:"offset"`
+	Limit        int              `json:"limit"`
+	TotalMatches int              `json:"totalMatches"` // All-time matches played
+	Entries      []LeaderboardRow `json:"entries"`
+}
+
+// winRate returns the share of games won (0-1)
+func (e *LeaderboardEntry) winRate() float64 {
+	if e.GamesPlayed == 0 {
+		return 0
+	}
+	return float64(e.GamesWon) / float64(e.GamesPlayed)
+}
+
+// killsPerMinute returns enemy units, structures and players destroyed per minute played
+func (e *LeaderboardEntry) killsPerMinute() float64 {
+	if e.TotalPlayTime == 0 {
+		return 0
+	}
+	kills := e.TankKills + e.AirplaneKills + e.SniperKills + e.RocketLauncherKills +
+		e.TurretKills + e.BarracksKills + e.PlayerKills
+	return float64(kills) / (float64(e.TotalPlayTime) / 60)
+}
+
+// addMatch adds one side of a recorded match to the entry's totals
+func (e *LeaderboardEntry) addMatch(record *types.MatchRecord, playerID int) {
+	e.addStats(record.Players[playerID].Stats)
+	e.GamesPlayed++
+	e.TotalPlayTime += record.Duration
+	e.LastPlayed = max(e.LastPlayed, record.EndedAt/1000)
+	if record.Winner == playerID {
+		e.recordWin(record.Reason)
+	} else if record.Winner != 0 && record.Winner != 1 {
+		e.GamesDrawn++
+	}
+}
+
+// rankEntries sorts entries and returns the requested page of them, along with the total count
+// Ties are broken by points and then name so pages don't shuffle between requests
+func rankEntries(entries []LeaderboardEntry, sortBy string, offset, limit int) ([]LeaderboardRow, int) {
+	rows := make([]LeaderboardRow, len(entries))
+	for i, entry := range entries {
+		rows[i] = LeaderboardRow{
+			LeaderboardEntry: entry,
+			WinRate:          entry.winRate(),
+			KillsPerMinute:   entry.killsPerMinute(),
+		}
+	}
+
+	metric := func(row *LeaderboardRow) float64 {
+		switch sortBy {
+		case types.LeaderboardSortRating:
+			return row.Rating.Value
+		case types.LeaderboardSortWinRate:
+			return row.WinRate
+		case types.LeaderboardSortGamesWon:
+			return float64(row.GamesWon)
+		case types.LeaderboardSortKillsPerMinute:
+			return row.KillsPerMinute
+		default:
+			return float64(row.TotalPoints)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if a, b := metric(&rows[i]), metric(&rows[j]); a != b {
+			return a > b
+		}
+		if rows[i].TotalPoints != rows[j].TotalPoints {
+			return rows[i].TotalPoints > rows[j].TotalPoints
+		}
+		return rows[i].PlayerName < rows[j].PlayerName
+	})
+
+	total := len(rows)
+	if offset >= total {
+		return []LeaderboardRow{}, total
+	}
+	end := min(offset+limit, total)
+	page := rows[offset:end]
+	for i := range page {
+		page[i].Rank = offset + i + 1
+	}
+	return page, total
+}
+
+// Standings totals the ranked matches that ended within [from, to), optionally on one map only.
+// Names, avatars and ratings come from the player's all-time leaderboard entry (via profile).
+func (h *MatchHistory) Standings(from, to time.Time, mapID string, profile func(userID string) *LeaderboardEntry) []LeaderboardEntry {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	fromMillis, toMillis := from.UnixMilli(), to.UnixMilli()
+	byUser := make(map[string]*LeaderboardEntry)
+	for _, record := range h.records {
+		if !record.Ranked || record.EndedAt < fromMillis || record.EndedAt >= toMillis {
+			continue
+		}
+		if mapID != "" && record.MapID != mapID {
+			continue
+		}
+
+		for playerID, side := range record.Players {
+			// Only signed-in players are on the leaderboard, the same as RecordGameResult
+			if len(side.Members) != 1 {
+				continue
+			}
+			member := side.Members[0]
+			if member.UserID == "" || member.IsGuest || member.AIDifficulty != "" {
+				continue
+			}
+
+			entry, exists := byUser[member.UserID]
+			if !exists {
+				entry = &LeaderboardEntry{
+					UserID:     member.UserID,
+					PlayerName: member.DisplayName,
+					Rating:     NewRating(),
+					AIRating:   NewRating(),
+				}
+				if current := profile(member.UserID); current != nil {
+					entry.PlayerName = current.PlayerName
+					entry.AvatarURL = current.AvatarURL
+					entry.Rating = current.Rating
+					entry.AIRating = current.AIRating
+				}
+				byUser[member.UserID] = entry
+			}
+			entry.addMatch(record, playerID)
+		}
+	}
+
+	entries := make([]LeaderboardEntry, 0, len(byUser))
+	for _, entry := range byUser {
+		entries = append(entries, *entry)
+	}
+	return entries
+}
+
+// QueryLeaderboard returns one page of the leaderboard for an all-time, rolling or season window
+func (m *Manager) QueryLeaderboard(query LeaderboardQuery, now time.Time) (*LeaderboardPage, error) {
+	if query.Window == "" {
+		query.Window = types.LeaderboardWindowAll
+	}
+	if query.SortBy == "" {
+		query.SortBy = types.LeaderboardSortPoints
+	}
+	switch query.SortBy {
+	case types.LeaderboardSortPoints, types.LeaderboardSortRating, types.LeaderboardSortWinRate, types.LeaderboardSortGamesWon, types.LeaderboardSortKillsPerMinute:
+	default:
+		return nil, fmt.Errorf("unknown sort: %s", query.SortBy)
+	}
+	if query.Limit <= 0 {
+		query.Limit = types.LeaderboardPageSize
+	}
+	query.Limit = min(query.Limit, types.LeaderboardMaxPageSize)
+	query.Offset = max(query.Offset, 0)
+
+	page := &LeaderboardPage{
+		Window:       query.Window,
+		MapID:        query.MapID,
+		SortBy:       query.SortBy,
+		Offset:       query.Offset,
+		Limit:        query.Limit,
+		TotalMatches: m.leaderboard.GetTotalMatches(),
+	}
+
+	var from, to time.Time
+	switch query.Window {
+	case types.LeaderboardWindowAll:
+		if query.MapID == "" {
+			page.Entries, page.Total = m.leaderboard.GetTopPlayers(query.SortBy, query.Offset, query.Limit)
+			return page, nil
+		}
+		// The all-time entries don't split by map, so total up the match history instead
+		to = now.Add(time.Millisecond)
+	case types.LeaderboardWindowDay:
+		from, to = now.AddDate(0, 0, -1), now.Add(time.Millisecond)
+	case types.LeaderboardWindowWeek:
+		from, to = now.AddDate(0, 0, -7), now.Add(time.Millisecond)
+	case types.LeaderboardWindowMonth:
+		from, to = now.AddDate(0, 0, -30), now.Add(time.Millisecond)
+	case types.LeaderboardWindowSeason:
+		season, err := m.findSeason(query.SeasonID, now)
+		if err != nil {
+			return nil, err
+		}
+		page.Season = &season
+		from, to = season.Start, season.End
+
+		// Ended seasons are served from their archived final standings
+		if archive := m.seasons.Archive(season.ID); archive != nil && query.MapID == "" {
+			page.Entries, page.Total = rankEntries(archive.Entries, query.SortBy, query.Offset, query.Limit)
+			return page, nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown window: %s", query.Window)
+	}
+
+	entries := m.matches.Standings(from, to, query.MapID, m.leaderboard.GetPlayerStats)
+	page.Entries, page.Total = rankEntries(entries, query.SortBy, query.Offset, query.Limit)
+	return page, nil
+}
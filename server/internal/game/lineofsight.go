@@ -81,9 +81,10 @@ func (l *LOSSystem) HasLineOfSightBetweenUnits(attacker, target Unit) bool {
 	from := attacker.GetPosition()
 	to := target.GetPosition()
 
-	// Helicopters (airplanes) ignore obstacles for LOS
-	attackerType := attacker.GetType()
-	ignoreHeight := attackerType == "airplane" || attackerType == "super_helicopter"
+	return l.HasLineOfSight(from, to, ignoresObstacles(attacker.GetType()))
+}
 
-	return l.HasLineOfSight(from, to, ignoreHeight)
+// ignoresObstacles returns true for units that see over obstacles: helicopters (airplanes)
+func ignoresObstacles(unitType string) bool {
+	return unitType == "airplane" || unitType == "super_helicopter"
 }
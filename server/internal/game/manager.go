@@ -1,13 +1,18 @@
 package game
 
 import (
+	"fmt"
 	"log"
+	"math"
 	"math/rand"
 	"os"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/tombuildsstuff/web-arena-game/server/internal/game/maps"
+	"github.com/tombuildsstuff/web-arena-game/server/internal/storage"
 	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
 )
 
@@ -26,32 +31,123 @@ type Manager struct {
 	// Spectator to room mapping
 	spectatorToRoom map[string]string // clientID -> roomID
 
+	// User to room mapping (for reconnecting to a game in progress)
+	userToRoom map[string]string // userID -> roomID
+
+	// Private lobbies: join code -> lobby
+	lobbies       map[string]*PrivateLobby
+	clientToLobby map[string]string // clientID -> join code
+	lobbiesMutex  sync.Mutex
+
+	// Replay playbacks being watched: clientID -> playback
+	replayPlaybacks map[string]*ReplayPlayback
+
 	// Leaderboard
 	leaderboard *Leaderboard
+
+	// Recorded match replays
+	replays *ReplayStore
+
+	// Results of finished matches, for player profiles
+	matches *MatchHistory
+
+	// Season calendar and archived season standings
+	seasons *Seasons
+
+	// Unlocked achievements and chosen cosmetics
+	achievements *Achievements
+
+	// Furthest back player shots are resolved to make up for their latency
+	maxShotRewind time.Duration
+
+	// Called with each guest's user ID when a game they're playing in starts
+	onGuestPlaying func(userID string)
 }
 
 // PlayerQueueEntry represents a player in the matchmaking queue
 type PlayerQueueEntry struct {
 	ClientID      string
+	UserID        string
 	Connection    ClientConnection
 	DisplayName   string
+	AvatarURL     string
 	IsGuest       bool
-	MapPreference string // Preferred map ID (empty = no preference)
+	MapPreference string    // Preferred map ID (empty = no preference)
+	GameMode      string    // Requested game mode - players are only matched with the same mode
+	TeamSize      int       // Players per team - players are only matched with the same team size
+	Rating        float64   // Skill rating against other players, used to find a fair opponent
+	QueuedAt      time.Time // When the player joined the queue - the rating window widens the longer they wait
+}
+
+// ratingWindow returns the largest rating gap this player will currently accept in an opponent
+func (e *PlayerQueueEntry) ratingWindow(now time.Time) float64 {
+	waited := now.Sub(e.QueuedAt).Seconds()
+	return types.MatchmakingRatingWindow + types.MatchmakingRatingWindowGrowth*waited
 }
 
-// NewManager creates a new game manager
-func NewManager() *Manager {
+// NewManager creates a new game manager, keeping the leaderboard and match history in the store.
+// Results are saved in the background through writer.
+func NewManager(store storage.Store, writer *storage.Writer) *Manager {
 	leaderboardFile := os.Getenv("LEADERBOARD_FILE")
 	if leaderboardFile == "" {
 		leaderboardFile = "leaderboard.json"
 	}
 
+	seasonsFile := os.Getenv("SEASONS_FILE")
+	if seasonsFile == "" {
+		seasonsFile = "seasons.json"
+	}
+
+	replayDir := os.Getenv("REPLAY_DIR")
+	if replayDir == "" {
+		replayDir = "replays"
+	}
+
+	maxShotRewind := types.DefaultMaxShotRewind
+	if value := os.Getenv("MAX_SHOT_REWIND"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			log.Printf("Ignoring invalid MAX_SHOT_REWIND %q, using %s", value, maxShotRewind)
+		} else {
+			maxShotRewind = parsed
+		}
+	}
+
 	return &Manager{
 		rooms:           make(map[string]*GameRoom),
 		queue:           make(map[string]*PlayerQueueEntry),
 		clientToRoom:    make(map[string]string),
 		spectatorToRoom: make(map[string]string),
-		leaderboard:     NewLeaderboard(leaderboardFile),
+		userToRoom:      make(map[string]string),
+		replayPlaybacks: make(map[string]*ReplayPlayback),
+		lobbies:         make(map[string]*PrivateLobby),
+		clientToLobby:   make(map[string]string),
+		leaderboard:     NewLeaderboard(store, writer, leaderboardFile),
+		replays:         NewReplayStore(replayDir, writer),
+		matches:         NewMatchHistory(store, writer),
+		seasons:         NewSeasons(store, seasonsFile),
+		achievements:    NewAchievements(store, writer),
+		maxShotRewind:   maxShotRewind,
+	}
+}
+
+// SetOnGuestPlaying sets the callback for guests starting a game, such as to save their session.
+// Must be set before matchmaking starts.
+func (m *Manager) SetOnGuestPlaying(callback func(userID string)) {
+	m.onGuestPlaying = callback
+}
+
+// guestsPlaying tells the guest playing callback about the guests in a room that's about to start
+func (m *Manager) guestsPlaying(room *GameRoom) {
+	if m.onGuestPlaying == nil {
+		return
+	}
+	for _, player := range room.State.Players {
+		for _, member := range player.Members {
+			if member.IsGuest && member.UserID != "" {
+				m.onGuestPlaying(member.UserID)
+			}
+		}
 	}
 }
 
@@ -60,8 +156,49 @@ func (m *Manager) GetLeaderboard() *Leaderboard {
 	return m.leaderboard
 }
 
+// GetReplayStore returns the replay store instance
+func (m *Manager) GetReplayStore() *ReplayStore {
+	return m.replays
+}
+
+// GetMatchHistory returns the match history instance
+func (m *Manager) GetMatchHistory() *MatchHistory {
+	return m.matches
+}
+
+// GetPlayerProfile returns a player's profile with their recent matches, or nil if the player is unknown
+func (m *Manager) GetPlayerProfile(userID string) *PlayerProfile {
+	stats := m.leaderboard.GetPlayerStats(userID)
+	profile := m.matches.Profile(userID, types.ProfileRecentMatches)
+	if profile == nil {
+		if stats == nil {
+			return nil
+		}
+		profile = &PlayerProfile{UserID: userID}
+	}
+
+	profile.Stats = stats
+	if stats != nil {
+		profile.DisplayName = stats.PlayerName
+		profile.AvatarURL = stats.AvatarURL
+	}
+	profile.Achievements = m.achievements.Get(userID)
+	return profile
+}
+
+// GetAchievements returns a player's unlocked achievements and chosen cosmetics
+func (m *Manager) GetAchievements(userID string) *types.UserAchievements {
+	return m.achievements.Get(userID)
+}
+
+// SetPlayerColor chooses the unlocked player colour a player uses in their future matches
+func (m *Manager) SetPlayerColor(userID string, color string) error {
+	return m.achievements.SetColor(userID, color)
+}
+
 // AddToQueue adds a player to the matchmaking queue
-func (m *Manager) AddToQueue(clientID string, conn ClientConnection, displayName string, isGuest bool, mapPreference string) {
+// teamSize is the number of players per side (1 for 1v1, 2 for 2v2)
+func (m *Manager) AddToQueue(clientID string, userID string, conn ClientConnection, displayName string, avatarURL string, isGuest bool, mapPreference string, gameMode string, teamSize int) {
 	m.queueMutex.Lock()
 	defer m.queueMutex.Unlock()
 
@@ -70,13 +207,23 @@ func (m *Manager) AddToQueue(clientID string, conn ClientConnection, displayName
 		return
 	}
 
+	if teamSize < 1 {
+		teamSize = 1
+	}
+
 	// Add to queue
 	m.queue[clientID] = &PlayerQueueEntry{
 		ClientID:      clientID,
+		UserID:        userID,
 		Connection:    conn,
 		DisplayName:   displayName,
+		AvatarURL:     avatarURL,
 		IsGuest:       isGuest,
 		MapPreference: mapPreference,
+		GameMode:      gameMode,
+		TeamSize:      teamSize,
+		Rating:        m.leaderboard.GetRating(userID),
+		QueuedAt:      time.Now(),
 	}
 
 	// Try to match players
@@ -93,83 +240,156 @@ func (m *Manager) RemoveFromQueue(clientID string) {
 	}
 }
 
-// resolveMapVote determines which map to use based on two players' preferences
-func resolveMapVote(pref1, pref2 string) *types.MapDefinition {
-	// Both have same preference (including both empty)
-	if pref1 == pref2 {
-		if pref1 == "" {
-			return maps.GetDefault()
+// resolveMapVote determines which map to use based on the matched players' preferences
+// The most requested map wins, ties are broken at random, and no preferences gives the default map
+func resolveMapVote(prefs ...string) *types.MapDefinition {
+	votes := make(map[string]int)
+	var candidates []string
+	mostVotes := 0
+	for _, pref := range prefs {
+		if pref == "" {
+			continue
 		}
-		if m, err := maps.Get(pref1); err == nil {
-			return m
+		votes[pref]++
+		switch {
+		case votes[pref] > mostVotes:
+			mostVotes = votes[pref]
+			candidates = []string{pref}
+		case votes[pref] == mostVotes:
+			candidates = append(candidates, pref)
 		}
-		return maps.GetDefault()
 	}
 
-	// One has preference, other doesn't
-	if pref1 == "" && pref2 != "" {
-		if m, err := maps.Get(pref2); err == nil {
-			return m
-		}
-		return maps.GetDefault()
-	}
-	if pref2 == "" && pref1 != "" {
-		if m, err := maps.Get(pref1); err == nil {
-			return m
-		}
+	if len(candidates) == 0 {
 		return maps.GetDefault()
 	}
 
-	// Both have different preferences - random pick
-	chosen := pref1
-	if rand.Intn(2) == 1 {
-		chosen = pref2
-	}
+	chosen := candidates[rand.Intn(len(candidates))]
 	if m, err := maps.Get(chosen); err == nil {
 		return m
 	}
 	return maps.GetDefault()
 }
 
-// tryMatchPlayers attempts to match players from the queue
+// tryMatchPlayers starts as many matches as the queue currently allows
+// Players are only matched with others who want the same game mode and team size
 func (m *Manager) tryMatchPlayers() {
+	for {
+		entries := m.findMatchLocked(time.Now())
+		if entries == nil {
+			return
+		}
+		m.startQueuedMatch(entries)
+	}
+}
+
+// RunMatchmaking periodically re-checks the queue, so players whose rating windows have
+// widened while waiting get matched. Runs until the process exits.
+func (m *Manager) RunMatchmaking() {
+	ticker := time.NewTicker(types.MatchmakingInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		m.queueMutex.Lock()
+		m.tryMatchPlayers()
+		m.queueMutex.Unlock()
+	}
+}
+
+// findMatchLocked picks the players for the next match from the queue, or nil if there isn't one yet.
+// 1v1 players are paired with the closest rating inside both players' rating windows, longest
+// waiting first. Team games are unrated, so they start as soon as there are enough players.
+// Caller must hold queueMutex.
+func (m *Manager) findMatchLocked(now time.Time) []*PlayerQueueEntry {
 	if len(m.queue) < 2 {
-		return
+		return nil
 	}
 
-	// Get first two players from queue
-	var player1, player2 *PlayerQueueEntry
+	// Group waiting players by mode/team size, longest waiting first
+	waiting := make([]*PlayerQueueEntry, 0, len(m.queue))
 	for _, entry := range m.queue {
-		if player1 == nil {
-			player1 = entry
-		} else if player2 == nil {
-			player2 = entry
-			break
+		waiting = append(waiting, entry)
+	}
+	sort.Slice(waiting, func(i, j int) bool {
+		return waiting[i].QueuedAt.Before(waiting[j].QueuedAt)
+	})
+	groups := make(map[string][]*PlayerQueueEntry)
+	var keys []string
+	for _, entry := range waiting {
+		key := fmt.Sprintf("%s/%d", entry.GameMode, entry.TeamSize)
+		if groups[key] == nil {
+			keys = append(keys, key)
 		}
+		groups[key] = append(groups[key], entry)
 	}
 
-	if player1 == nil || player2 == nil {
-		return
+	for _, key := range keys {
+		group := groups[key]
+		teamSize := group[0].TeamSize
+		if len(group) < 2*teamSize {
+			continue
+		}
+
+		if teamSize > 1 {
+			return group[:2*teamSize]
+		}
+
+		for i, entry := range group {
+			var best *PlayerQueueEntry
+			bestGap := 0.0
+			for _, other := range group[i+1:] {
+				gap := math.Abs(entry.Rating - other.Rating)
+				if gap > entry.ratingWindow(now) || gap > other.ratingWindow(now) {
+					continue
+				}
+				if best == nil || gap < bestGap {
+					best = other
+					bestGap = gap
+				}
+			}
+			if best != nil {
+				return []*PlayerQueueEntry{entry, best}
+			}
+		}
 	}
 
-	// Remove from queue
-	delete(m.queue, player1.ClientID)
-	delete(m.queue, player2.ClientID)
+	return nil
+}
+
+// startQueuedMatch creates and starts a game room for players matched from the queue
+// Caller must hold queueMutex
+func (m *Manager) startQueuedMatch(entries []*PlayerQueueEntry) {
+	// Remove from queue and deal the players out to alternate teams, so entry i takes seat i
+	var teams [2][]SeatConfig
+	prefs := make([]string, len(entries))
+	for i, entry := range entries {
+		delete(m.queue, entry.ClientID)
+		teams[TeamForSeat(i)] = append(teams[TeamForSeat(i)], SeatConfig{
+			ClientID:    entry.ClientID,
+			UserID:      entry.UserID,
+			DisplayName: entry.DisplayName,
+			AvatarURL:   entry.AvatarURL,
+			IsGuest:     entry.IsGuest,
+			Color:       m.achievements.Color(entry.UserID),
+		})
+		prefs[i] = entry.MapPreference
+	}
 
 	// Resolve map vote
-	mapDef := resolveMapVote(player1.MapPreference, player2.MapPreference)
-	log.Printf("Map vote resolved: P1=%q, P2=%q -> %s", player1.MapPreference, player2.MapPreference, mapDef.Name)
+	mapDef := resolveMapVote(prefs...)
+	log.Printf("Map vote resolved: %q -> %s", prefs, mapDef.Name)
 
 	// Create game room with display names
 	gameID := uuid.New().String()
-	room := NewGameRoomWithMap(gameID, mapDef,
-		player1.ClientID, player1.DisplayName, player1.IsGuest,
-		player2.ClientID, player2.DisplayName, player2.IsGuest,
-	)
+	room := NewTeamGameRoomWithMap(gameID, mapDef, rand.Int63(), teams)
+
+	gameMode := entries[0].GameMode
+	room.SetGameMode(gameMode)
 
 	// Set client connections
-	room.SetClientConnection(0, player1.Connection)
-	room.SetClientConnection(1, player2.Connection)
+	for seat, entry := range entries {
+		room.SetClientConnection(seat, entry.Connection)
+	}
 
 	// Set callback for when game ends
 	room.SetOnGameEnd(m.handleGameEnd)
@@ -177,24 +397,38 @@ func (m *Manager) tryMatchPlayers() {
 	// Set callback for recording game results to leaderboard
 	room.SetOnGameResult(m.leaderboard.RecordGameResult)
 
+	// Record the match so it can be replayed later
+	room.SetOnReplay(m.replays.Save)
+	room.SetOnMatchRecord(m.matches.Save)
+
+	room.SetMaxRewind(m.maxShotRewind)
+
+	// Unlock the achievements the players earn
+	room.Subscribe(NewAchievementTracker(room, m.achievements))
+
 	// Store room
 	m.roomsMutex.Lock()
 	m.rooms[gameID] = room
-	m.clientToRoom[player1.ClientID] = gameID
-	m.clientToRoom[player2.ClientID] = gameID
+	for _, entry := range entries {
+		m.clientToRoom[entry.ClientID] = gameID
+		m.trackUserLocked(entry.UserID, gameID)
+	}
 	m.roomsMutex.Unlock()
 
-	log.Printf("Created game room %s with players %s/%s (P1) and %s/%s (P2)", gameID, player1.ClientID, player1.DisplayName, player2.ClientID, player2.DisplayName)
+	log.Printf("Created %s game room %s: %s (P1) vs %s (P2)", gameMode, gameID, room.State.Players[0].DisplayName, room.State.Players[1].DisplayName)
 
 	// Increment total matches counter
 	m.leaderboard.IncrementTotalMatches()
 
+	m.guestsPlaying(room)
+
 	// Start the game
 	room.Start()
 }
 
 // CreateAIGame creates a game with a human player vs AI
-func (m *Manager) CreateAIGame(clientID string, conn ClientConnection, displayName string, isGuest bool, difficulty string, mapPreference string) {
+// With a teamSize above 1 the human is joined by AI teammates against a team of AIs
+func (m *Manager) CreateAIGame(clientID string, userID string, conn ClientConnection, displayName string, avatarURL string, isGuest bool, difficulty string, mapPreference string, gameMode string, teamSize int) {
 	// Remove from queue if present
 	m.queueMutex.Lock()
 	delete(m.queue, clientID)
@@ -208,24 +442,36 @@ func (m *Manager) CreateAIGame(clientID string, conn ClientConnection, displayNa
 		}
 	}
 
-	// Create game room with AI as player 2
+	if teamSize < 1 {
+		teamSize = 1
+	}
+
+	// The human takes seat 0 (player 1), AI fills every other seat
 	gameID := uuid.New().String()
 	aiDisplayName := "AI (" + difficulty + ")"
 
-	room := NewGameRoomWithMap(gameID, mapDef,
-		clientID, displayName, isGuest,
-		"ai-"+gameID, aiDisplayName, false,
-	)
+	teams := [2][]SeatConfig{
+		{{ClientID: clientID, UserID: userID, DisplayName: displayName, AvatarURL: avatarURL, IsGuest: isGuest, Color: m.achievements.Color(userID)}},
+	}
+	for seat := 1; seat < 2*teamSize; seat++ {
+		team := TeamForSeat(seat)
+		teams[team] = append(teams[team], SeatConfig{
+			ClientID:    fmt.Sprintf("ai-%s-%d", gameID, seat),
+			DisplayName: aiDisplayName,
+		})
+	}
+
+	room := NewTeamGameRoomWithMap(gameID, mapDef, rand.Int63(), teams)
+	room.SetGameMode(gameMode)
 
 	// Set human player connection
 	room.SetClientConnection(0, conn)
 
-	// Set AI connection (dummy - AI doesn't need to receive messages)
-	room.SetClientConnection(1, &AIClientConnection{})
-
-	// Create and set AI controller (AI is player 1, index 1)
-	aiController := NewAIController(1, difficulty)
-	room.SetAIController(aiController)
+	// Set AI connections (dummy - AI doesn't need to receive messages) and controllers
+	for seat := 1; seat < 2*teamSize; seat++ {
+		room.SetClientConnection(seat, &AIClientConnection{})
+		room.SetAIController(NewAIController(seat, difficulty))
+	}
 
 	// Set callback for when game ends
 	room.SetOnGameEnd(m.handleGameEnd)
@@ -233,17 +479,29 @@ func (m *Manager) CreateAIGame(clientID string, conn ClientConnection, displayNa
 	// Set callback for recording game results to leaderboard
 	room.SetOnGameResult(m.leaderboard.RecordGameResult)
 
+	// Record the match so it can be replayed later
+	room.SetOnReplay(m.replays.Save)
+	room.SetOnMatchRecord(m.matches.Save)
+
+	room.SetMaxRewind(m.maxShotRewind)
+
+	// Unlock the achievements the player earns
+	room.Subscribe(NewAchievementTracker(room, m.achievements))
+
 	// Store room
 	m.roomsMutex.Lock()
 	m.rooms[gameID] = room
 	m.clientToRoom[clientID] = gameID
+	m.trackUserLocked(userID, gameID)
 	m.roomsMutex.Unlock()
 
-	log.Printf("Created AI game room %s: %s vs %s", gameID, displayName, aiDisplayName)
+	log.Printf("Created AI game room %s: %s vs %s", gameID, room.State.Players[0].DisplayName, room.State.Players[1].DisplayName)
 
 	// Increment total matches counter
 	m.leaderboard.IncrementTotalMatches()
 
+	m.guestsPlaying(room)
+
 	// Start the game
 	room.Start()
 }
@@ -266,6 +524,9 @@ func (m *Manager) handleGameEnd(roomID string) {
 		delete(m.clientToRoom, clientID)
 	}
 
+	// Remove user-to-room mappings
+	m.untrackUsersLocked(room)
+
 	// Remove room
 	delete(m.rooms, roomID)
 
@@ -284,8 +545,27 @@ func (m *Manager) GetRoomByClient(clientID string) *GameRoom {
 	return nil
 }
 
-// GetPlayerIDInRoom returns the player ID for a client in their game room
-func (m *Manager) GetPlayerIDInRoom(clientID string) int {
+// AcknowledgeSnapshot records the newest game_update snapshot a player, spectator or replay
+// viewer has applied, so their next updates can be sent as deltas
+func (m *Manager) AcknowledgeSnapshot(clientID string, snapshot int64) {
+	m.roomsMutex.RLock()
+	var room *GameRoom
+	if roomID, exists := m.clientToRoom[clientID]; exists {
+		room = m.rooms[roomID]
+	} else if gameID, exists := m.spectatorToRoom[clientID]; exists {
+		room = m.rooms[gameID]
+	} else if playback, exists := m.replayPlaybacks[clientID]; exists {
+		room = playback.room
+	}
+	m.roomsMutex.RUnlock()
+
+	if room != nil {
+		room.AcknowledgeSnapshot(clientID, snapshot)
+	}
+}
+
+// GetSeatInRoom returns the seat a client occupies in their game room (the player ID in 1v1)
+func (m *Manager) GetSeatInRoom(clientID string) int {
 	m.roomsMutex.RLock()
 	defer m.roomsMutex.RUnlock()
 
@@ -299,14 +579,7 @@ func (m *Manager) GetPlayerIDInRoom(clientID string) int {
 		return -1
 	}
 
-	// Check which player this client is
-	for i, player := range room.State.Players {
-		if player.ClientID == clientID {
-			return i
-		}
-	}
-
-	return -1
+	return room.GetSeat(clientID)
 }
 
 // RemoveClient removes a client from their game room or spectating session
@@ -315,14 +588,22 @@ func (m *Manager) RemoveClient(clientID string) {
 	delete(m.queue, clientID)
 	m.queueMutex.Unlock()
 
+	m.LeaveLobby(clientID)
+
 	var roomToStop *GameRoom
 
 	m.roomsMutex.Lock()
 	// Check if client is a player
 	if roomID, exists := m.clientToRoom[clientID]; exists {
 		if room, ok := m.rooms[roomID]; ok {
-			roomToStop = room
-			delete(m.rooms, roomID)
+			if seat := room.GetSeat(clientID); seat >= 0 && room.State.Players[TeamForSeat(seat)].IsTeam() && room.HasOtherHumanPlayers(clientID) {
+				// Their teammates play on - the team only forfeits once every member has gone
+				room.HandleDisconnect(seat)
+			} else {
+				roomToStop = room
+				delete(m.rooms, roomID)
+				m.untrackUsersLocked(room)
+			}
 		}
 		delete(m.clientToRoom, clientID)
 	} else if gameID, exists := m.spectatorToRoom[clientID]; exists {
@@ -332,6 +613,7 @@ func (m *Manager) RemoveClient(clientID string) {
 		}
 		delete(m.spectatorToRoom, clientID)
 	}
+	m.stopReplayLocked(clientID)
 	m.roomsMutex.Unlock()
 
 	// Call Stop outside of lock to avoid deadlock with handleGameEnd callback
@@ -340,6 +622,80 @@ func (m *Manager) RemoveClient(clientID string) {
 	}
 }
 
+// DisconnectClient handles a client's connection dropping.
+// Queued players, lobby members and spectators are removed, but a player in a running game keeps
+// their seat so they can reconnect within types.ReconnectGracePeriod.
+func (m *Manager) DisconnectClient(clientID string) {
+	m.queueMutex.Lock()
+	delete(m.queue, clientID)
+	m.queueMutex.Unlock()
+
+	m.LeaveLobby(clientID)
+
+	m.roomsMutex.Lock()
+	defer m.roomsMutex.Unlock()
+
+	if roomID, exists := m.clientToRoom[clientID]; exists {
+		delete(m.clientToRoom, clientID)
+		if room, ok := m.rooms[roomID]; ok {
+			if seat := room.GetSeat(clientID); seat >= 0 {
+				room.HandleDisconnect(seat)
+			}
+		}
+	} else if gameID, exists := m.spectatorToRoom[clientID]; exists {
+		if room, ok := m.rooms[gameID]; ok {
+			room.RemoveSpectator(clientID)
+		}
+		delete(m.spectatorToRoom, clientID)
+	}
+	m.stopReplayLocked(clientID)
+}
+
+// ReconnectClient re-binds a newly connected client to a game the same user was disconnected from
+// Returns true if the client was placed back into a game
+func (m *Manager) ReconnectClient(clientID string, userID string, conn ClientConnection) bool {
+	if userID == "" {
+		return false
+	}
+
+	m.roomsMutex.Lock()
+	defer m.roomsMutex.Unlock()
+
+	roomID, exists := m.userToRoom[userID]
+	if !exists {
+		return false
+	}
+
+	room, ok := m.rooms[roomID]
+	if !ok {
+		delete(m.userToRoom, userID)
+		return false
+	}
+
+	if room.Reconnect(userID, clientID, conn) < 0 {
+		return false
+	}
+
+	m.clientToRoom[clientID] = roomID
+	return true
+}
+
+// trackUserLocked records which room a user is playing in (must hold roomsMutex)
+func (m *Manager) trackUserLocked(userID string, roomID string) {
+	if userID != "" {
+		m.userToRoom[userID] = roomID
+	}
+}
+
+// untrackUsersLocked removes the user-to-room mappings for a room's players (must hold roomsMutex)
+func (m *Manager) untrackUsersLocked(room *GameRoom) {
+	for _, userID := range room.GetUserIDs() {
+		if m.userToRoom[userID] == room.ID {
+			delete(m.userToRoom, userID)
+		}
+	}
+}
+
 // GetRoom returns a game room by ID
 func (m *Manager) GetRoom(roomID string) *GameRoom {
 	m.roomsMutex.RLock()
@@ -416,12 +772,47 @@ func (m *Manager) RemoveSpectator(clientID string) {
 		}
 		delete(m.spectatorToRoom, clientID)
 	}
+	m.stopReplayLocked(clientID)
 }
 
-// IsSpectating checks if a client is currently spectating a game
+// IsSpectating checks if a client is currently spectating a game or replay
 func (m *Manager) IsSpectating(clientID string) bool {
 	m.roomsMutex.RLock()
 	defer m.roomsMutex.RUnlock()
-	_, exists := m.spectatorToRoom[clientID]
+	if _, exists := m.spectatorToRoom[clientID]; exists {
+		return true
+	}
+	_, exists := m.replayPlaybacks[clientID]
 	return exists
 }
+
+// SpectateReplay starts playback of a stored replay for a client
+func (m *Manager) SpectateReplay(clientID string, replayID string, speed int, conn ClientConnection) error {
+	replay, err := m.replays.Get(replayID)
+	if err != nil {
+		return err
+	}
+
+	playback := NewReplayPlayback(replay, speed)
+
+	m.roomsMutex.Lock()
+	m.replayPlaybacks[clientID] = playback
+	m.roomsMutex.Unlock()
+
+	playback.Start(clientID, conn, func() {
+		m.roomsMutex.Lock()
+		defer m.roomsMutex.Unlock()
+		if m.replayPlaybacks[clientID] == playback {
+			delete(m.replayPlaybacks, clientID)
+		}
+	})
+	return nil
+}
+
+// stopReplayLocked stops a client's replay playback, if any (must hold roomsMutex)
+func (m *Manager) stopReplayLocked(clientID string) {
+	if playback, exists := m.replayPlaybacks[clientID]; exists {
+		playback.Stop()
+		delete(m.replayPlaybacks, clientID)
+	}
+}
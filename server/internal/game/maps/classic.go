@@ -22,11 +22,13 @@ func ClassicMap() *types.MapDefinition {
 				BasePosition: types.Vector3{X: -90, Y: 0, Z: 0},
 				SpawnOffset:  types.Vector3{X: 5, Y: 0, Z: 0},
 				Color:        "#3b82f6", // Blue
+				SpawnSlots:   []types.Vector3{{X: 0, Y: 0, Z: 0}, {X: 0, Y: 0, Z: 6}},
 			},
 			{
 				BasePosition: types.Vector3{X: 90, Y: 0, Z: 0},
 				SpawnOffset:  types.Vector3{X: -5, Y: 0, Z: 0},
 				Color:        "#ef4444", // Red
+				SpawnSlots:   []types.Vector3{{X: 0, Y: 0, Z: 0}, {X: 0, Y: 0, Z: 6}},
 			},
 		},
 
@@ -42,6 +44,17 @@ func ClassicMap() *types.MapDefinition {
 		// Obstacles
 		Obstacles: classicObstacles(),
 
+		// King of the Hill zone in the centre of the arena
+		HillZones: []types.MapHillZone{
+			{ID: "hill_center", Position: types.Vector3{X: 0, Y: 0, Z: 0}, Radius: 12},
+		},
+
+		// Capture the Flag stands, just in front of each base
+		FlagStands: []types.MapFlagStand{
+			{OwnerID: 0, Position: types.Vector3{X: -80, Y: 0, Z: 0}},
+			{OwnerID: 1, Position: types.Vector3{X: 80, Y: 0, Z: 0}},
+		},
+
 		// Health pack spawn bounds (avoiding bases)
 		HealthPackSpawnBounds: types.MapBounds{
 			MinX: -70,
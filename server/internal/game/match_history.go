@@ -0,0 +1,243 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tombuildsstuff/web-arena-game/server/internal/storage"
+	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
+)
+
+// MatchRecordCallback is called with the record of a finished match
+// It's called with the room locked, so it should queue the record rather than write it
+type MatchRecordCallback func(record *types.MatchRecord)
+
+// buildMatchRecord describes the finished match for match history
+// Note: Caller must hold the lock
+func (r *GameRoom) buildMatchRecord(winner int, reason string, matchDuration int, ranked bool, stats [2]types.PlayerStats, ratings []*types.RatingChange) *types.MatchRecord {
+	record := &types.MatchRecord{
+		ID:       r.ID,
+		GameMode: r.State.Mode.Name(),
+		Ranked:   ranked,
+		Winner:   winner,
+		Reason:   reason,
+		Duration: matchDuration,
+		EndedAt:  time.Now().UnixMilli(),
+	}
+	if r.State.MapDefinition != nil {
+		record.MapID = r.State.MapDefinition.ID
+		record.MapName = r.State.MapDefinition.Name
+	}
+
+	for playerID, player := range r.State.Players {
+		side := types.MatchRecordPlayer{
+			DisplayName: player.DisplayName,
+			Stats:       stats[playerID],
+		}
+		if playerID < len(ratings) {
+			side.Rating = ratings[playerID]
+		}
+		for _, member := range player.Members {
+			side.Members = append(side.Members, types.MatchRecordMember{
+				Seat:           member.Seat,
+				UserID:         member.UserID,
+				DisplayName:    member.DisplayName,
+				IsGuest:        member.IsGuest,
+				AIDifficulty:   r.aiDifficultyFor(member.Seat),
+				UnitsPurchased: member.UnitsPurchased,
+			})
+		}
+		record.Players[playerID] = side
+	}
+
+	return record
+}
+
+// PlayerProfile is a player's leaderboard stats together with their match history
+type PlayerProfile struct {
+	UserID        string                  `json:"userId"`
+	DisplayName   string                  `json:"displayName"`
+	AvatarURL     string                  `json:"avatarUrl,omitempty"`
+	Stats         *LeaderboardEntry       `json:"stats,omitempty"` // nil until the player finishes a ranked match
+	MatchesPlayed int                     `json:"matchesPlayed"`   // Every recorded match, including unranked ones
+	MatchesWon    int                     `json:"matchesWon"`
+	WinRateByMap  []types.MapWinRate      `json:"winRateByMap"`
+	FavouriteUnit string                  `json:"favouriteUnit,omitempty"` // Unit type the player has bought the most
+	RecentMatches []*types.MatchRecord    `json:"recentMatches"`           // Newest first
+	Achievements  *types.UserAchievements `json:"achievements"`
+}
+
+// MatchHistory persists match records in the store
+type MatchHistory struct {
+	store   storage.Store
+	writer  *storage.Writer // Saves records without holding up the room that finished
+	records map[string]*types.MatchRecord
+	byUser  map[string][]*types.MatchRecord // userID -> records, oldest first
+	mu      sync.RWMutex
+}
+
+// NewMatchHistory creates a match history, loading any records already in the store, and
+// saving new ones through writer
+func NewMatchHistory(store storage.Store, writer *storage.Writer) *MatchHistory {
+	h := &MatchHistory{
+		store:   store,
+		writer:  writer,
+		records: make(map[string]*types.MatchRecord),
+		byUser:  make(map[string][]*types.MatchRecord),
+	}
+	h.load()
+	return h
+}
+
+// Save adds a match record to the history and queues writing it to the store. The record
+// mustn't be changed afterwards.
+func (h *MatchHistory) Save(record *types.MatchRecord) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.writer.Queue("match "+record.ID, func(tx storage.Tx) error {
+		return storage.PutJSONTx(tx, storage.BucketMatches, record.ID, record)
+	})
+
+	h.indexUnlocked(record)
+}
+
+// Get returns a match record by ID
+func (h *MatchHistory) Get(id string) (*types.MatchRecord, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	record, exists := h.records[id]
+	if !exists {
+		return nil, fmt.Errorf("match not found: %s", id)
+	}
+	return record, nil
+}
+
+// Earliest returns when the oldest recorded match ended, or now if there are none
+func (h *MatchHistory) Earliest(now time.Time) time.Time {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	earliest := now
+	for _, record := range h.records {
+		if ended := time.UnixMilli(record.EndedAt); ended.Before(earliest) {
+			earliest = ended
+		}
+	}
+	return earliest
+}
+
+// Profile summarises a player's matches. Returns nil if they haven't finished any.
+func (h *MatchHistory) Profile(userID string, recentLimit int) *PlayerProfile {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	matches := h.byUser[userID]
+	if len(matches) == 0 {
+		return nil
+	}
+
+	profile := &PlayerProfile{
+		UserID:        userID,
+		MatchesPlayed: len(matches),
+	}
+
+	mapStats := make(map[string]*types.MapWinRate)
+	var mapOrder []string
+	purchases := make(map[string]int)
+	for _, record := range matches {
+		playerID, member := findMember(record, userID)
+		profile.DisplayName = member.DisplayName
+
+		stats, exists := mapStats[record.MapID]
+		if !exists {
+			stats = &types.MapWinRate{MapID: record.MapID, MapName: record.MapName}
+			mapStats[record.MapID] = stats
+			mapOrder = append(mapOrder, record.MapID)
+		}
+		stats.Played++
+		if record.Winner == playerID {
+			stats.Won++
+			profile.MatchesWon++
+		}
+
+		for unitType, count := range member.UnitsPurchased {
+			purchases[unitType] += count
+		}
+	}
+
+	for _, mapID := range mapOrder {
+		stats := mapStats[mapID]
+		stats.WinRate = float64(stats.Won) / float64(stats.Played)
+		profile.WinRateByMap = append(profile.WinRateByMap, *stats)
+	}
+
+	// Ties go to the alphabetically first unit type so the answer doesn't change between requests
+	for unitType, count := range purchases {
+		best := purchases[profile.FavouriteUnit]
+		if count > best || (count == best && unitType < profile.FavouriteUnit) {
+			profile.FavouriteUnit = unitType
+		}
+	}
+
+	for i := len(matches) - 1; i >= 0 && len(profile.RecentMatches) < recentLimit; i-- {
+		profile.RecentMatches = append(profile.RecentMatches, matches[i])
+	}
+
+	return profile
+}
+
+// findMember returns the side and seat a user played in a match
+func findMember(record *types.MatchRecord, userID string) (int, types.MatchRecordMember) {
+	for playerID, side := range record.Players {
+		for _, member := range side.Members {
+			if member.UserID == userID {
+				return playerID, member
+			}
+		}
+	}
+	return -1, types.MatchRecordMember{}
+}
+
+// indexUnlocked adds a record to the lookups (must hold write lock)
+func (h *MatchHistory) indexUnlocked(record *types.MatchRecord) {
+	h.records[record.ID] = record
+	for _, side := range record.Players {
+		for _, member := range side.Members {
+			if member.UserID != "" {
+				h.byUser[member.UserID] = append(h.byUser[member.UserID], record)
+			}
+		}
+	}
+}
+
+// load reads the match records already in the store
+func (h *MatchHistory) load() {
+	var records []*types.MatchRecord
+	err := h.store.ForEach(storage.BucketMatches, func(key string, value []byte) error {
+		var record types.MatchRecord
+		if err := json.Unmarshal(value, &record); err != nil {
+			log.Printf("Error parsing match %s: %v", key, err)
+			return nil
+		}
+		records = append(records, &record)
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error reading match history: %v", err)
+	}
+
+	// Index oldest first, the same order matches are saved in while running
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].EndedAt < records[j].EndedAt
+	})
+	for _, record := range records {
+		h.indexUnlocked(record)
+	}
+	log.Printf("Loaded %d match records", len(h.records))
+}
@@ -0,0 +1,107 @@
+package game
+
+import (
+	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
+)
+
+// Seats number every controllable PlayerUnit in a room. Teams take turns, so seat 0 is
+// the first member of team 0, seat 1 the first member of team 1, seat 2 the second member
+// of team 0 and so on. In a 1v1 the seat and the player ID are the same.
+
+// TeamForSeat returns the team (player ID) a seat plays for
+func TeamForSeat(seat int) int {
+	return seat % 2
+}
+
+// SeatFor returns the seat of a team's nth member
+func SeatFor(team int, memberIndex int) int {
+	return memberIndex*2 + team
+}
+
+// SeatConfig describes who occupies a seat when a room is created
+type SeatConfig struct {
+	ClientID    string // WebSocket client ID (or "ai-..." for AI seats)
+	UserID      string // Persistent user ID (empty for AI seats)
+	DisplayName string
+	AvatarURL   string
+	IsGuest     bool
+	Color       string // Unlocked player colour the seat has chosen (empty = the map's colour)
+}
+
+// Wallet is something purchases can be paid from - the team's shared pool or a member's own money
+type Wallet interface {
+	CanAfford(cost int) bool
+	Spend(amount int)
+	AddMoney(amount int)
+}
+
+// Member is one seat on a team
+type Member struct {
+	Seat           int
+	TeamID         int
+	ClientID       string         // WebSocket client ID
+	UserID         string         // Persistent user ID (empty for AI players)
+	DisplayName    string         // GitHub username or "Guest_XXXX"
+	AvatarURL      string         // Profile picture URL (empty for guests and AI players)
+	IsGuest        bool           // Whether this is a guest player
+	Money          int            // Personal wallet, only used when the economy is split
+	DisconnectedAt int64          // Unix millis when the member's connection dropped (0 if connected)
+	UnitsPurchased map[string]int // Unit type -> number bought this match
+	Color          string         // Unlocked player colour the member has chosen (empty = the map's colour)
+
+	// Newest move or shoot input processed from the member's client, echoed in their input acks
+	LastInput types.InputSequence
+}
+
+// NewMember creates a team member for a seat
+func NewMember(seat int, config SeatConfig) *Member {
+	return &Member{
+		Seat:        seat,
+		TeamID:      TeamForSeat(seat),
+		ClientID:    config.ClientID,
+		UserID:      config.UserID,
+		DisplayName: config.DisplayName,
+		AvatarURL:   config.AvatarURL,
+		IsGuest:     config.IsGuest,
+		Color:       config.Color,
+	}
+}
+
+// ToType converts Member to types.Member for JSON serialization
+func (m *Member) ToType() types.Member {
+	return types.Member{
+		Seat:           m.Seat,
+		DisplayName:    m.DisplayName,
+		IsGuest:        m.IsGuest,
+		Money:          m.Money,
+		IsDisconnected: m.IsDisconnected(),
+	}
+}
+
+// IsDisconnected returns true if the member's seat is being held for a reconnect
+func (m *Member) IsDisconnected() bool {
+	return m.DisconnectedAt != 0
+}
+
+// RecordPurchase counts units the member bought
+func (m *Member) RecordPurchase(unitType string, count int) {
+	if m.UnitsPurchased == nil {
+		m.UnitsPurchased = make(map[string]int)
+	}
+	m.UnitsPurchased[unitType] += count
+}
+
+// CanAfford checks if the member can afford a purchase
+func (m *Member) CanAfford(cost int) bool {
+	return m.Money >= cost
+}
+
+// Spend deducts money from the member
+func (m *Member) Spend(amount int) {
+	m.Money -= amount
+}
+
+// AddMoney adds money to the member
+func (m *Member) AddMoney(amount int) {
+	m.Money += amount
+}
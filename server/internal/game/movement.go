@@ -2,7 +2,6 @@ package game
 
 import (
 	"math"
-	"math/rand"
 
 	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
 )
@@ -44,7 +43,7 @@ func (s *MovementSystem) updatePlayerMovement(unit Unit, state *State, deltaTime
 	}
 
 	// Check for respawn
-	if playerUnit.CheckRespawn() {
+	if playerUnit.CheckRespawn(state.Now()) {
 		return // Just respawned, skip movement this frame
 	}
 
@@ -53,6 +52,18 @@ func (s *MovementSystem) updatePlayerMovement(unit Unit, state *State, deltaTime
 		return
 	}
 
+	// Work out how far the unit actually moves, for clients reconciling predicted movement
+	previousPos := playerUnit.Position
+	defer func() {
+		if deltaTime > 0 {
+			playerUnit.Velocity = types.Vector3{
+				X: (playerUnit.Position.X - previousPos.X) / deltaTime,
+				Y: (playerUnit.Position.Y - previousPos.Y) / deltaTime,
+				Z: (playerUnit.Position.Z - previousPos.Z) / deltaTime,
+			}
+		}
+	}()
+
 	// Get movement direction from input
 	dir := playerUnit.GetMoveDirection()
 
@@ -502,8 +513,8 @@ func (s *MovementSystem) updateTankMovement(unit Unit, state *State, deltaTime f
 				// Reset path attempts and try a completely random direction
 				unit.SetPathAttempts(0)
 				// Apply a random nudge to get unstuck
-				nudgeX := (rand.Float64() - 0.5) * 10.0
-				nudgeZ := (rand.Float64() - 0.5) * 10.0
+				nudgeX := (state.Rand.Float64() - 0.5) * 10.0
+				nudgeZ := (state.Rand.Float64() - 0.5) * 10.0
 				nudgedPos := types.Vector3{
 					X: clamp(newPos.X+nudgeX, -boundary, boundary),
 					Y: newPos.Y,
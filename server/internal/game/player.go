@@ -1,45 +1,86 @@
 package game
 
 import (
+	"strings"
+
 	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
 )
 
 // Player represents a player in the game
 type Player struct {
-	ID                  int
-	Money               int
-	BasePosition        types.Vector3
-	Color               string
-	ClientID            string // WebSocket client ID
-	DisplayName         string // GitHub username or "Guest_XXXX"
-	IsGuest             bool   // Whether this is a guest player
-	Kills               int    // Total number of enemy units destroyed
-	TankKills           int    // Tanks destroyed
-	AirplaneKills       int    // Airplanes destroyed
-	TurretKills         int    // Turrets destroyed
-	PlayerKills         int    // Enemy player deaths caused
-	SniperKills         int    // Snipers destroyed
-	RocketLauncherKills int    // Rocket launchers destroyed
-	BarracksKills       int    // Barracks destroyed
+	ID                   int
+	Money                int
+	BasePosition         types.Vector3
+	Color                string
+	DisplayName          string         // GitHub username or "Guest_XXXX" (member names joined in team games)
+	IsGuest              bool           // Whether every member is a guest
+	Kills                int            // Total number of enemy units destroyed
+	TankKills            int            // Tanks destroyed, including super tanks
+	SuperTankKills       int            // Super tanks destroyed
+	AirplaneKills        int            // Airplanes destroyed
+	TurretKills          int            // Turrets destroyed
+	PlayerKills          int            // Enemy player deaths caused
+	SniperKills          int            // Snipers destroyed
+	RocketLauncherKills  int            // Rocket launchers destroyed
+	BarracksKills        int            // Barracks destroyed
+	Deaths               int            // Times the team's player units were killed
+	DamageDealt          int            // Damage done to enemy units, structures and bases
+	MoneyEarned          int            // Income, kill rewards and claim rewards
+	MoneySpent           int            // Units bought and structures claimed
+	UnitsPurchased       map[string]int // Unit type -> number bought this match
+	TurretsClaimed       int
+	BuyZonesClaimed      int
+	BarracksClaimed      int
+	HealthPacksCollected int
+	Members              []*Member // Seats on this team, one per PlayerUnit
 }
 
-// NewPlayerWithMap creates a new player using map configuration
-func NewPlayerWithMap(id int, clientID string, displayName string, isGuest bool, mapDef *types.MapDefinition) *Player {
+// NewPlayerWithMap creates a new player (team) using map configuration
+// The team starts with StartingMoney per member, and plays in the first colour a member has
+// chosen from their unlocks, or the map's colour if nobody has chosen one
+func NewPlayerWithMap(id int, members []*Member, mapDef *types.MapDefinition) *Player {
 	playerConfig := mapDef.Players[id]
+
+	names := make([]string, len(members))
+	isGuest := len(members) > 0
+	color := playerConfig.Color
+	for i, member := range members {
+		names[i] = member.DisplayName
+		isGuest = isGuest && member.IsGuest
+		if member.Color != "" && color == playerConfig.Color {
+			color = member.Color
+		}
+	}
+
 	return &Player{
 		ID:           id,
-		Money:        types.StartingMoney,
+		Money:        types.StartingMoney * len(members),
 		BasePosition: playerConfig.BasePosition,
-		Color:        playerConfig.Color,
-		ClientID:     clientID,
-		DisplayName:  displayName,
+		Color:        color,
+		DisplayName:  strings.Join(names, " & "),
 		IsGuest:      isGuest,
+		Members:      members,
 	}
 }
 
+// IsTeam returns true if more than one member plays on this side
+func (p *Player) IsTeam() bool {
+	return len(p.Members) > 1
+}
+
+// GetMember returns the member in the given seat, or nil if the seat isn't on this team
+func (p *Player) GetMember(seat int) *Member {
+	for _, member := range p.Members {
+		if member.Seat == seat {
+			return member
+		}
+	}
+	return nil
+}
+
 // ToType converts Player to types.Player for JSON serialization
 func (p *Player) ToType() types.Player {
-	return types.Player{
+	player := types.Player{
 		ID:           p.ID,
 		Money:        p.Money,
 		BasePosition: p.BasePosition,
@@ -48,6 +89,13 @@ func (p *Player) ToType() types.Player {
 		IsGuest:      p.IsGuest,
 		Kills:        p.Kills,
 	}
+	if p.IsTeam() {
+		player.Members = make([]types.Member, len(p.Members))
+		for i, member := range p.Members {
+			player.Members[i] = member.ToType()
+		}
+	}
+	return player
 }
 
 // AddKill increments the player's kill count (legacy, use AddKillByType)
@@ -61,32 +109,61 @@ func (p *Player) AddKillByType(unitType string) {
 	switch unitType {
 	case "tank":
 		p.TankKills++
-		p.Money += types.KillRewardUnit // 10 money per unit kill
+		p.AddMoney(types.KillRewardUnit) // 10 money per unit kill
 	case "super_tank":
 		p.TankKills++
-		p.Money += types.KillRewardUnit * 2 // 20 money for super units (double)
+		p.SuperTankKills++
+		p.AddMoney(types.KillRewardUnit * 2) // 20 money for super units (double)
 	case "airplane":
 		p.AirplaneKills++
-		p.Money += types.KillRewardUnit // 10 money per unit kill
+		p.AddMoney(types.KillRewardUnit) // 10 money per unit kill
 	case "super_helicopter":
 		p.AirplaneKills++
-		p.Money += types.KillRewardUnit * 2 // 20 money for super units (double)
+		p.AddMoney(types.KillRewardUnit * 2) // 20 money for super units (double)
 	case "sniper":
 		p.SniperKills++
-		p.Money += types.KillRewardUnit // 10 money per sniper kill
+		p.AddMoney(types.KillRewardUnit) // 10 money per sniper kill
 	case "rocket_launcher":
 		p.RocketLauncherKills++
-		p.Money += types.KillRewardUnit // 10 money per rocket launcher kill
+		p.AddMoney(types.KillRewardUnit) // 10 money per rocket launcher kill
 	case "turret":
 		p.TurretKills++
-		p.Money += types.KillRewardUnit // 10 money per turret destroyed
+		p.AddMoney(types.KillRewardUnit) // 10 money per turret destroyed
 	case "barracks":
 		p.BarracksKills++
-		p.Money += types.KillRewardUnit // 10 money per barracks destroyed
+		p.AddMoney(types.KillRewardUnit) // 10 money per barracks destroyed
 	case "player":
 		p.PlayerKills++
-		p.Money += types.KillRewardUnit // 10 money per player kill
+		p.AddMoney(types.KillRewardUnit) // 10 money per player kill
+	}
+}
+
+// recordDamage counts damage the team did to an enemy. Safe to call on a nil player.
+func (p *Player) recordDamage(amount int) {
+	if p == nil {
+		return
+	}
+	p.DamageDealt += amount
+}
+
+// AddClaimByType counts a structure the team claimed ("turret", "buy_zone" or "barracks")
+func (p *Player) AddClaimByType(structureType string) {
+	switch structureType {
+	case "turret":
+		p.TurretsClaimed++
+	case "buy_zone":
+		p.BuyZonesClaimed++
+	case "barracks":
+		p.BarracksClaimed++
+	}
+}
+
+// RecordPurchase counts units the team bought
+func (p *Player) RecordPurchase(unitType string, count int) {
+	if p.UnitsPurchased == nil {
+		p.UnitsPurchased = make(map[string]int)
 	}
+	p.UnitsPurchased[unitType] += count
 }
 
 // GetStats returns the player's detailed statistics
@@ -101,14 +178,24 @@ func (p *Player) GetStats() types.PlayerStats {
 		p.BarracksKills*25 +
 		p.PlayerKills*50
 	return types.PlayerStats{
-		TankKills:           p.TankKills,
-		AirplaneKills:       p.AirplaneKills,
-		SniperKills:         p.SniperKills,
-		RocketLauncherKills: p.RocketLauncherKills,
-		TurretKills:         p.TurretKills,
-		BarracksKills:       p.BarracksKills,
-		PlayerKills:         p.PlayerKills,
-		TotalPoints:         points,
+		TankKills:            p.TankKills,
+		SuperTankKills:       p.SuperTankKills,
+		AirplaneKills:        p.AirplaneKills,
+		SniperKills:          p.SniperKills,
+		RocketLauncherKills:  p.RocketLauncherKills,
+		TurretKills:          p.TurretKills,
+		BarracksKills:        p.BarracksKills,
+		PlayerKills:          p.PlayerKills,
+		TotalPoints:          points,
+		Deaths:               p.Deaths,
+		DamageDealt:          p.DamageDealt,
+		MoneyEarned:          p.MoneyEarned,
+		MoneySpent:           p.MoneySpent,
+		UnitsPurchased:       p.UnitsPurchased,
+		TurretsClaimed:       p.TurretsClaimed,
+		BuyZonesClaimed:      p.BuyZonesClaimed,
+		BarracksClaimed:      p.BarracksClaimed,
+		HealthPacksCollected: p.HealthPacksCollected,
 	}
 }
 
@@ -122,7 +209,8 @@ func (p *Player) Spend(amount int) {
 	p.Money -= amount
 }
 
-// AddMoney adds money to the player
+// AddMoney adds money the team earned (income, kill and claim rewards)
 func (p *Player) AddMoney(amount int) {
 	p.Money += amount
+	p.MoneyEarned += amount
 }
@@ -1,32 +1,27 @@
 package game
 
-import (
-	"fmt"
-	"time"
-
-	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
-)
+import "github.com/tombuildsstuff/web-arena-game/server/internal/types"
 
 // PlayerUnit represents a player-controlled unit in the game
 type PlayerUnit struct {
 	BaseUnit
-	RespawnTime    int64 // Unix timestamp when unit can respawn (0 if alive)
-	IsRespawning   bool
-	MoveDirection  types.Vector3 // Current movement direction from input
-	BasePosition   types.Vector3 // Where to respawn
+	RespawnTime      int64 // Simulation time (Unix millis) when unit can respawn (0 if alive or not yet scheduled)
+	IsRespawning     bool
+	RespawnRemaining float64       // Seconds until respawn as of the last CheckRespawn
+	MoveDirection    types.Vector3 // Current movement direction from input
+	Velocity         types.Vector3 // Units per second moved in the last tick
+	BasePosition     types.Vector3 // Where to respawn
+	Seat             int           // Seat controlling this unit (see SeatFor)
 }
 
-var playerUnitCounter = 0
-
-// NewPlayerUnit creates a new player unit
-func NewPlayerUnit(ownerID int, basePosition types.Vector3) *PlayerUnit {
-	playerUnitCounter++
+// NewPlayerUnit creates a new player unit for a seat, spawning and respawning at basePosition
+// The unit is given its ID when it's added to the state (see State.AddUnit)
+func NewPlayerUnit(ownerID int, seat int, basePosition types.Vector3) *PlayerUnit {
 	spawnPos := basePosition
 	spawnPos.Y = types.PlayerUnitYPosition
 
 	return &PlayerUnit{
 		BaseUnit: BaseUnit{
-			ID:              fmt.Sprintf("player_%d", playerUnitCounter),
 			Type:            "player",
 			OwnerID:         ownerID,
 			Position:        spawnPos,
@@ -44,6 +39,7 @@ func NewPlayerUnit(ownerID int, basePosition types.Vector3) *PlayerUnit {
 		IsRespawning:  false,
 		MoveDirection: types.Vector3{X: 0, Y: 0, Z: 0},
 		BasePosition:  basePosition,
+		Seat:          seat,
 	}
 }
 
@@ -75,23 +71,31 @@ func (p *PlayerUnit) TakeDamage(amount int) {
 	}
 }
 
-// startRespawn begins the respawn timer
+// startRespawn marks the unit as respawning.
+// The timer itself is scheduled by the next CheckRespawn, which knows the simulation time.
 func (p *PlayerUnit) startRespawn() {
 	p.IsRespawning = true
-	p.RespawnTime = time.Now().UnixMilli() + int64(types.PlayerRespawnTime*1000)
+	p.RespawnTime = 0
+	p.RespawnRemaining = types.PlayerRespawnTime
+	p.Velocity = types.Vector3{X: 0, Y: 0, Z: 0}
 }
 
 // CheckRespawn checks if the respawn timer has expired and respawns if so
-func (p *PlayerUnit) CheckRespawn() bool {
+func (p *PlayerUnit) CheckRespawn(now int64) bool {
 	if !p.IsRespawning {
 		return false
 	}
 
-	now := time.Now().UnixMilli()
+	if p.RespawnTime == 0 {
+		p.RespawnTime = now + int64(types.PlayerRespawnTime*1000)
+	}
+
 	if now >= p.RespawnTime {
 		p.respawn()
 		return true
 	}
+
+	p.RespawnRemaining = float64(p.RespawnTime-now) / 1000.0
 	return false
 }
 
@@ -100,9 +104,18 @@ func (p *PlayerUnit) respawn() {
 	p.Health = types.PlayerUnitHealth
 	p.IsRespawning = false
 	p.RespawnTime = 0
+	p.RespawnRemaining = 0
 	p.Position = p.BasePosition
 	p.Position.Y = types.PlayerUnitYPosition
 	p.MoveDirection = types.Vector3{X: 0, Y: 0, Z: 0}
+	p.Velocity = types.Vector3{X: 0, Y: 0, Z: 0}
+}
+
+// ShootCooldownRemaining returns the seconds until the player unit can shoot again (0 if it can now)
+func (p *PlayerUnit) ShootCooldownRemaining(now int64) float64 {
+	attackCooldown := int64(1000.0 / p.GetAttackSpeed())
+	remaining := p.GetLastAttackTime() + attackCooldown - now
+	return float64(max(remaining, 0)) / 1000.0
 }
 
 // GetRespawnTimeRemaining returns seconds until respawn (-1 if not respawning)
@@ -110,11 +123,7 @@ func (p *PlayerUnit) GetRespawnTimeRemaining() float64 {
 	if !p.IsRespawning {
 		return -1
 	}
-	remaining := float64(p.RespawnTime-time.Now().UnixMilli()) / 1000.0
-	if remaining < 0 {
-		return 0
-	}
-	return remaining
+	return p.RespawnRemaining
 }
 
 // ToType converts PlayerUnit to types.Unit for JSON serialization
@@ -128,5 +137,6 @@ func (p *PlayerUnit) ToType() types.Unit {
 		TargetPosition: p.TargetPosition,
 		IsRespawning:   p.IsRespawning,
 		RespawnTime:    p.GetRespawnTimeRemaining(),
+		Seat:           p.Seat,
 	}
 }
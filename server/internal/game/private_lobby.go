@@ -0,0 +1,423 @@
+package game
+
+import (
+	cryptorand "crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/tombuildsstuff/web-arena-game/server/internal/game/maps"
+	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
+)
+
+// PrivateLobby is a group of friends setting up a match together, joined by a short code
+type PrivateLobby struct {
+	Code         string
+	HostClientID string
+	Settings     types.LobbySettings
+	Members      []*PrivateLobbyMember // In join order, which is also seat order
+}
+
+// PrivateLobbyMember is a player waiting in a private lobby
+type PrivateLobbyMember struct {
+	ClientID    string
+	UserID      string
+	DisplayName string
+	IsGuest     bool
+	Ready       bool
+	Connection  ClientConnection
+}
+
+// Capacity returns the number of seats in the match
+func (l *PrivateLobby) Capacity() int {
+	return 2 * l.Settings.TeamSize
+}
+
+// GetMember returns a member by client ID
+func (l *PrivateLobby) GetMember(clientID string) *PrivateLobbyMember {
+	for _, member := range l.Members {
+		if member.ClientID == clientID {
+			return member
+		}
+	}
+	return nil
+}
+
+// removeMember removes a member, handing the host role to the next member if the host left
+func (l *PrivateLobby) removeMember(clientID string) {
+	for i, member := range l.Members {
+		if member.ClientID == clientID {
+			l.Members = append(l.Members[:i], l.Members[i+1:]...)
+			break
+		}
+	}
+	if l.HostClientID == clientID && len(l.Members) > 0 {
+		l.HostClientID = l.Members[0].ClientID
+	}
+}
+
+// checkCanStart returns why the match can't start yet, or nil if it can
+func (l *PrivateLobby) checkCanStart() error {
+	if len(l.Members) > l.Capacity() {
+		return fmt.Errorf("Too many players for %dv%d", l.Settings.TeamSize, l.Settings.TeamSize)
+	}
+	if !l.Settings.AIFill && len(l.Members) < l.Capacity() {
+		return errors.New("Waiting for more players (or turn on AI fill)")
+	}
+	for _, member := range l.Members {
+		if member.ClientID != l.HostClientID && !member.Ready {
+			return errors.New("Not everyone is ready")
+		}
+	}
+	return nil
+}
+
+// ToType converts PrivateLobby to types.PrivateLobby for JSON serialization
+func (l *PrivateLobby) ToType() types.PrivateLobby {
+	members := make([]types.LobbyMember, len(l.Members))
+	for i, member := range l.Members {
+		members[i] = types.LobbyMember{
+			DisplayName: member.DisplayName,
+			IsGuest:     member.IsGuest,
+			IsHost:      member.ClientID == l.HostClientID,
+			Ready:       member.Ready,
+			Seat:        i,
+			Team:        TeamForSeat(i),
+		}
+	}
+
+	mapIDs := maps.List()
+	sort.Strings(mapIDs)
+
+	return types.PrivateLobby{
+		Code:     l.Code,
+		Settings: l.Settings,
+		Members:  members,
+		Maps:     mapIDs,
+		CanStart: l.checkCanStart() == nil,
+	}
+}
+
+// broadcastUpdate sends the lobby's state to every member
+func (l *PrivateLobby) broadcastUpdate() {
+	payload := l.ToType()
+	for _, member := range l.Members {
+		member.Connection.SendMessage("lobby_update", payload)
+	}
+}
+
+// normalizeLobbySettings replaces any invalid settings with their defaults and clamps the rest
+func normalizeLobbySettings(settings types.LobbySettings) types.LobbySettings {
+	defaults := types.DefaultLobbySettings(maps.GetDefault().ID)
+
+	if _, err := maps.Get(settings.MapID); err != nil {
+		settings.MapID = defaults.MapID
+	}
+	if !IsValidGameMode(settings.GameMode) {
+		settings.GameMode = defaults.GameMode
+	}
+	if settings.TeamSize < 1 || settings.TeamSize > types.MaxTeamSize {
+		settings.TeamSize = defaults.TeamSize
+	}
+	if settings.StartingMoney <= 0 {
+		settings.StartingMoney = defaults.StartingMoney
+	}
+	settings.StartingMoney = max(types.LobbyMinStartingMoney, min(types.LobbyMaxStartingMoney, settings.StartingMoney))
+	if settings.TimeLimit < 0 {
+		settings.TimeLimit = defaults.TimeLimit
+	}
+	if settings.TimeLimit > 0 {
+		settings.TimeLimit = max(types.LobbyMinTimeLimit, min(types.LobbyMaxTimeLimit, settings.TimeLimit))
+	}
+	if settings.AIDifficulty != "easy" && settings.AIDifficulty != "medium" && settings.AIDifficulty != "hard" {
+		settings.AIDifficulty = defaults.AIDifficulty
+	}
+	return settings
+}
+
+// generateLobbyCodeLocked returns a join code that isn't in use (must hold lobbiesMutex)
+// Codes come from crypto/rand so they can't be predicted from ones handed out earlier. The
+// alphabet has 32 letters, so each random byte maps onto it evenly.
+func (m *Manager) generateLobbyCodeLocked() string {
+	for {
+		code := make([]byte, types.LobbyCodeLength)
+		cryptorand.Read(code)
+		for i, b := range code {
+			code[i] = types.LobbyCodeAlphabet[int(b)%len(types.LobbyCodeAlphabet)]
+		}
+		if _, exists := m.lobbies[string(code)]; !exists {
+			return string(code)
+		}
+	}
+}
+
+// CreateLobby creates a private lobby hosted by the client and returns its join code
+func (m *Manager) CreateLobby(clientID string, userID string, conn ClientConnection, displayName string, isGuest bool, settings *types.LobbySettings) (string, error) {
+	m.RemoveFromQueue(clientID)
+
+	m.lobbiesMutex.Lock()
+	defer m.lobbiesMutex.Unlock()
+
+	if _, exists := m.clientToLobby[clientID]; exists {
+		return "", errors.New("Already in a lobby")
+	}
+
+	lobbySettings := types.DefaultLobbySettings(maps.GetDefault().ID)
+	if settings != nil {
+		lobbySettings = normalizeLobbySettings(*settings)
+	}
+
+	lobby := &PrivateLobby{
+		Code:         m.generateLobbyCodeLocked(),
+		HostClientID: clientID,
+		Settings:     lobbySettings,
+		Members: []*PrivateLobbyMember{{
+			ClientID:    clientID,
+			UserID:      userID,
+			DisplayName: displayName,
+			IsGuest:     isGuest,
+			Connection:  conn,
+		}},
+	}
+	m.lobbies[lobby.Code] = lobby
+	m.clientToLobby[clientID] = lobby.Code
+
+	log.Printf("Created private lobby %s for %s", lobby.Code, displayName)
+
+	lobby.broadcastUpdate()
+	return lobby.Code, nil
+}
+
+// JoinLobby adds the client to the private lobby with the given code
+func (m *Manager) JoinLobby(code string, clientID string, userID string, conn ClientConnection, displayName string, isGuest bool) error {
+	m.RemoveFromQueue(clientID)
+
+	m.lobbiesMutex.Lock()
+	defer m.lobbiesMutex.Unlock()
+
+	if _, exists := m.clientToLobby[clientID]; exists {
+		return errors.New("Already in a lobby")
+	}
+
+	lobby, exists := m.lobbies[strings.ToUpper(strings.TrimSpace(code))]
+	if !exists {
+		return errors.New("Lobby not found")
+	}
+	if len(lobby.Members) >= lobby.Capacity() {
+		return errors.New("Lobby is full")
+	}
+
+	lobby.Members = append(lobby.Members, &PrivateLobbyMember{
+		ClientID:    clientID,
+		UserID:      userID,
+		DisplayName: displayName,
+		IsGuest:     isGuest,
+		Connection:  conn,
+	})
+	m.clientToLobby[clientID] = lobby.Code
+
+	lobby.broadcastUpdate()
+	return nil
+}
+
+// UpdateLobbySettings changes a private lobby's settings (host only)
+// Everyone has to ready up again after a change
+func (m *Manager) UpdateLobbySettings(clientID string, settings types.LobbySettings) error {
+	m.lobbiesMutex.Lock()
+	defer m.lobbiesMutex.Unlock()
+
+	lobby := m.getLobbyLocked(clientID)
+	if lobby == nil {
+		return errors.New("You are not in a lobby")
+	}
+	if lobby.HostClientID != clientID {
+		return errors.New("Only the host can change the settings")
+	}
+
+	settings = normalizeLobbySettings(settings)
+	if len(lobby.Members) > 2*settings.TeamSize {
+		return errors.New("Too many players in the lobby for that team size")
+	}
+
+	lobby.Settings = settings
+	for _, member := range lobby.Members {
+		member.Ready = false
+	}
+
+	lobby.broadcastUpdate()
+	return nil
+}
+
+// SetLobbyReady marks a lobby member as ready (or not)
+func (m *Manager) SetLobbyReady(clientID string, ready bool) error {
+	m.lobbiesMutex.Lock()
+	defer m.lobbiesMutex.Unlock()
+
+	lobby := m.getLobbyLocked(clientID)
+	if lobby == nil {
+		return errors.New("You are not in a lobby")
+	}
+
+	lobby.GetMember(clientID).Ready = ready
+	lobby.broadcastUpdate()
+	return nil
+}
+
+// LeaveLobby removes the client from their private lobby, if any
+// The lobby is closed once its last member leaves
+func (m *Manager) LeaveLobby(clientID string) {
+	m.lobbiesMutex.Lock()
+	defer m.lobbiesMutex.Unlock()
+
+	lobby := m.getLobbyLocked(clientID)
+	if lobby == nil {
+		return
+	}
+
+	lobby.removeMember(clientID)
+	delete(m.clientToLobby, clientID)
+
+	if len(lobby.Members) == 0 {
+		delete(m.lobbies, lobby.Code)
+		log.Printf("Closed private lobby %s", lobby.Code)
+		return
+	}
+	lobby.broadcastUpdate()
+}
+
+// IsInLobby checks if a client is waiting in a private lobby
+func (m *Manager) IsInLobby(clientID string) bool {
+	m.lobbiesMutex.Lock()
+	defer m.lobbiesMutex.Unlock()
+	_, exists := m.clientToLobby[clientID]
+	return exists
+}
+
+// StartLobby starts the match for a private lobby (host only)
+func (m *Manager) StartLobby(clientID string) error {
+	m.lobbiesMutex.Lock()
+	lobby := m.getLobbyLocked(clientID)
+	if lobby == nil {
+		m.lobbiesMutex.Unlock()
+		return errors.New("You are not in a lobby")
+	}
+	if lobby.HostClientID != clientID {
+		m.lobbiesMutex.Unlock()
+		return errors.New("Only the host can start the match")
+	}
+	if err := lobby.checkCanStart(); err != nil {
+		m.lobbiesMutex.Unlock()
+		return err
+	}
+
+	// The lobby is done with once the match starts
+	delete(m.lobbies, lobby.Code)
+	for _, member := range lobby.Members {
+		delete(m.clientToLobby, member.ClientID)
+	}
+	m.lobbiesMutex.Unlock()
+
+	m.startLobbyGame(lobby)
+	return nil
+}
+
+// getLobbyLocked returns the lobby a client is in (must hold lobbiesMutex)
+func (m *Manager) getLobbyLocked(clientID string) *PrivateLobby {
+	code, exists := m.clientToLobby[clientID]
+	if !exists {
+		return nil
+	}
+	return m.lobbies[code]
+}
+
+// startLobbyGame creates and starts the game room for a private lobby
+// Members take the seats in join order, and AI fills any empty seats if the host asked for it
+func (m *Manager) startLobbyGame(lobby *PrivateLobby) {
+	settings := lobby.Settings
+
+	mapDef, err := maps.Get(settings.MapID)
+	if err != nil {
+		mapDef = maps.GetDefault()
+	}
+
+	gameID := uuid.New().String()
+	aiDisplayName := "AI (" + settings.AIDifficulty + ")"
+
+	var teams [2][]SeatConfig
+	var aiSeats []int
+	for seat := 0; seat < lobby.Capacity(); seat++ {
+		team := TeamForSeat(seat)
+		if seat < len(lobby.Members) {
+			member := lobby.Members[seat]
+			teams[team] = append(teams[team], SeatConfig{
+				ClientID:    member.ClientID,
+				UserID:      member.UserID,
+				DisplayName: member.DisplayName,
+				IsGuest:     member.IsGuest,
+				Color:       m.achievements.Color(member.UserID),
+			})
+		} else if settings.AIFill {
+			teams[team] = append(teams[team], SeatConfig{
+				ClientID:    fmt.Sprintf("ai-%s-%d", gameID, seat),
+				DisplayName: aiDisplayName,
+			})
+			aiSeats = append(aiSeats, seat)
+		}
+	}
+
+	room := NewTeamGameRoomWithMap(gameID, mapDef, rand.Int63(), teams)
+	room.SetGameMode(settings.GameMode)
+
+	rules := mapDef.MatchRules()
+	if settings.TimeLimit > 0 {
+		rules.TimeLimit = settings.TimeLimit
+	}
+	rules.StartingMoney = settings.StartingMoney
+	room.SetMatchRules(rules)
+
+	// Set client connections
+	for seat, member := range lobby.Members {
+		room.SetClientConnection(seat, member.Connection)
+	}
+	for _, seat := range aiSeats {
+		room.SetClientConnection(seat, &AIClientConnection{})
+		room.SetAIController(NewAIController(seat, settings.AIDifficulty))
+	}
+
+	// Set callback for when game ends
+	room.SetOnGameEnd(m.handleGameEnd)
+
+	// Private lobbies play with custom settings, so their results aren't recorded to the leaderboard
+
+	// Record the match so it can be replayed later
+	room.SetOnReplay(m.replays.Save)
+	room.SetOnMatchRecord(m.matches.Save)
+
+	room.SetMaxRewind(m.maxShotRewind)
+
+	// Unlock the achievements the players earn
+	room.Subscribe(NewAchievementTracker(room, m.achievements))
+
+	// Store room
+	m.roomsMutex.Lock()
+	m.rooms[gameID] = room
+	for _, member := range lobby.Members {
+		m.clientToRoom[member.ClientID] = gameID
+		m.trackUserLocked(member.UserID, gameID)
+	}
+	m.roomsMutex.Unlock()
+
+	log.Printf("Started private lobby %s as %s game room %s: %s (P1) vs %s (P2)", lobby.Code, settings.GameMode, gameID, room.State.Players[0].DisplayName, room.State.Players[1].DisplayName)
+
+	// Increment total matches counter
+	m.leaderboard.IncrementTotalMatches()
+
+	m.guestsPlaying(room)
+
+	// Start the game
+	room.Start()
+}
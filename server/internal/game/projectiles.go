@@ -100,6 +100,24 @@ func NewProjectileToBarracks(shooter Unit, barracks *Barracks, timestamp int64)
 	}
 }
 
+// NewProjectileToBase creates a projectile from a unit to an enemy base
+func NewProjectileToBase(shooter Unit, base *Base, timestamp int64) *Projectile {
+	shooterPos := shooter.GetPosition()
+	targetPos := base.Position
+
+	return &Projectile{
+		ID:        uuid.New().String(),
+		ShooterID: shooter.GetID(),
+		TargetID:  base.ID,
+		Position:  shooterPos,
+		StartPos:  shooterPos,
+		EndPos:    targetPos,
+		Speed:     ProjectileSpeed,
+		Damage:    shooter.GetDamage(),
+		CreatedAt: timestamp,
+	}
+}
+
 // ToType converts Projectile to types.Projectile for JSON serialization
 func (p *Projectile) ToType() types.Projectile {
 	return types.Projectile{
@@ -148,34 +166,30 @@ func (ps *ProjectileSystem) Update(state *State, deltaTime float64) {
 		distToTarget := calculateDistance(proj.Position, proj.EndPos)
 		if distToTarget < 2.0 {
 			hit := false
+			shooterOwner, shooterType, shooterSeat := projectileShooter(state, proj)
 
 			// Try to apply damage to unit target
 			target := state.GetUnitByID(proj.TargetID)
 			if target != nil && target.IsAlive() {
 				wasAlive := target.IsAlive()
+				health := target.GetHealth()
 				target.TakeDamage(proj.Damage)
+				shooterOwner.recordDamage(health - target.GetHealth())
 				hit = true
 
 				// If target died from this hit, credit the kill with type
 				if wasAlive && !target.IsAlive() {
 					targetType := target.GetType()
-					// Find the shooter (could be unit or turret)
-					shooter := state.GetUnitByID(proj.ShooterID)
-					if shooter != nil {
-						shooterOwner := state.GetPlayer(shooter.GetOwnerID())
-						if shooterOwner != nil {
-							shooterOwner.AddKillByType(targetType)
-						}
-					} else {
-						// Check if shooter is a turret
-						turret := state.GetTurretByID(proj.ShooterID)
-						if turret != nil && turret.OwnerID >= 0 {
-							shooterOwner := state.GetPlayer(turret.OwnerID)
-							if shooterOwner != nil {
-								shooterOwner.AddKillByType(targetType)
-							}
+					position := target.GetPosition()
+					if shooterOwner != nil {
+						shooterOwner.AddKillByType(targetType)
+					}
+					if targetType == "player" {
+						if victimOwner := state.GetPlayer(target.GetOwnerID()); victimOwner != nil {
+							victimOwner.Deaths++
 						}
 					}
+					emitDestroyed(state, types.GameEventUnitKilled, shooterOwner, shooterType, shooterSeat, target.GetID(), targetType, target.GetOwnerID(), &position)
 				}
 			}
 
@@ -184,18 +198,17 @@ func (ps *ProjectileSystem) Update(state *State, deltaTime float64) {
 				targetTurret := state.GetTurretByID(proj.TargetID)
 				if targetTurret != nil && targetTurret.IsAlive() {
 					wasAlive := targetTurret.IsAlive()
+					health := targetTurret.Health
 					targetTurret.TakeDamage(proj.Damage)
+					shooterOwner.recordDamage(health - targetTurret.Health)
 					hit = true
 
-					// If turret was destroyed, credit the kill
+					// If turret was destroyed, credit the kill (turrets don't get credit for destroying structures)
 					if wasAlive && !targetTurret.IsAlive() {
-						shooter := state.GetUnitByID(proj.ShooterID)
-						if shooter != nil {
-							shooterOwner := state.GetPlayer(shooter.GetOwnerID())
-							if shooterOwner != nil {
-								shooterOwner.AddKillByType("turret")
-							}
+						if shooterOwner != nil && shooterType != "turret" {
+							shooterOwner.AddKillByType("turret")
 						}
+						emitDestroyed(state, types.GameEventStructureDestroyed, shooterOwner, shooterType, shooterSeat, targetTurret.ID, "turret", targetTurret.OwnerID, nil)
 					}
 				}
 			}
@@ -205,18 +218,38 @@ func (ps *ProjectileSystem) Update(state *State, deltaTime float64) {
 				targetBarracks := state.GetBarracksByID(proj.TargetID)
 				if targetBarracks != nil && targetBarracks.IsAlive() {
 					wasAlive := targetBarracks.IsAlive()
+					health := targetBarracks.Health
+					ownerID := targetBarracks.OwnerID // Destroyed barracks go back to neutral
 					targetBarracks.TakeDamage(proj.Damage)
+					shooterOwner.recordDamage(health - targetBarracks.Health)
 					hit = true
 
-					// If barracks was destroyed, credit the kill
+					// If barracks was destroyed, credit the kill (turrets don't get credit for destroying structures)
+					// and the damage its scattered infantry take
 					if wasAlive && !targetBarracks.IsAlive() {
-						shooter := state.GetUnitByID(proj.ShooterID)
-						if shooter != nil {
-							shooterOwner := state.GetPlayer(shooter.GetOwnerID())
-							if shooterOwner != nil {
-								shooterOwner.AddKillByType("barracks")
-							}
+						if shooterOwner != nil && shooterType != "turret" {
+							shooterOwner.AddKillByType("barracks")
 						}
+						targetBarracks.DestroyedByID = -1
+						if shooterOwner != nil {
+							targetBarracks.DestroyedByID = shooterOwner.ID
+						}
+						emitDestroyed(state, types.GameEventStructureDestroyed, shooterOwner, shooterType, shooterSeat, targetBarracks.ID, "barracks", ownerID, nil)
+					}
+				}
+			}
+
+			// Try to apply siege damage to base target
+			if !hit {
+				targetBase := state.GetBaseByID(proj.TargetID)
+				if targetBase != nil && targetBase.IsAlive() {
+					health := targetBase.Health
+					targetBase.TakeDamage(proj.Damage)
+					shooterOwner.recordDamage(health - targetBase.Health)
+					hit = true
+
+					if !targetBase.IsAlive() {
+						emitDestroyed(state, types.GameEventBaseDestroyed, shooterOwner, shooterType, shooterSeat, targetBase.ID, "base", targetBase.OwnerID, nil)
 					}
 				}
 			}
@@ -236,3 +269,38 @@ func (ps *ProjectileSystem) Update(state *State, deltaTime float64) {
 		state.RemoveProjectiles(toRemove)
 	}
 }
+
+// projectileShooter returns the team that fired a projectile, what fired it ("turret" for turret fire)
+// and the seat of the player unit that fired it (-1 for any other shooter).
+// The team is nil if the shooter is gone or the turret is neutral.
+func projectileShooter(state *State, proj *Projectile) (*Player, string, int) {
+	if shooter := state.GetUnitByID(proj.ShooterID); shooter != nil {
+		seat := -1
+		if playerUnit, ok := shooter.(*PlayerUnit); ok {
+			seat = playerUnit.Seat
+		}
+		return state.GetPlayer(shooter.GetOwnerID()), shooter.GetType(), seat
+	}
+	if turret := state.GetTurretByID(proj.ShooterID); turret != nil && turret.OwnerID >= 0 {
+		return state.GetPlayer(turret.OwnerID), "turret", -1
+	}
+	return nil, "", -1
+}
+
+// emitDestroyed emits the event for a projectile destroying its target. position is where a
+// killed unit was, or nil for structures and bases, which fog of war never hides.
+func emitDestroyed(state *State, eventType string, shooterOwner *Player, shooterType string, shooterSeat int, targetID string, targetType string, targetOwnerID int, position *types.Vector3) {
+	playerID := -1
+	if shooterOwner != nil {
+		playerID = shooterOwner.ID
+	}
+	state.emitAt(types.GameEvent{
+		Type:           eventType,
+		PlayerID:       playerID,
+		Seat:           shooterSeat,
+		UnitType:       shooterType,
+		TargetID:       targetID,
+		TargetType:     targetType,
+		TargetPlayerID: targetOwnerID,
+	}, position)
+}
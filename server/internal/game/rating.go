@@ -0,0 +1,76 @@
+package game
+
+import (
+	"math"
+
+	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
+)
+
+// Rating is an Elo skill rating
+type Rating struct {
+	Value      float64 `json:"value"`
+	LastChange float64 `json:"lastChange"` // How much the most recent rated match moved the rating
+	Games      int     `json:"games"`      // Rated matches played
+}
+
+// NewRating returns the rating a new player starts with
+func NewRating() Rating {
+	return Rating{Value: types.DefaultRating}
+}
+
+// expectedScore returns the chance of a player with the given rating beating the opponent (0-1)
+func expectedScore(rating, opponent float64) float64 {
+	return 1 / (1 + math.Pow(10, (opponent-rating)/400))
+}
+
+// kFactor returns how many rating points are at stake for this player
+func (r *Rating) kFactor() float64 {
+	if r.Games < types.ProvisionalGames {
+		return types.ProvisionalKFactor
+	}
+	return types.RatingKFactor
+}
+
+// apply updates the rating with a match result against an opponent's pre-match rating.
+// score is 1 for a win, 0.5 for a draw and 0 for a loss.
+func (r *Rating) apply(opponent float64, score float64) {
+	change := r.kFactor() * (score - expectedScore(r.Value, opponent))
+	change = math.Round(change*10) / 10
+
+	r.Value += change
+	r.LastChange = change
+	r.Games++
+}
+
+// toChange converts the rating's latest update to a types.RatingChange for the game over message
+func (r *Rating) toChange(vsAI bool) *types.RatingChange {
+	return &types.RatingChange{
+		Rating: r.Value,
+		Change: r.LastChange,
+		VsAI:   vsAI,
+	}
+}
+
+// aiRating returns the fixed rating an AI opponent of the given difficulty is treated as
+func aiRating(difficulty string) float64 {
+	switch difficulty {
+	case "easy":
+		return types.AIRatingEasy
+	case "hard":
+		return types.AIRatingHard
+	default:
+		return types.AIRatingMedium
+	}
+}
+
+// matchScore returns the Elo score for a player given the match winner (-1 = draw)
+func matchScore(playerID int, winner int) float64 {
+	switch winner {
+	case playerID:
+		return 1
+	case 1 - playerID:
+		return 0
+	default:
+		return 0.5
+	}
+}
@@ -0,0 +1,216 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tombuildsstuff/web-arena-game/server/internal/storage"
+	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
+)
+
+// ReplayCallback is called with the finished replay when a recorded game ends
+// It's called with the room locked, so it should queue the replay rather than write it
+type ReplayCallback func(replay *types.Replay)
+
+// ReplayRecorder captures the inputs routed into a game room
+type ReplayRecorder struct {
+	replay *types.Replay
+}
+
+// NewReplayRecorder creates a recorder for a game that is about to start
+func NewReplayRecorder(gameID string, state *State) *ReplayRecorder {
+	replay := &types.Replay{
+		ID:        gameID,
+		Map:       state.MapDefinition,
+		Seed:      state.Seed,
+		StartedAt: state.MatchStartTime,
+		Inputs:    make([]types.ReplayInput, 0),
+	}
+	isTeamGame := false
+	for i, player := range state.Players {
+		replay.Players[i] = types.ReplayPlayer{
+			DisplayName: player.DisplayName,
+			IsGuest:     player.IsGuest,
+		}
+		isTeamGame = isTeamGame || player.IsTeam()
+	}
+
+	// Team games record every seat so playback can recreate the same teams
+	if isTeamGame {
+		for _, player := range state.Players {
+			for _, member := range player.Members {
+				replay.Seats = append(replay.Seats, types.ReplaySeat{
+					Seat:        member.Seat,
+					DisplayName: member.DisplayName,
+					IsGuest:     member.IsGuest,
+				})
+			}
+		}
+		sort.Slice(replay.Seats, func(i, j int) bool {
+			return replay.Seats[i].Seat < replay.Seats[j].Seat
+		})
+	}
+	return &ReplayRecorder{replay: replay}
+}
+
+// Record appends an input to the replay
+func (r *ReplayRecorder) Record(input types.ReplayInput) {
+	r.replay.Inputs = append(r.replay.Inputs, input)
+}
+
+// SetAIDifficulty marks a seat as AI-controlled so playback can recreate the controller
+func (r *ReplayRecorder) SetAIDifficulty(seat int, difficulty string) {
+	if r.replay.Seats == nil {
+		if seat >= 0 && seat < len(r.replay.Players) {
+			r.replay.Players[seat].AIDifficulty = difficulty
+		}
+		return
+	}
+
+	for i := range r.replay.Seats {
+		if r.replay.Seats[i].Seat == seat {
+			r.replay.Seats[i].AIDifficulty = difficulty
+		}
+	}
+}
+
+// Finish completes the replay with the match result
+func (r *ReplayRecorder) Finish(state *State, winner int, reason string, matchDuration int) *types.Replay {
+	rules := state.Rules
+	r.replay.Rules = &rules
+	r.replay.GameMode = state.Mode.Name()
+	r.replay.Winner = winner
+	r.replay.Reason = reason
+	r.replay.Duration = matchDuration
+	r.replay.TotalTicks = state.Tick
+	return r.replay
+}
+
+// ReplayStore persists replays as one JSON file per match
+type ReplayStore struct {
+	dir       string
+	writer    *storage.Writer // Writes replay files without holding up the room that finished
+	summaries map[string]types.ReplaySummary
+	mu        sync.RWMutex
+}
+
+// NewReplayStore creates a replay store, indexing any replays already in the directory, and
+// writing new ones through writer
+func NewReplayStore(dir string, writer *storage.Writer) *ReplayStore {
+	s := &ReplayStore{
+		dir:       dir,
+		writer:    writer,
+		summaries: make(map[string]types.ReplaySummary),
+	}
+	s.load()
+	return s
+}
+
+// Save queues writing a replay to disk. It's listed once the file has been written. The replay
+// mustn't be changed afterwards.
+func (s *ReplayStore) Save(replay *types.Replay) {
+	s.writer.QueueTask("replay "+replay.ID, func() error {
+		data, err := json.Marshal(replay)
+		if err != nil {
+			return fmt.Errorf("marshaling: %v", err)
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if err := os.MkdirAll(s.dir, 0755); err != nil {
+			return fmt.Errorf("creating replay directory: %v", err)
+		}
+		if err := os.WriteFile(s.pathFor(replay.ID), data, 0644); err != nil {
+			return err
+		}
+
+		s.summaries[replay.ID] = replay.Summary()
+		return nil
+	})
+}
+
+// Get loads a replay by ID
+func (s *ReplayStore) Get(id string) (*types.Replay, error) {
+	s.mu.RLock()
+	_, exists := s.summaries[id]
+	s.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("replay not found: %s", id)
+	}
+
+	data, err := os.ReadFile(s.pathFor(id))
+	if err != nil {
+		return nil, fmt.Errorf("reading replay %s: %v", id, err)
+	}
+
+	var replay types.Replay
+	if err := json.Unmarshal(data, &replay); err != nil {
+		return nil, fmt.Errorf("parsing replay %s: %v", id, err)
+	}
+	return &replay, nil
+}
+
+// List returns the most recent replays, newest first
+func (s *ReplayStore) List(limit int) []types.ReplaySummary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	summaries := make([]types.ReplaySummary, 0, len(s.summaries))
+	for _, summary := range s.summaries {
+		summaries = append(summaries, summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].StartedAt > summaries[j].StartedAt
+	})
+
+	if limit > 0 && len(summaries) > limit {
+		summaries = summaries[:limit]
+	}
+	return summaries
+}
+
+// pathFor returns the file path for a replay ID
+func (s *ReplayStore) pathFor(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+// load indexes the replays already on disk
+func (s *ReplayStore) load() {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.Printf("Replay directory not found, starting fresh")
+			return
+		}
+		log.Printf("Error reading replay directory: %v", err)
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			log.Printf("Error reading replay %s: %v", entry.Name(), err)
+			continue
+		}
+
+		var replay types.Replay
+		if err := json.Unmarshal(data, &replay); err != nil {
+			log.Printf("Error parsing replay %s: %v", entry.Name(), err)
+			continue
+		}
+		s.summaries[replay.ID] = replay.Summary()
+	}
+	log.Printf("Loaded %d replays", len(s.summaries))
+}
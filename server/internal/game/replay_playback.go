@@ -0,0 +1,176 @@
+package game
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
+)
+
+// ReplayPlayback re-simulates a recorded match for a spectator
+type ReplayPlayback struct {
+	replay    *types.Replay
+	room      *GameRoom
+	speed     int
+	nextInput int
+	stopChan  chan bool
+	stopOnce  sync.Once
+	onEnd     func()
+}
+
+// NewReplayPlayback creates a playback of a replay at the given speed (1, 2 or 4)
+func NewReplayPlayback(replay *types.Replay, speed int) *ReplayPlayback {
+	if speed != 2 && speed != 4 {
+		speed = 1
+	}
+
+	// Recreate the same teams, seat by seat
+	var teams [2][]SeatConfig
+	if replay.Seats == nil {
+		for i, player := range replay.Players {
+			teams[i] = []SeatConfig{{DisplayName: player.DisplayName, IsGuest: player.IsGuest}}
+		}
+	}
+	for _, seat := range replay.Seats {
+		team := TeamForSeat(seat.Seat)
+		teams[team] = append(teams[team], SeatConfig{DisplayName: seat.DisplayName, IsGuest: seat.IsGuest})
+	}
+	room := NewTeamGameRoomWithMap("replay-"+replay.ID, replay.Map, replay.Seed, teams)
+
+	// Replay on the original clock so timers line up with the recorded ticks
+	room.State.MatchStartTime = replay.StartedAt
+	room.State.Timestamp = replay.StartedAt
+
+	if replay.Rules != nil {
+		room.SetMatchRules(*replay.Rules)
+	}
+	room.SetGameMode(replay.GameMode)
+
+	// The match is over, so replays show everything even on maps with fog of war
+	room.fogOfWar = nil
+
+	// Keep enough unit positions to rewind shots as far as they were when recorded
+	var maxRewind int64
+	for _, input := range replay.Inputs {
+		maxRewind = max(maxRewind, input.Rewind)
+	}
+	room.SetMaxRewind(time.Duration(maxRewind) * time.Millisecond)
+
+	// Recreate the AI controller for any AI-controlled seat
+	for i, player := range replay.Players {
+		if player.AIDifficulty != "" {
+			room.SetAIController(NewAIController(i, player.AIDifficulty))
+		}
+	}
+	for _, seat := range replay.Seats {
+		if seat.AIDifficulty != "" {
+			room.SetAIController(NewAIController(seat.Seat, seat.AIDifficulty))
+		}
+	}
+
+	return &ReplayPlayback{
+		replay:   replay,
+		room:     room,
+		speed:    speed,
+		stopChan: make(chan bool),
+	}
+}
+
+// Start begins playback, streaming the re-simulated match to the spectator
+func (p *ReplayPlayback) Start(clientID string, conn ClientConnection, onEnd func()) {
+	p.onEnd = onEnd
+
+	p.room.mu.Lock()
+	p.room.IsRunning = true
+	p.room.spectators[clientID] = conn
+	p.room.mu.Unlock()
+
+	conn.SendMessage("spectate_start", types.SpectateStartPayload{
+		GameID:   p.room.ID,
+		State:    p.room.GetState(),
+		ReplayID: p.replay.ID,
+		Speed:    p.speed,
+	})
+
+	log.Printf("Replay %s started at %dx", p.replay.ID, p.speed)
+
+	go p.loop()
+}
+
+// Stop ends playback early
+func (p *ReplayPlayback) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.stopChan)
+	})
+}
+
+// loop drives the room's update loop at the playback speed
+func (p *ReplayPlayback) loop() {
+	ticker := time.NewTicker(types.TickDuration / time.Duration(p.speed))
+	defer ticker.Stop()
+	defer func() {
+		if p.onEnd != nil {
+			p.onEnd()
+		}
+	}()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.applyInputs()
+			p.room.update()
+
+			p.room.mu.RLock()
+			finished := !p.room.IsRunning || p.room.State.Tick > p.replay.TotalTicks
+			p.room.mu.RUnlock()
+			if finished {
+				return
+			}
+		case <-p.stopChan:
+			return
+		}
+	}
+}
+
+// applyInputs feeds every recorded input for the current tick into the room
+func (p *ReplayPlayback) applyInputs() {
+	p.room.mu.RLock()
+	tick := p.room.State.Tick
+	p.room.mu.RUnlock()
+
+	conn := &replayConnection{}
+	for p.nextInput < len(p.replay.Inputs) && p.replay.Inputs[p.nextInput].Tick <= tick {
+		input := p.replay.Inputs[p.nextInput]
+		p.nextInput++
+
+		switch input.Type {
+		case "purchase_unit":
+			p.room.HandlePurchase(input.PlayerID, input.UnitType)
+		case "player_move":
+			if input.Direction != nil {
+				p.room.HandlePlayerMove(input.PlayerID, *input.Direction, types.InputSequence{})
+			}
+		case "player_shoot":
+			p.room.replayPlayerShoot(input.PlayerID, input.TargetX, input.TargetZ, input.Rewind)
+		case "buy_from_zone":
+			p.room.HandleBuyFromZone(input.PlayerID, input.TargetID, conn)
+		case "bulk_buy_from_zone":
+			p.room.HandleBulkBuyFromZone(input.PlayerID, input.TargetID, conn)
+		case "claim_turret":
+			p.room.HandleClaimTurret(input.PlayerID, input.TargetID, conn)
+		case "claim_buy_zone":
+			p.room.HandleClaimBuyZone(input.PlayerID, input.TargetID, conn)
+		case "claim_barracks":
+			p.room.HandleClaimBarracks(input.PlayerID, input.TargetID, conn)
+		}
+	}
+}
+
+// replayConnection discards the error messages produced while re-applying inputs
+type replayConnection struct{}
+
+// SendMessage discards messages (the original player already saw them)
+func (c *replayConnection) SendMessage(msgType string, payload interface{}) {
+	// No-op
+}
@@ -14,8 +14,19 @@ import (
 // GameEndCallback is called when a game ends
 type GameEndCallback func(roomID string)
 
-// GameResultCallback is called with game results for leaderboard
-type GameResultCallback func(player1Name, player2Name string, winner int, matchDuration int, p1Stats, p2Stats types.PlayerStats)
+// GameResultCallback is called with game results for leaderboard, returning any rating changes indexed by player ID.
+// It's called while the room is locked, so it mustn't wait on the disk.
+type GameResultCallback func(players [2]MatchResultPlayer, winner int, reason string, matchDuration int) []*types.RatingChange
+
+// MatchResultPlayer is one side of a finished 1v1 match, as reported to the leaderboard
+type MatchResultPlayer struct {
+	UserID       string // Empty for AI players
+	DisplayName  string
+	AvatarURL    string
+	IsGuest      bool
+	AIDifficulty string // Difficulty of the AI playing this side (empty for humans)
+	Stats        types.PlayerStats
+}
 
 // GameRoom represents a single game instance
 type GameRoom struct {
@@ -24,14 +35,34 @@ type GameRoom struct {
 	IsRunning         bool
 	mu                sync.RWMutex
 	stopChan          chan bool
-	clientConnections map[int]ClientConnection    // Map player ID to client connection
+	clientConnections map[int]ClientConnection    // Map seat to client connection
 	spectators        map[string]ClientConnection // Map client ID to spectator connection
-	lastIncomeTime    time.Time
+	lastIncomeTime    int64 // Simulation time of the last income payout (0 until the first tick)
 	onGameEnd         GameEndCallback    // Callback when game ends
 	onGameResult      GameResultCallback // Callback for game results (leaderboard)
+	onReplay          ReplayCallback     // Callback with the recorded replay when the game ends
+	replayRecorder    *ReplayRecorder    // Records player inputs (nil when not recording)
+
+	// Callback with the record of the finished match for match history
+	onMatchRecord MatchRecordCallback
+
+	// Subscribers to the room's game events (kills, claims, the match ending)
+	observers []GameEventObserver
+
+	// Recent game_update snapshots of each view, and what each client has acknowledged, for delta updates
+	snapshotSequence int64
+	snapshots        map[stateView][]stateSnapshot
+	snapshotAcks     map[string]*snapshotAck // Client ID -> acknowledgement
+
+	// Fog of war settings from the map (nil when everyone sees everything)
+	fogOfWar *types.MapFogOfWar
+
+	// Recent unit positions, for resolving player shots against what the shooter saw
+	maxRewind       time.Duration
+	positionHistory *positionHistory
 
-	// AI controller (nil for human vs human games)
-	aiController *AIController
+	// AI controllers, one per computer-controlled player (empty for human vs human games)
+	aiControllers []*AIController
 
 	// Game systems
 	pathfindingSystem  *PathfindingSystem
@@ -49,10 +80,19 @@ type ClientConnection interface {
 	SendMessage(msgType string, payload interface{})
 }
 
-// NewGameRoomWithMap creates a new game room using a specific map
-func NewGameRoomWithMap(id string, mapDef *types.MapDefinition, player1ClientID, player1DisplayName string, player1IsGuest bool, player2ClientID, player2DisplayName string, player2IsGuest bool) *GameRoom {
-	state := NewStateWithMap(mapDef, player1ClientID, player1DisplayName, player1IsGuest, player2ClientID, player2DisplayName, player2IsGuest)
+// NewGameRoomWithMap creates a new 1v1 game room using a specific map
+func NewGameRoomWithMap(id string, mapDef *types.MapDefinition, seed int64, player1ClientID, player1UserID, player1DisplayName string, player1IsGuest bool, player2ClientID, player2UserID, player2DisplayName string, player2IsGuest bool) *GameRoom {
+	return newGameRoom(id, NewStateWithMap(mapDef, seed, player1ClientID, player1UserID, player1DisplayName, player1IsGuest, player2ClientID, player2UserID, player2DisplayName, player2IsGuest))
+}
+
+// NewTeamGameRoomWithMap creates a new game room for two teams using a specific map
+// Members are seated in order, see SeatFor
+func NewTeamGameRoomWithMap(id string, mapDef *types.MapDefinition, seed int64, teams [2][]SeatConfig) *GameRoom {
+	return newGameRoom(id, NewTeamStateWithMap(mapDef, seed, teams))
+}
 
+// newGameRoom creates a game room around an initial state
+func newGameRoom(id string, state *State) *GameRoom {
 	// Initialize spatial systems
 	spatialGrid := NewSpatialGrid(state.Obstacles)
 	pathfindingSystem := NewPathfindingSystem(state.Obstacles)
@@ -65,7 +105,12 @@ func NewGameRoomWithMap(id string, mapDef *types.MapDefinition, player1ClientID,
 		stopChan:           make(chan bool),
 		clientConnections:  make(map[int]ClientConnection),
 		spectators:         make(map[string]ClientConnection),
-		lastIncomeTime:     time.Now(),
+		snapshots:          make(map[stateView][]stateSnapshot),
+		snapshotAcks:       make(map[string]*snapshotAck),
+		fogOfWar:           state.MapDefinition.FogOfWar,
+		maxRewind:          types.DefaultMaxShotRewind,
+		positionHistory:    newPositionHistory(types.DefaultMaxShotRewind),
+		lastIncomeTime:     0,
 		pathfindingSystem:  pathfindingSystem,
 		spatialGrid:        spatialGrid,
 		losSystem:          losSystem,
@@ -77,11 +122,11 @@ func NewGameRoomWithMap(id string, mapDef *types.MapDefinition, player1ClientID,
 	}
 }
 
-// SetClientConnection sets the client connection for a player
-func (r *GameRoom) SetClientConnection(playerID int, conn ClientConnection) {
+// SetClientConnection sets the client connection for a seat
+func (r *GameRoom) SetClientConnection(seat int, conn ClientConnection) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.clientConnections[playerID] = conn
+	r.clientConnections[seat] = conn
 }
 
 // SetOnGameEnd sets the callback for when the game ends
@@ -98,11 +143,180 @@ func (r *GameRoom) SetOnGameResult(callback GameResultCallback) {
 	r.onGameResult = callback
 }
 
-// SetAIController sets the AI controller for computer-controlled player
+// SetOnReplay enables replay recording and sets the callback for the finished replay
+func (r *GameRoom) SetOnReplay(callback ReplayCallback) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onReplay = callback
+	r.replayRecorder = NewReplayRecorder(r.ID, r.State)
+}
+
+// SetOnMatchRecord sets the callback for the finished match's record (match history)
+func (r *GameRoom) SetOnMatchRecord(callback MatchRecordCallback) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onMatchRecord = callback
+}
+
+// recordInput appends a player input to the replay (if recording)
+// Note: Caller must hold the lock
+func (r *GameRoom) recordInput(input types.ReplayInput) {
+	if r.replayRecorder == nil {
+		return
+	}
+	input.Tick = r.State.Tick
+	r.replayRecorder.Record(input)
+}
+
+// SetMatchRules overrides the map's time limit, tiebreak, sudden-death and economy rules (e.g. for a lobby)
+// Must be called before the game starts
+func (r *GameRoom) SetMatchRules(rules types.MatchRules) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.State.SetRules(rules)
+}
+
+// SetGameMode switches the match to a game mode (see IsValidGameMode)
+// Must be called before the game starts
+func (r *GameRoom) SetGameMode(mode string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.State.SetGameMode(NewGameMode(mode))
+}
+
+// SetAIController adds an AI controller for a computer-controlled player
 func (r *GameRoom) SetAIController(ai *AIController) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.aiController = ai
+	r.aiControllers = append(r.aiControllers, ai)
+}
+
+// HandleDisconnect vacates a seat while the game keeps running.
+// The member's record and PlayerUnit stay reserved so the same user can reconnect
+// within types.ReconnectGracePeriod, otherwise their team loses by forfeit.
+func (r *GameRoom) HandleDisconnect(seat int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.IsRunning {
+		return
+	}
+
+	member := r.State.GetMember(seat)
+	if member == nil || member.IsDisconnected() {
+		return
+	}
+
+	delete(r.clientConnections, seat)
+	r.forgetSnapshotAcks(member.ClientID)
+	member.ClientID = ""
+	member.DisconnectedAt = r.State.Now()
+
+	// Stop the player where they are rather than letting them run on the last input, recording
+	// it as a move so replays stop them too
+	stop := types.Vector3{X: 0, Y: 0, Z: 0}
+	r.recordInput(types.ReplayInput{PlayerID: seat, Type: "player_move", Direction: &stop})
+	if playerUnit := r.State.GetSeatUnit(seat); playerUnit != nil {
+		playerUnit.SetMoveDirection(stop)
+	}
+
+	log.Printf("Game %s: seat %d (%s) disconnected, holding seat for %.0fs", r.ID, seat, member.DisplayName, types.ReconnectGracePeriod)
+
+	r.broadcastConnectionStatus("player_disconnected", types.PlayerConnectionPayload{
+		PlayerID:    member.TeamID,
+		Seat:        seat,
+		GracePeriod: types.ReconnectGracePeriod,
+	})
+}
+
+// Reconnect re-binds a disconnected seat to a new client connection
+// Returns the seat that was re-bound, or -1 if the user has no vacant seat in this room
+func (r *GameRoom) Reconnect(userID string, clientID string, conn ClientConnection) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.IsRunning || userID == "" {
+		return -1
+	}
+
+	for _, player := range r.State.Players {
+		for _, member := range player.Members {
+			if member.UserID != userID || !member.IsDisconnected() {
+				continue
+			}
+
+			member.ClientID = clientID
+			member.DisconnectedAt = 0
+			member.LastInput = types.InputSequence{} // The new connection numbers its inputs from scratch
+			r.clientConnections[member.Seat] = conn
+
+			log.Printf("Game %s: seat %d (%s) reconnected", r.ID, member.Seat, member.DisplayName)
+
+			// Send a fresh snapshot so the client can rebuild the scene
+			conn.SendMessage("game_start", types.GameStartPayload{
+				GameID:      r.ID,
+				PlayerID:    player.ID,
+				Seat:        member.Seat,
+				State:       r.visibleState(r.playerView(member.Seat), nil),
+				Map:         r.State.MapDefinition,
+				Reconnected: true,
+			})
+
+			r.broadcastConnectionStatus("player_reconnected", types.PlayerConnectionPayload{
+				PlayerID: player.ID,
+				Seat:     member.Seat,
+			})
+			return member.Seat
+		}
+	}
+
+	return -1
+}
+
+// GetSeat returns the seat a client occupies, or -1 if they aren't playing in this room
+func (r *GameRoom) GetSeat(clientID string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, player := range r.State.Players {
+		for _, member := range player.Members {
+			if member.ClientID == clientID {
+				return member.Seat
+			}
+		}
+	}
+	return -1
+}
+
+// HasOtherHumanPlayers returns true if anyone other than the given client is still
+// connected to a seat (AI players don't count)
+func (r *GameRoom) HasOtherHumanPlayers(clientID string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, player := range r.State.Players {
+		for _, member := range player.Members {
+			if member.ClientID != clientID && member.UserID != "" && !member.IsDisconnected() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// broadcastConnectionStatus notifies players and spectators about a seat being vacated or re-bound
+// Note: Caller must hold the lock
+func (r *GameRoom) broadcastConnectionStatus(msgType string, payload types.PlayerConnectionPayload) {
+	for seat, conn := range r.clientConnections {
+		if seat == payload.Seat {
+			continue
+		}
+		conn.SendMessage(msgType, payload)
+	}
+
+	for _, conn := range r.spectators {
+		conn.SendMessage(msgType, payload)
+	}
 }
 
 // AddSpectator adds a spectator to the game room
@@ -112,7 +326,7 @@ func (r *GameRoom) AddSpectator(clientID string, conn ClientConnection) {
 	r.spectators[clientID] = conn
 
 	// Send current game state to the new spectator
-	stateData := r.State.ToType()
+	stateData := r.visibleState(r.spectatorView(), nil)
 	conn.SendMessage("spectate_start", types.SpectateStartPayload{
 		GameID: r.ID,
 		State:  stateData,
@@ -125,6 +339,7 @@ func (r *GameRoom) RemoveSpectator(clientID string) {
 	defer r.mu.Unlock()
 	if _, exists := r.spectators[clientID]; exists {
 		delete(r.spectators, clientID)
+		r.forgetSnapshotAcks(clientID)
 	}
 }
 
@@ -190,6 +405,12 @@ func (r *GameRoom) gameLoop() {
 	}
 }
 
+// Step advances the game by a single tick without the real-time ticker.
+// Used by the headless simulation runner; Start must not be called on the same room.
+func (r *GameRoom) Step() {
+	r.update()
+}
+
 // update updates the game state for one tick
 func (r *GameRoom) update() {
 	// Use a variable to store any callback that needs to be called after releasing the lock
@@ -208,14 +429,16 @@ func (r *GameRoom) update() {
 		return
 	}
 
+	r.State.Tick++
+
 	deltaTime := float64(types.TickDuration) / float64(time.Second)
 
 	// Update passive income
 	r.updateIncome()
 
 	// Update AI (if present)
-	if r.aiController != nil {
-		r.aiController.Update(r.State, r)
+	for _, ai := range r.aiControllers {
+		ai.Update(r.State, r)
 	}
 
 	// Update movement
@@ -235,6 +458,9 @@ func (r *GameRoom) update() {
 	// Update turrets (combat and respawns)
 	r.turretSystem.Update(r.State, deltaTime)
 
+	// Update the game mode's objective (hills, flags)
+	r.State.Mode.Update(r.State, deltaTime)
+
 	// Update health packs (spawning and collection)
 	r.healthPackSystem.Update(r.State)
 
@@ -244,8 +470,15 @@ func (r *GameRoom) update() {
 	// Check player respawns
 	r.checkPlayerRespawns()
 
-	// Check win condition
-	if hasWinner, winnerID, reason := r.winConditionSystem.Check(r.State); hasWinner {
+	// Hand this tick's events to the observers
+	r.dispatchEvents()
+
+	// Check for players whose reconnect grace period has expired, then the win condition
+	hasWinner, winnerID, reason := r.checkDisconnectForfeit()
+	if !hasWinner {
+		hasWinner, winnerID, reason = r.winConditionSystem.Check(r.State)
+	}
+	if hasWinner {
 		r.State.GameStatus = "finished"
 		r.State.Winner = &winnerID
 		r.broadcastGameOver(winnerID, reason)
@@ -263,26 +496,69 @@ func (r *GameRoom) update() {
 
 // updateIncome handles passive income for both players
 func (r *GameRoom) updateIncome() {
-	now := time.Now()
-	elapsed := now.Sub(r.lastIncomeTime).Seconds()
+	now := r.State.Now()
+	if r.lastIncomeTime == 0 {
+		r.lastIncomeTime = now
+	}
+	elapsed := float64(now-r.lastIncomeTime) / 1000.0
 
 	// Give income approximately every second
 	if elapsed >= 1.0 {
-		incomeAmount := int(elapsed * types.PassiveIncomePerSecond)
+		incomeRate := float64(types.PassiveIncomePerSecond)
+		if r.State.SuddenDeath {
+			incomeRate *= r.State.Rules.SuddenDeathIncomeMultiplier
+		}
+		incomeAmount := int(elapsed * incomeRate)
 
+		// Teams earn per member, split economies share it out in distributeTeamMoney
 		for _, player := range r.State.Players {
-			player.AddMoney(incomeAmount)
+			player.AddMoney(incomeAmount * len(player.Members))
 		}
 
+		// Share team income and the rewards earned since the last income out to members
+		r.State.distributeTeamMoney()
+
 		r.lastIncomeTime = now
 	}
 }
 
+// checkDisconnectForfeit checks if every member of a team has run out of time to reconnect
+// A team with anyone still connected (including AI teammates) plays on
+// Returns (hasWinner, winnerID, reason)
+func (r *GameRoom) checkDisconnectForfeit() (bool, int, string) {
+	now := r.State.Now()
+	gracePeriod := int64(types.ReconnectGracePeriod * 1000)
+
+	for _, player := range r.State.Players {
+		forfeited := true
+		for _, member := range player.Members {
+			if !member.IsDisconnected() || now-member.DisconnectedAt < gracePeriod {
+				forfeited = false
+				break
+			}
+		}
+		if forfeited {
+			return true, 1 - player.ID, "Opponent disconnected"
+		}
+	}
+
+	return false, -1, ""
+}
+
 // checkPlayerRespawns checks if any player units need to respawn
 func (r *GameRoom) checkPlayerRespawns() {
 	for _, unit := range r.State.Units {
-		if playerUnit, ok := unit.(*PlayerUnit); ok {
-			playerUnit.CheckRespawn()
+		if playerUnit, ok := unit.(*PlayerUnit); ok && playerUnit.CheckRespawn(r.State.Now()) {
+			position := playerUnit.GetPosition()
+			r.State.emitAt(types.GameEvent{
+				Type:           types.GameEventPlayerRespawned,
+				PlayerID:       playerUnit.GetOwnerID(),
+				Seat:           playerUnit.Seat,
+				UnitType:       "player",
+				TargetID:       playerUnit.GetID(),
+				TargetType:     "player",
+				TargetPlayerID: playerUnit.GetOwnerID(),
+			}, &position)
 		}
 	}
 }
@@ -294,9 +570,20 @@ func (r *GameRoom) updateBarracks(deltaTime float64) {
 		barracks.Update(deltaTime)
 
 		// Process any pending scatter from destruction
-		scatteredIDs := barracks.GetAndClearPendingScatter()
+		scatteredIDs, scatterOwnerID := barracks.GetAndClearPendingScatter()
 		for _, unitID := range scatteredIDs {
-			r.scatterInfantryFromBarracks(unitID, barracks.Position)
+			r.scatterInfantryFromBarracks(unitID, barracks.Position, barracks.DestroyedByID)
+		}
+		if len(scatteredIDs) > 0 {
+			r.State.emit(types.GameEvent{
+				Type:           types.GameEventBarracksScattered,
+				PlayerID:       -1,
+				Seat:           -1,
+				Count:          len(scatteredIDs),
+				TargetID:       barracks.ID,
+				TargetType:     "barracks",
+				TargetPlayerID: scatterOwnerID,
+			})
 		}
 
 		// Skip occupant tracking if barracks is destroyed
@@ -328,15 +615,20 @@ func (r *GameRoom) updateBarracks(deltaTime float64) {
 	}
 }
 
-// scatterInfantryFromBarracks moves an infantry unit outside the barracks and applies damage
-func (r *GameRoom) scatterInfantryFromBarracks(unitID string, barracksPos types.Vector3) {
+// scatterInfantryFromBarracks moves an infantry unit outside the barracks and applies damage,
+// crediting the team that destroyed the barracks (-1 for none) with the damage done to enemies
+func (r *GameRoom) scatterInfantryFromBarracks(unitID string, barracksPos types.Vector3, destroyedByID int) {
 	unit := r.State.GetUnitByID(unitID)
 	if unit == nil || !unit.IsInfantry() {
 		return
 	}
 
 	// Apply scatter damage
+	health := unit.GetHealth()
 	unit.TakeDamage(types.BarracksScatterDamage)
+	if unit.GetOwnerID() != destroyedByID {
+		r.State.GetPlayer(destroyedByID).recordDamage(health - unit.GetHealth())
+	}
 
 	// Scatter in a random direction
 	// Use unit ID hash for deterministic scatter direction
@@ -360,14 +652,18 @@ func (r *GameRoom) scatterInfantryFromBarracks(unitID string, barracksPos types.
 }
 
 // HandlePurchase handles a unit purchase request
-func (r *GameRoom) HandlePurchase(playerID int, unitType string) {
+func (r *GameRoom) HandlePurchase(seat int, unitType string) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	playerID := TeamForSeat(seat)
+
 	if r.State.GameStatus != "playing" {
 		return
 	}
 
+	r.recordInput(types.ReplayInput{PlayerID: seat, Type: "purchase_unit", UnitType: unitType})
+
 	player := r.State.GetPlayer(playerID)
 	if player == nil {
 		log.Printf("Player %d not found", playerID)
@@ -400,8 +696,9 @@ func (r *GameRoom) HandlePurchase(playerID int, unitType string) {
 	}
 
 	// Check if player can afford
-	if !player.CanAfford(cost) {
-		if conn, ok := r.clientConnections[playerID]; ok {
+	wallet := r.State.WalletFor(seat)
+	if !wallet.CanAfford(cost) {
+		if conn, ok := r.clientConnections[seat]; ok {
 			conn.SendMessage("error", types.ErrorPayload{
 				Message: "Not enough money",
 			})
@@ -412,7 +709,7 @@ func (r *GameRoom) HandlePurchase(playerID int, unitType string) {
 	// Check super unit limit (only 1 super tank and 1 super helicopter per player)
 	if unitType == "super_tank" || unitType == "super_helicopter" {
 		if r.State.HasSuperUnit(playerID, unitType) {
-			if conn, ok := r.clientConnections[playerID]; ok {
+			if conn, ok := r.clientConnections[seat]; ok {
 				unitName := "Super Tank"
 				if unitType == "super_helicopter" {
 					unitName = "Super Helicopter"
@@ -426,7 +723,8 @@ func (r *GameRoom) HandlePurchase(playerID int, unitType string) {
 	}
 
 	// Deduct cost
-	player.Spend(cost)
+	r.State.Spend(seat, cost)
+	r.State.RecordPurchase(seat, unitType, 1)
 
 	// Create unit
 	var unit Unit
@@ -446,7 +744,7 @@ func (r *GameRoom) HandlePurchase(playerID int, unitType string) {
 }
 
 // HandlePlayerMove handles player movement input
-func (r *GameRoom) HandlePlayerMove(playerID int, direction types.Vector3) {
+func (r *GameRoom) HandlePlayerMove(seat int, direction types.Vector3, input types.InputSequence) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -454,7 +752,10 @@ func (r *GameRoom) HandlePlayerMove(playerID int, direction types.Vector3) {
 		return
 	}
 
-	playerUnit := r.State.GetPlayerUnit(playerID)
+	r.recordInput(types.ReplayInput{PlayerID: seat, Type: "player_move", Direction: &direction})
+	r.acknowledgeInput(seat, input)
+
+	playerUnit := r.State.GetSeatUnit(seat)
 	if playerUnit == nil {
 		return
 	}
@@ -463,14 +764,18 @@ func (r *GameRoom) HandlePlayerMove(playerID int, direction types.Vector3) {
 }
 
 // HandleBuyFromZone handles a buy from zone request
-func (r *GameRoom) HandleBuyFromZone(playerID int, zoneID string, conn ClientConnection) {
+func (r *GameRoom) HandleBuyFromZone(seat int, zoneID string, conn ClientConnection) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	playerID := TeamForSeat(seat)
+
 	if r.State.GameStatus != "playing" {
 		return
 	}
 
+	r.recordInput(types.ReplayInput{PlayerID: seat, Type: "buy_from_zone", TargetID: zoneID})
+
 	// Find the buy zone
 	var zone *BuyZone
 	for _, z := range r.State.BuyZones {
@@ -495,14 +800,14 @@ func (r *GameRoom) HandleBuyFromZone(playerID int, zoneID string, conn ClientCon
 		return
 	}
 
-	// Get the player
-	player := r.State.GetPlayer(playerID)
-	if player == nil {
+	// Get the wallet this seat spends from
+	wallet := r.State.WalletFor(seat)
+	if wallet == nil {
 		return
 	}
 
 	// Get the player unit
-	playerUnit := r.State.GetPlayerUnit(playerID)
+	playerUnit := r.State.GetSeatUnit(seat)
 	if playerUnit == nil || !playerUnit.IsAlive() {
 		conn.SendMessage("error", types.ErrorPayload{
 			Message: "You must be alive to purchase",
@@ -519,7 +824,7 @@ func (r *GameRoom) HandleBuyFromZone(playerID int, zoneID string, conn ClientCon
 	}
 
 	// Check if player can afford
-	if !player.CanAfford(zone.Cost) {
+	if !wallet.CanAfford(zone.Cost) {
 		conn.SendMessage("error", types.ErrorPayload{
 			Message: "Not enough money",
 		})
@@ -541,7 +846,8 @@ func (r *GameRoom) HandleBuyFromZone(playerID int, zoneID string, conn ClientCon
 	}
 
 	// Deduct cost
-	player.Spend(zone.Cost)
+	r.State.Spend(seat, zone.Cost)
+	r.State.RecordPurchase(seat, zone.UnitType, 1)
 
 	// Queue the spawn instead of creating immediately
 	spawnPos := zone.Position
@@ -562,18 +868,22 @@ func (r *GameRoom) HandleBuyFromZone(playerID int, zoneID string, conn ClientCon
 	}
 
 	// Add to spawn queue
-	r.State.SpawnQueue.Add(zone.UnitType, playerID, spawnPos, targetPos, zoneID)
+	r.State.SpawnQueue.Add(zone.UnitType, playerID, spawnPos, targetPos, zoneID, r.State.Now())
 }
 
 // HandleBulkBuyFromZone handles a bulk purchase of 10 units at 10% discount
-func (r *GameRoom) HandleBulkBuyFromZone(playerID int, zoneID string, conn ClientConnection) {
+func (r *GameRoom) HandleBulkBuyFromZone(seat int, zoneID string, conn ClientConnection) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	playerID := TeamForSeat(seat)
+
 	if r.State.GameStatus != "playing" {
 		return
 	}
 
+	r.recordInput(types.ReplayInput{PlayerID: seat, Type: "bulk_buy_from_zone", TargetID: zoneID})
+
 	// Find the buy zone
 	var zone *BuyZone
 	for _, z := range r.State.BuyZones {
@@ -606,14 +916,14 @@ func (r *GameRoom) HandleBulkBuyFromZone(playerID int, zoneID string, conn Clien
 		return
 	}
 
-	// Get the player
-	player := r.State.GetPlayer(playerID)
-	if player == nil {
+	// Get the wallet this seat spends from
+	wallet := r.State.WalletFor(seat)
+	if wallet == nil {
 		return
 	}
 
 	// Get the player unit
-	playerUnit := r.State.GetPlayerUnit(playerID)
+	playerUnit := r.State.GetSeatUnit(seat)
 	if playerUnit == nil || !playerUnit.IsAlive() {
 		conn.SendMessage("error", types.ErrorPayload{
 			Message: "You must be alive to purchase",
@@ -634,7 +944,7 @@ func (r *GameRoom) HandleBulkBuyFromZone(playerID int, zoneID string, conn Clien
 	totalCost := int(float64(zone.Cost*quantity) * (1.0 - types.BulkBuyDiscount))
 
 	// Check if player can afford
-	if !player.CanAfford(totalCost) {
+	if !wallet.CanAfford(totalCost) {
 		conn.SendMessage("error", types.ErrorPayload{
 			Message: fmt.Sprintf("Not enough money! Need $%d for %d units", totalCost, quantity),
 		})
@@ -642,7 +952,8 @@ func (r *GameRoom) HandleBulkBuyFromZone(playerID int, zoneID string, conn Clien
 	}
 
 	// Deduct cost
-	player.Spend(totalCost)
+	r.State.Spend(seat, totalCost)
+	r.State.RecordPurchase(seat, zone.UnitType, quantity)
 
 	// Queue all spawns
 	spawnPos := zone.Position
@@ -657,19 +968,23 @@ func (r *GameRoom) HandleBulkBuyFromZone(playerID int, zoneID string, conn Clien
 
 	// Add all units to spawn queue
 	for i := 0; i < quantity; i++ {
-		r.State.SpawnQueue.Add(zone.UnitType, playerID, spawnPos, targetPos, zoneID)
+		r.State.SpawnQueue.Add(zone.UnitType, playerID, spawnPos, targetPos, zoneID, r.State.Now())
 	}
 }
 
 // HandleClaimTurret handles a turret claiming request
-func (r *GameRoom) HandleClaimTurret(playerID int, turretID string, conn ClientConnection) {
+func (r *GameRoom) HandleClaimTurret(seat int, turretID string, conn ClientConnection) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	playerID := TeamForSeat(seat)
+
 	if r.State.GameStatus != "playing" {
 		return
 	}
 
+	r.recordInput(types.ReplayInput{PlayerID: seat, Type: "claim_turret", TargetID: turretID})
+
 	// Find the turret
 	turret := r.State.GetTurretByID(turretID)
 	if turret == nil {
@@ -680,7 +995,7 @@ func (r *GameRoom) HandleClaimTurret(playerID int, turretID string, conn ClientC
 	}
 
 	// Get the player unit
-	playerUnit := r.State.GetPlayerUnit(playerID)
+	playerUnit := r.State.GetSeatUnit(seat)
 	if playerUnit == nil || !playerUnit.IsAlive() {
 		conn.SendMessage("error", types.ErrorPayload{
 			Message: "You must be alive to claim a turret",
@@ -716,23 +1031,28 @@ func (r *GameRoom) HandleClaimTurret(playerID int, turretID string, conn ClientC
 
 	// Claim the turret
 	turret.Claim(playerID)
+	r.State.recordClaim(playerID, seat, "player", "turret", turret.ID)
 
-	// Reward player for claiming turret
+	// Reward the team for claiming turret
 	player := r.State.GetPlayer(playerID)
 	if player != nil {
-		player.Money += types.TurretClaimReward
+		player.AddMoney(types.TurretClaimReward)
 	}
 }
 
 // HandleClaimBuyZone handles a buy zone claiming request
-func (r *GameRoom) HandleClaimBuyZone(playerID int, zoneID string, conn ClientConnection) {
+func (r *GameRoom) HandleClaimBuyZone(seat int, zoneID string, conn ClientConnection) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	playerID := TeamForSeat(seat)
+
 	if r.State.GameStatus != "playing" {
 		return
 	}
 
+	r.recordInput(types.ReplayInput{PlayerID: seat, Type: "claim_buy_zone", TargetID: zoneID})
+
 	// Find the buy zone
 	var zone *BuyZone
 	for _, z := range r.State.BuyZones {
@@ -750,7 +1070,7 @@ func (r *GameRoom) HandleClaimBuyZone(playerID int, zoneID string, conn ClientCo
 	}
 
 	// Get the player unit
-	playerUnit := r.State.GetPlayerUnit(playerID)
+	playerUnit := r.State.GetSeatUnit(seat)
 	if playerUnit == nil || !playerUnit.IsAlive() {
 		conn.SendMessage("error", types.ErrorPayload{
 			Message: "You must be alive to claim a base",
@@ -785,8 +1105,8 @@ func (r *GameRoom) HandleClaimBuyZone(playerID int, zoneID string, conn ClientCo
 	}
 
 	// Check if player has enough money to claim
-	player := r.State.Players[playerID]
-	if player.Money < zone.ClaimCost {
+	wallet := r.State.WalletFor(seat)
+	if !wallet.CanAfford(zone.ClaimCost) {
 		conn.SendMessage("error", types.ErrorPayload{
 			Message: fmt.Sprintf("Not enough money! Need $%d", zone.ClaimCost),
 		})
@@ -794,10 +1114,11 @@ func (r *GameRoom) HandleClaimBuyZone(playerID int, zoneID string, conn ClientCo
 	}
 
 	// Deduct the claim cost
-	player.Money -= zone.ClaimCost
+	r.State.Spend(seat, zone.ClaimCost)
 
 	// Claim the zone
 	zone.Claim(playerID)
+	r.State.recordClaim(playerID, seat, "player", "buy_zone", zone.ID)
 
 	// If this is a forward base, also claim all child zones
 	if zone.UnitType == "" && zone.IsClaimable {
@@ -810,14 +1131,18 @@ func (r *GameRoom) HandleClaimBuyZone(playerID int, zoneID string, conn ClientCo
 }
 
 // HandleClaimBarracks handles a barracks claiming request
-func (r *GameRoom) HandleClaimBarracks(playerID int, barracksID string, conn ClientConnection) {
+func (r *GameRoom) HandleClaimBarracks(seat int, barracksID string, conn ClientConnection) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	playerID := TeamForSeat(seat)
+
 	if r.State.GameStatus != "playing" {
 		return
 	}
 
+	r.recordInput(types.ReplayInput{PlayerID: seat, Type: "claim_barracks", TargetID: barracksID})
+
 	// Find the barracks
 	var barracks *Barracks
 	for _, b := range r.State.Barracks {
@@ -835,7 +1160,7 @@ func (r *GameRoom) HandleClaimBarracks(playerID int, barracksID string, conn Cli
 	}
 
 	// Get the player unit
-	playerUnit := r.State.GetPlayerUnit(playerID)
+	playerUnit := r.State.GetSeatUnit(seat)
 	if playerUnit == nil || !playerUnit.IsAlive() {
 		conn.SendMessage("error", types.ErrorPayload{
 			Message: "You must be alive to claim a barracks",
@@ -867,10 +1192,25 @@ func (r *GameRoom) HandleClaimBarracks(playerID int, barracksID string, conn Cli
 
 	// Claim the barracks (free for infantry)
 	barracks.Claim(playerID)
+	r.State.recordClaim(playerID, seat, "player", "barracks", barracks.ID)
+}
+
+// HandlePlayerShoot handles player shoot command. To make up for the shooter's latency, the target
+// is picked from where units were in the game update they had applied (see shotRewind).
+func (r *GameRoom) HandlePlayerShoot(seat int, targetX, targetZ float64, viewSnapshot int64, input types.InputSequence) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.State.GameStatus != "playing" {
+		return
+	}
+
+	r.acknowledgeInput(seat, input)
+	r.playerShoot(seat, targetX, targetZ, r.shotRewind(viewSnapshot))
 }
 
-// HandlePlayerShoot handles player shoot command
-func (r *GameRoom) HandlePlayerShoot(playerID int, targetX, targetZ float64) {
+// replayPlayerShoot handles a recorded player shoot command, rewound as far as it was when recorded
+func (r *GameRoom) replayPlayerShoot(seat int, targetX, targetZ float64, rewind int64) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -878,17 +1218,25 @@ func (r *GameRoom) HandlePlayerShoot(playerID int, targetX, targetZ float64) {
 		return
 	}
 
-	playerUnit := r.State.GetPlayerUnit(playerID)
+	r.playerShoot(seat, targetX, targetZ, rewind)
+}
+
+// playerShoot fires a seat's player unit at a position, picking the target from where units were
+// rewind milliseconds ago
+// Note: Caller must hold the lock
+func (r *GameRoom) playerShoot(seat int, targetX, targetZ float64, rewind int64) {
+	playerID := TeamForSeat(seat)
+
+	r.recordInput(types.ReplayInput{PlayerID: seat, Type: "player_shoot", TargetX: targetX, TargetZ: targetZ, Rewind: rewind})
+
+	playerUnit := r.State.GetSeatUnit(seat)
 	if playerUnit == nil || !playerUnit.IsAlive() {
 		return
 	}
 
 	// Check attack cooldown
-	now := time.Now().UnixMilli()
-	timeSinceLastAttack := now - playerUnit.GetLastAttackTime()
-	attackCooldown := int64(1000.0 / playerUnit.GetAttackSpeed())
-
-	if timeSinceLastAttack < attackCooldown {
+	now := r.State.Now()
+	if playerUnit.ShootCooldownRemaining(now) > 0 {
 		return // Still on cooldown
 	}
 
@@ -910,7 +1258,8 @@ func (r *GameRoom) HandlePlayerShoot(playerID int, targetX, targetZ float64) {
 		return // Blocked by obstacle
 	}
 
-	// Find if there's a unit at the target position (or close to it)
+	// Find if there's a unit at the target position (or close to it), where the shooter saw it
+	rewound := r.rewoundPositions(rewind)
 	var targetUnit Unit
 	for _, unit := range r.State.Units {
 		if unit.GetOwnerID() == playerID {
@@ -920,7 +1269,10 @@ func (r *GameRoom) HandlePlayerShoot(playerID int, targetX, targetZ float64) {
 			continue
 		}
 
-		unitPos := unit.GetPosition()
+		unitPos, seen := rewound[unit.GetID()]
+		if !seen {
+			unitPos = unit.GetPosition()
+		}
 		distToTarget := calculateDistance(unitPos, targetPos)
 		if distToTarget < 3.0 { // Close enough to target position
 			targetUnit = unit
@@ -966,12 +1318,23 @@ func (r *GameRoom) HandlePlayerShoot(playerID int, targetX, targetZ float64) {
 		}
 	}
 
+	// Check if the shot lands on the enemy base
+	var targetBase *Base
+	if targetUnit == nil && targetTurret == nil && targetBarracks == nil {
+		enemyBase := r.State.Bases[1-playerID]
+		if enemyBase.IsAlive() && calculateDistance(enemyBase.Position, targetPos) < enemyBase.Radius+1.0 {
+			targetBase = enemyBase
+		}
+	}
+
 	// Create projectile
 	var projectile *Projectile
 	if targetTurret != nil {
 		projectile = NewProjectileFromPlayerToTurret(playerUnit, targetTurret, now)
 	} else if targetBarracks != nil {
 		projectile = NewProjectileToBarracks(playerUnit, targetBarracks, now)
+	} else if targetBase != nil {
+		projectile = NewProjectileToBase(playerUnit, targetBase, now)
 	} else {
 		projectile = NewProjectileFromPlayer(playerUnit, targetPos, targetUnit, now)
 	}
@@ -984,33 +1347,58 @@ func (r *GameRoom) broadcastGameStart() {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	for playerID, conn := range r.clientConnections {
+	for seat, conn := range r.clientConnections {
 		payload := types.GameStartPayload{
 			GameID:   r.ID,
-			PlayerID: playerID,
-			State:    r.State.ToType(),
+			PlayerID: TeamForSeat(seat),
+			Seat:     seat,
+			State:    r.visibleState(r.playerView(seat), nil),
 			Map:      r.State.MapDefinition,
 		}
 		conn.SendMessage("game_start", payload)
 	}
 }
 
-// broadcastState sends the current game state to all players and spectators
+// broadcastState sends the current game state to all players and spectators, as much of it as
+// their view shows (see stateView), as a delta against what each of them last acknowledged
+// (see stateUpdateFor)
 func (r *GameRoom) broadcastState() {
-	stateData := r.State.ToType()
-	payload := types.GameUpdatePayload{
-		Timestamp: r.State.Timestamp,
-		State:     stateData,
+	r.snapshotSequence++
+	r.positionHistory.record(r.snapshotSequence, r.State.Now(), r.State.Units)
+	snapshots := make(map[stateView]*stateSnapshot)
+	updates := make(map[stateUpdateKey]*types.SharedPayload)
+
+	var sight *teamSight
+	if r.fogOfWar != nil {
+		sight = r.computeTeamSight()
 	}
 
 	// Send to players
-	for _, conn := range r.clientConnections {
-		conn.SendMessage("game_update", payload)
+	for seat, conn := range r.clientConnections {
+		view := r.playerView(seat)
+		if snapshots[view] == nil {
+			snapshots[view] = r.takeSnapshot(view, sight)
+		}
+
+		clientID := ""
+		if member := r.State.GetMember(seat); member != nil {
+			clientID = member.ClientID
+		}
+		conn.SendMessage("game_update", r.stateUpdateFor(clientID, snapshots[view], updates))
+
+		// Players also get their own inputs and movement, separately so the update can be shared
+		if input := r.playerInputAck(seat); input != nil {
+			conn.SendMessage("input_ack", input)
+		}
 	}
 
 	// Send to spectators
-	for _, conn := range r.spectators {
-		conn.SendMessage("game_update", payload)
+	view := r.spectatorView()
+	for clientID, conn := range r.spectators {
+		if snapshots[view] == nil {
+			snapshots[view] = r.takeSnapshot(view, sight)
+		}
+		conn.SendMessage("game_update", r.stateUpdateFor(clientID, snapshots[view], updates))
 	}
 }
 
@@ -1029,6 +1417,19 @@ func (r *GameRoom) broadcastGameOver(winner int, reason string) {
 		p2Stats.TotalPoints += winBonus
 	}
 
+	// Record to leaderboard, keyed by each player's user ID
+	// Team games aren't ranked: ratings and the leaderboard are for 1v1 only, as there's no
+	// team rating yet and a team result says little about each member's skill
+	var ratings []*types.RatingChange
+	ranked := r.onGameResult != nil && !r.State.Players[0].IsTeam() && !r.State.Players[1].IsTeam()
+	if ranked {
+		players := [2]MatchResultPlayer{
+			r.matchResultPlayer(0, p1Stats),
+			r.matchResultPlayer(1, p2Stats),
+		}
+		ratings = r.onGameResult(players, winner, reason, matchDuration)
+	}
+
 	payload := types.GameOverPayload{
 		Winner:        winner,
 		Reason:        reason,
@@ -1039,6 +1440,7 @@ func (r *GameRoom) broadcastGameOver(winner int, reason string) {
 			Player1Stats: p1Stats,
 			Player2Stats: p2Stats,
 		},
+		Ratings: ratings,
 	}
 
 	// Send to players
@@ -1055,14 +1457,55 @@ func (r *GameRoom) broadcastGameOver(winner int, reason string) {
 		r.ID, winner, reason, matchDuration,
 		p1Stats.TotalPoints, p2Stats.TotalPoints)
 
-	// Record to leaderboard using display names (GitHub username or Guest_XXXX)
-	if r.onGameResult != nil {
-		// Call callback outside lock - use goroutine to avoid blocking
-		callback := r.onGameResult
-		p1Name := r.State.Players[0].DisplayName
-		p2Name := r.State.Players[1].DisplayName
-		go callback(p1Name, p2Name, winner, matchDuration, p1Stats, p2Stats)
+	// Let the observers know the match is over
+	r.State.emit(types.GameEvent{
+		Type:           types.GameEventMatchEnded,
+		PlayerID:       winner,
+		Seat:           -1,
+		TargetPlayerID: -1,
+		Reason:         reason,
+	})
+	r.dispatchEvents()
+
+	// Hand the match over for the players' history
+	if r.onMatchRecord != nil {
+		record := r.buildMatchRecord(winner, reason, matchDuration, ranked, [2]types.PlayerStats{p1Stats, p2Stats}, ratings)
+		r.onMatchRecord(record)
+	}
+
+	// Hand the finished replay over for storage
+	if r.replayRecorder != nil && r.onReplay != nil {
+		for _, ai := range r.aiControllers {
+			r.replayRecorder.SetAIDifficulty(ai.seat, ai.difficulty)
+		}
+		replay := r.replayRecorder.Finish(r.State, winner, reason, matchDuration)
+		r.onReplay(replay)
+	}
+}
+
+// matchResultPlayer describes the player in a 1v1 seat for the leaderboard
+// Note: Caller must hold the lock
+func (r *GameRoom) matchResultPlayer(seat int, stats types.PlayerStats) MatchResultPlayer {
+	member := r.State.GetMember(seat)
+	return MatchResultPlayer{
+		UserID:       member.UserID,
+		DisplayName:  member.DisplayName,
+		AvatarURL:    member.AvatarURL,
+		IsGuest:      member.IsGuest,
+		AIDifficulty: r.aiDifficultyFor(seat),
+		Stats:        stats,
+	}
+}
+
+// aiDifficultyFor returns the difficulty of the AI controlling a seat, or "" for a human seat
+// Note: Caller must hold the lock
+func (r *GameRoom) aiDifficultyFor(seat int) string {
+	for _, ai := range r.aiControllers {
+		if ai.seat == seat {
+			return ai.difficulty
+		}
 	}
+	return ""
 }
 
 // GetState returns a copy of the current game state (thread-safe)
@@ -1072,20 +1515,38 @@ func (r *GameRoom) GetState() types.GameState {
 	return r.State.ToType()
 }
 
-// GetClientIDs returns the client IDs of both players
+// GetClientIDs returns the client IDs of every seated player
 func (r *GameRoom) GetClientIDs() []string {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	clientIDs := make([]string, 0, 2)
 	for _, player := range r.State.Players {
-		if player.ClientID != "" {
-			clientIDs = append(clientIDs, player.ClientID)
+		for _, member := range player.Members {
+			if member.ClientID != "" {
+				clientIDs = append(clientIDs, member.ClientID)
+			}
 		}
 	}
 	return clientIDs
 }
 
+// GetUserIDs returns the persistent user IDs of every seated player (excluding AI players)
+func (r *GameRoom) GetUserIDs() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	userIDs := make([]string, 0, 2)
+	for _, player := range r.State.Players {
+		for _, member := range player.Members {
+			if member.UserID != "" {
+				userIDs = append(userIDs, member.UserID)
+			}
+		}
+	}
+	return userIDs
+}
+
 // GetGameInfo returns summary information about the game for lobby display
 func (r *GameRoom) GetGameInfo() types.ActiveGame {
 	r.mu.RLock()
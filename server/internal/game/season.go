@@ -0,0 +1,210 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tombuildsstuff/web-arena-game/server/internal/storage"
+	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
+)
+
+// SeasonArchive is the final standings of a season that has ended
+type SeasonArchive struct {
+	Season     types.Season       `json:"season"`
+	ArchivedAt int64              `json:"archivedAt"` // Unix timestamp
+	Entries    []LeaderboardEntry `json:"entries"`
+}
+
+// Seasons keeps the season calendar and the archived standings of seasons that have ended.
+// Each season's standings only count matches played during it, so every season starts from zero.
+type Seasons struct {
+	defined  []types.Season // Seasons from the seasons file (nil = calendar quarters)
+	archives map[string]*SeasonArchive
+	store    storage.Store
+	mu       sync.RWMutex
+}
+
+// NewSeasons creates the season calendar. Seasons are read from filePath (a JSON array of
+// seasons) if it exists, otherwise every calendar quarter is a season.
+func NewSeasons(store storage.Store, filePath string) *Seasons {
+	s := &Seasons{
+		archives: make(map[string]*SeasonArchive),
+		store:    store,
+	}
+	s.loadDefinitions(filePath)
+	s.loadArchives()
+	return s
+}
+
+// List returns every season from the one containing earliest up to the current one, plus any
+// upcoming seasons from the seasons file, oldest first
+func (s *Seasons) List(now, earliest time.Time) []types.SeasonInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var infos []types.SeasonInfo
+	for _, season := range s.calendar(now, earliest) {
+		_, archived := s.archives[season.ID]
+		infos = append(infos, types.SeasonInfo{
+			Season:   season,
+			Active:   season.Contains(now),
+			Archived: archived,
+		})
+	}
+	return infos
+}
+
+// Find returns a season by ID, or the current season when the ID is empty
+func (s *Seasons) Find(id string, now, earliest time.Time) (types.Season, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, season := range s.calendar(now, earliest) {
+		if (id == "" && season.Contains(now)) || (id != "" && season.ID == id) {
+			return season, nil
+		}
+	}
+	if id == "" {
+		return types.Season{}, fmt.Errorf("no season is running")
+	}
+	return types.Season{}, fmt.Errorf("season not found: %s", id)
+}
+
+// Archive returns the final standings of an ended season, or nil if it hasn't been archived
+func (s *Seasons) Archive(id string) *SeasonArchive {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.archives[id]
+}
+
+// SaveArchive stores the final standings of a season that has ended
+func (s *Seasons) SaveArchive(season types.Season, entries []LeaderboardEntry, now time.Time) {
+	archive := &SeasonArchive{
+		Season:     season,
+		ArchivedAt: now.Unix(),
+		Entries:    entries,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := storage.PutJSON(s.store, storage.BucketSeasons, season.ID, archive); err != nil {
+		log.Printf("Error archiving season %s: %v", season.ID, err)
+		return
+	}
+	s.archives[season.ID] = archive
+}
+
+// calendar returns the seasons to list (must hold lock)
+func (s *Seasons) calendar(now, earliest time.Time) []types.Season {
+	if s.defined != nil {
+		return s.defined
+	}
+
+	var seasons []types.Season
+	for season := quarterSeason(earliest); !season.Start.After(now); season = quarterSeason(season.End) {
+		seasons = append(seasons, season)
+	}
+	return seasons
+}
+
+// quarterSeason returns the calendar quarter (in UTC) containing t as a season
+func quarterSeason(t time.Time) types.Season {
+	t = t.UTC()
+	quarter := (int(t.Month()) - 1) / 3
+	start := time.Date(t.Year(), time.Month(quarter*3+1), 1, 0, 0, 0, 0, time.UTC)
+	return types.Season{
+		ID:    fmt.Sprintf("%d-q%d", t.Year(), quarter+1),
+		Name:  fmt.Sprintf("%d Q%d", t.Year(), quarter+1),
+		Start: start,
+		End:   start.AddDate(0, 3, 0),
+	}
+}
+
+// loadDefinitions reads the seasons file, if there is one
+func (s *Seasons) loadDefinitions(filePath string) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.Printf("Seasons file not found, using calendar quarters")
+			return
+		}
+		log.Printf("Error reading seasons file: %v", err)
+		return
+	}
+
+	var seasons []types.Season
+	if err := json.Unmarshal(data, &seasons); err != nil {
+		log.Printf("Error parsing seasons file: %v", err)
+		return
+	}
+
+	valid := make([]types.Season, 0, len(seasons))
+	for _, season := range seasons {
+		if season.ID == "" || !season.End.After(season.Start) {
+			log.Printf("Skipping season %q: needs an ID and an end after its start", season.ID)
+			continue
+		}
+		valid = append(valid, season)
+	}
+	sort.Slice(valid, func(i, j int) bool {
+		return valid[i].Start.Before(valid[j].Start)
+	})
+	s.defined = valid
+	log.Printf("Loaded %d seasons", len(valid))
+}
+
+// loadArchives reads the archived season standings from the store
+func (s *Seasons) loadArchives() {
+	err := s.store.ForEach(storage.BucketSeasons, func(key string, value []byte) error {
+		var archive SeasonArchive
+		if err := json.Unmarshal(value, &archive); err != nil {
+			log.Printf("Error parsing season archive %s: %v", key, err)
+			return nil
+		}
+		s.archives[key] = &archive
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error reading season archives: %v", err)
+	}
+}
+
+// findSeason looks up a season in the manager's calendar (empty ID = the current season)
+func (m *Manager) findSeason(id string, now time.Time) (types.Season, error) {
+	return m.seasons.Find(id, now, m.matches.Earliest(now))
+}
+
+// ListSeasons returns the season calendar
+func (m *Manager) ListSeasons(now time.Time) []types.SeasonInfo {
+	return m.seasons.List(now, m.matches.Earliest(now))
+}
+
+// RunSeasonArchiver periodically archives the final standings of seasons that have ended.
+// Runs until the process exits.
+func (m *Manager) RunSeasonArchiver() {
+	ticker := time.NewTicker(types.SeasonArchiveInterval)
+	defer ticker.Stop()
+
+	for {
+		m.archiveEndedSeasons(time.Now())
+		<-ticker.C
+	}
+}
+
+// archiveEndedSeasons stores the standings of every ended season that hasn't been archived yet
+func (m *Manager) archiveEndedSeasons(now time.Time) {
+	for _, info := range m.ListSeasons(now) {
+		if info.Archived || info.End.After(now) {
+			continue
+		}
+		entries := m.matches.Standings(info.Start, info.End, "", m.leaderboard.GetPlayerStats)
+		m.seasons.SaveArchive(info.Season, entries, now)
+		log.Printf("Archived season %s with %d players", info.Name, len(entries))
+	}
+}
@@ -2,7 +2,6 @@ package game
 
 import (
 	"math"
-	"time"
 
 	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
 )
@@ -42,13 +41,13 @@ func NewSpawnQueue() *SpawnQueue {
 }
 
 // Add adds a pending spawn to the queue
-func (q *SpawnQueue) Add(unitType string, ownerID int, spawnPos, targetPos types.Vector3, zoneID string) {
+func (q *SpawnQueue) Add(unitType string, ownerID int, spawnPos, targetPos types.Vector3, zoneID string, queuedAt int64) {
 	q.Queue = append(q.Queue, &PendingSpawn{
 		UnitType:  unitType,
 		OwnerID:   ownerID,
 		SpawnPos:  spawnPos,
 		TargetPos: targetPos,
-		QueuedAt:  time.Now().UnixMilli(),
+		QueuedAt:  queuedAt,
 		ZoneID:    zoneID,
 	})
 }
@@ -80,7 +79,7 @@ func (q *SpawnQueue) GetPendingByType(playerID int) map[string]int {
 func (q *SpawnQueue) ProcessQueue(state *State) []Unit {
 	spawnedUnits := make([]Unit, 0)
 	remainingQueue := make([]*PendingSpawn, 0)
-	now := time.Now().UnixMilli()
+	now := state.Now()
 
 	for _, pending := range q.Queue {
 		// Check spawn delay for this unit type
@@ -280,9 +279,8 @@ func (q *SpawnQueue) isSpawnPositionClear(pending *PendingSpawn, units []Unit) b
 }
 
 // ToTypes converts pending spawns to types for JSON serialization
-func (q *SpawnQueue) ToTypes() []types.PendingSpawn {
+func (q *SpawnQueue) ToTypes(now int64) []types.PendingSpawn {
 	result := make([]types.PendingSpawn, len(q.Queue))
-	now := time.Now().UnixMilli()
 	for i, spawn := range q.Queue {
 		result[i] = types.PendingSpawn{
 			UnitType:  spawn.UnitType,
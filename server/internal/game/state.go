@@ -1,6 +1,9 @@
 package game
 
 import (
+	"fmt"
+	"math"
+	"math/rand"
 	"time"
 
 	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
@@ -9,6 +12,7 @@ import (
 // State represents the game state
 type State struct {
 	Timestamp      int64
+	Tick           int64 // Number of simulation ticks run so far
 	Players        [2]*Player
 	Units          []Unit
 	Obstacles      []*Obstacle
@@ -16,43 +20,97 @@ type State struct {
 	BuyZones       []*BuyZone
 	Turrets        []*Turret
 	Barracks       []*Barracks
+	Bases          [2]*Base
 	HealthPacks    []*HealthPack
 	SpawnQueue     *SpawnQueue
 	GameStatus     string // "waiting", "playing", "finished"
 	Winner         *int
-	MatchStartTime int64 // Unix timestamp when match started
+	MatchStartTime int64 // Unix timestamp when match started (epoch of the simulation clock)
 	MapDefinition  *types.MapDefinition
+	Rules          types.MatchRules // Time limit, tiebreak and sudden-death settings
+	SuddenDeath    bool             // True once regular time has run out and sudden death has begun
+
+	// Game mode and its objective
+	Mode            GameMode
+	Hills           []*Hill    // King of the Hill
+	Flags           [2]*Flag   // Capture the Flag (nil in other modes)
+	ObjectiveScores [2]float64 // Hill seconds or flag captures per player
+
+	// Seed and Rand make the simulation reproducible from its seed and input log.
+	// All randomness inside the room must come from Rand.
+	Seed         int64
+	Rand         *rand.Rand
+	nextEntityID int
+
+	// Events emitted since the room last handed them to its observers
+	events []emittedEvent
 }
 
-// NewStateWithMap creates a new game state using a map definition
-func NewStateWithMap(mapDef *types.MapDefinition, player1ClientID, player1DisplayName string, player1IsGuest bool, player2ClientID, player2DisplayName string, player2IsGuest bool) *State {
-	player1 := NewPlayerWithMap(0, player1ClientID, player1DisplayName, player1IsGuest, mapDef)
-	player2 := NewPlayerWithMap(1, player2ClientID, player2DisplayName, player2IsGuest, mapDef)
+// emittedEvent is a game event together with where it happened, so it can be hidden by fog of war
+type emittedEvent struct {
+	event    types.GameEvent
+	position *types.Vector3 // nil for events that aren't hidden by fog of war
+}
+
+// NewStateWithMap creates a new 1v1 game state using a map definition
+func NewStateWithMap(mapDef *types.MapDefinition, seed int64, player1ClientID, player1UserID, player1DisplayName string, player1IsGuest bool, player2ClientID, player2UserID, player2DisplayName string, player2IsGuest bool) *State {
+	return NewTeamStateWithMap(mapDef, seed, [2][]SeatConfig{
+		{{ClientID: player1ClientID, UserID: player1UserID, DisplayName: player1DisplayName, IsGuest: player1IsGuest}},
+		{{ClientID: player2ClientID, UserID: player2UserID, DisplayName: player2DisplayName, IsGuest: player2IsGuest}},
+	})
+}
 
-	// Create player units at their bases
-	playerUnit1 := NewPlayerUnit(0, player1.BasePosition)
-	playerUnit2 := NewPlayerUnit(1, player2.BasePosition)
+// NewTeamStateWithMap creates a new game state for two teams of one or more members each
+func NewTeamStateWithMap(mapDef *types.MapDefinition, seed int64, teams [2][]SeatConfig) *State {
+	var players [2]*Player
+	for team, seats := range teams {
+		members := make([]*Member, len(seats))
+		for i, config := range seats {
+			members[i] = NewMember(SeatFor(team, i), config)
+		}
+		players[team] = NewPlayerWithMap(team, members, mapDef)
+	}
+
+	// Both teams picking the same colour would make them impossible to tell apart
+	if players[0].Color == players[1].Color {
+		for team, player := range players {
+			player.Color = mapDef.Players[team].Color
+		}
+	}
 
 	now := time.Now().UnixMilli()
-	return &State{
-		Timestamp: now,
-		Players: [2]*Player{
-			player1,
-			player2,
-		},
-		Units:          []Unit{playerUnit1, playerUnit2},
+	state := &State{
+		Timestamp:      now,
+		Players:        players,
+		Units:          make([]Unit, 0),
 		Obstacles:      GetObstaclesFromMap(mapDef),
 		Projectiles:    make([]*Projectile, 0),
 		BuyZones:       GetBuyZonesFromMap(mapDef),
 		Turrets:        GetTurretsFromMap(mapDef),
 		Barracks:       GetBarracksFromMap(mapDef),
+		Bases:          [2]*Base{NewBaseFromMap(0, mapDef), NewBaseFromMap(1, mapDef)},
 		HealthPacks:    make([]*HealthPack, 0),
 		SpawnQueue:     NewSpawnQueue(),
 		GameStatus:     "playing",
 		Winner:         nil,
 		MatchStartTime: now,
 		MapDefinition:  mapDef,
+		Rules:          mapDef.MatchRules(),
+		Mode:           &SiegeMode{},
+		Seed:           seed,
+		Rand:           rand.New(rand.NewSource(seed)),
+	}
+
+	// Create player units at their team's spawn slots, in seat order
+	for i := 0; i < len(teams[0]) || i < len(teams[1]); i++ {
+		for team, player := range players {
+			if i < len(player.Members) {
+				spawnPos := spawnSlotPosition(mapDef.Players[team], i)
+				state.AddUnit(NewPlayerUnit(team, player.Members[i].Seat, spawnPos))
+			}
+		}
 	}
+	return state
 }
 
 // ToType converts State to types.GameState for JSON serialization
@@ -79,7 +137,7 @@ func (s *State) ToType() types.GameState {
 
 	turretsData := make([]types.Turret, len(s.Turrets))
 	for i, turret := range s.Turrets {
-		turretsData[i] = turret.ToType()
+		turretsData[i] = turret.ToType(s.Now())
 	}
 
 	barracksData := make([]types.Barracks, len(s.Barracks))
@@ -94,11 +152,23 @@ func (s *State) ToType() types.GameState {
 
 	var pendingSpawnsData []types.PendingSpawn
 	if s.SpawnQueue != nil {
-		pendingSpawnsData = s.SpawnQueue.ToTypes()
+		pendingSpawnsData = s.SpawnQueue.ToTypes(s.Now())
 	} else {
 		pendingSpawnsData = make([]types.PendingSpawn, 0)
 	}
 
+	var hillsData []types.Hill
+	for _, hill := range s.Hills {
+		hillsData = append(hillsData, hill.ToType())
+	}
+
+	var flagsData []types.Flag
+	for _, flag := range s.Flags {
+		if flag != nil {
+			flagsData = append(flagsData, flag.ToType())
+		}
+	}
+
 	return types.GameState{
 		Timestamp:     s.Timestamp,
 		Players:       [2]types.Player{s.Players[0].ToType(), s.Players[1].ToType()},
@@ -108,15 +178,52 @@ func (s *State) ToType() types.GameState {
 		BuyZones:      buyZonesData,
 		Turrets:       turretsData,
 		Barracks:      barracksData,
+		Bases:         []types.Base{s.Bases[0].ToType(), s.Bases[1].ToType()},
 		HealthPacks:   healthPacksData,
 		PendingSpawns: pendingSpawnsData,
 		GameStatus:    s.GameStatus,
 		Winner:        s.Winner,
+		TimeRemaining: s.timeRemainingPtr(),
+		SuddenDeath:   s.SuddenDeath,
+
+		GameMode:        s.Mode.Name(),
+		Hills:           hillsData,
+		Flags:           flagsData,
+		ObjectiveScores: s.ObjectiveScores,
+		ObjectiveTarget: s.Mode.ScoreToWin(),
 	}
 }
 
+// GetTimeRemaining returns the seconds left in the current phase (regular time or sudden death)
+// Returns false if the match has no time limit
+func (s *State) GetTimeRemaining() (float64, bool) {
+	if s.Rules.TimeLimit <= 0 {
+		return 0, false
+	}
+
+	phaseEnd := s.Rules.TimeLimit
+	if s.SuddenDeath {
+		phaseEnd += s.Rules.SuddenDeathDuration
+	}
+
+	elapsed := float64(s.Now()-s.MatchStartTime) / 1000.0
+	return math.Max(0, phaseEnd-elapsed), true
+}
+
+// timeRemainingPtr returns the time remaining for serialization (nil if there's no time limit)
+func (s *State) timeRemainingPtr() *float64 {
+	remaining, ok := s.GetTimeRemaining()
+	if !ok {
+		return nil
+	}
+	return &remaining
+}
+
 // AddUnit adds a unit to the game state
+// The unit is given an ID from the room's sequence so that matches are reproducible
 func (s *State) AddUnit(unit Unit) {
+	s.nextEntityID++
+	unit.SetID(fmt.Sprintf("%s_%d", unit.GetType(), s.nextEntityID))
 	s.Units = append(s.Units, unit)
 }
 
@@ -138,14 +245,56 @@ func (s *State) GetPlayer(id int) *Player {
 	return nil
 }
 
+// Now returns the simulation time in Unix milliseconds.
+// The clock starts at MatchStartTime and advances by one TickDuration per tick,
+// so the simulation never depends on wall-clock time.
+func (s *State) Now() int64 {
+	return s.MatchStartTime + s.Tick*types.TickDuration.Milliseconds()
+}
+
+// emit records a game event at the current simulation time
+func (s *State) emit(event types.GameEvent) {
+	s.emitAt(event, nil)
+}
+
+// emitAt records an event that happened at a position, which teams only see if they can see there
+func (s *State) emitAt(event types.GameEvent, position *types.Vector3) {
+	event.Time = s.Now()
+	s.events = append(s.events, emittedEvent{event: event, position: position})
+}
+
+// takeEvents returns the events emitted since the last call and clears them
+func (s *State) takeEvents() []emittedEvent {
+	events := s.events
+	s.events = nil
+	return events
+}
+
+// recordClaim counts a structure claimed by a team and emits the event for it.
+// seat is the seat whose player unit made the claim, or -1 when a unit of claimerType claimed it.
+func (s *State) recordClaim(playerID int, seat int, claimerType string, structureType string, structureID string) {
+	if player := s.GetPlayer(playerID); player != nil {
+		player.AddClaimByType(structureType)
+	}
+	s.emit(types.GameEvent{
+		Type:           types.GameEventStructureClaimed,
+		PlayerID:       playerID,
+		Seat:           seat,
+		UnitType:       claimerType,
+		TargetID:       structureID,
+		TargetType:     structureType,
+		TargetPlayerID: -1,
+	})
+}
+
 // UpdateTimestamp updates the state timestamp
 func (s *State) UpdateTimestamp() {
-	s.Timestamp = time.Now().UnixMilli()
+	s.Timestamp = s.Now()
 }
 
 // GetMatchDuration returns the match duration in seconds
 func (s *State) GetMatchDuration() int {
-	return int((time.Now().UnixMilli() - s.MatchStartTime) / 1000)
+	return int((s.Now() - s.MatchStartTime) / 1000)
 }
 
 // AddProjectile adds a projectile to the game state
@@ -207,16 +356,122 @@ func (s *State) GetElevationAt(x, z float64) float64 {
 	return maxElevation
 }
 
-// GetPlayerUnit returns the player unit for the given player ID
-func (s *State) GetPlayerUnit(playerID int) *PlayerUnit {
+// GetSeatUnit returns the player unit controlled by the given seat
+func (s *State) GetSeatUnit(seat int) *PlayerUnit {
 	for _, unit := range s.Units {
-		if pu, ok := unit.(*PlayerUnit); ok && pu.OwnerID == playerID {
+		if pu, ok := unit.(*PlayerUnit); ok && pu.Seat == seat {
 			return pu
 		}
 	}
 	return nil
 }
 
+// GetPlayerUnits returns every player unit on the given team
+func (s *State) GetPlayerUnits(playerID int) []*PlayerUnit {
+	var playerUnits []*PlayerUnit
+	for _, unit := range s.Units {
+		if pu, ok := unit.(*PlayerUnit); ok && pu.OwnerID == playerID {
+			playerUnits = append(playerUnits, pu)
+		}
+	}
+	return playerUnits
+}
+
+// GetMember returns the team member in the given seat
+func (s *State) GetMember(seat int) *Member {
+	player := s.GetPlayer(TeamForSeat(seat))
+	if player == nil {
+		return nil
+	}
+	return player.GetMember(seat)
+}
+
+// WalletFor returns the money a seat spends from: its own when a team's economy is split,
+// otherwise the team's shared pool
+func (s *State) WalletFor(seat int) Wallet {
+	player := s.GetPlayer(TeamForSeat(seat))
+	if player == nil {
+		return nil
+	}
+	if player.IsTeam() && s.Rules.Economy == types.EconomySplit {
+		if member := player.GetMember(seat); member != nil {
+			return member
+		}
+	}
+	return player
+}
+
+// Spend pays for a purchase or claim from a seat's wallet and counts it in the team's stats
+func (s *State) Spend(seat int, amount int) {
+	wallet := s.WalletFor(seat)
+	if wallet == nil {
+		return
+	}
+	wallet.Spend(amount)
+	s.GetPlayer(TeamForSeat(seat)).MoneySpent += amount
+}
+
+// RecordPurchase counts units a seat bought, for both the member and the team's stats,
+// and emits the event for the purchase
+func (s *State) RecordPurchase(seat int, unitType string, count int) {
+	if player := s.GetPlayer(TeamForSeat(seat)); player != nil {
+		player.RecordPurchase(unitType, count)
+	}
+	if member := s.GetMember(seat); member != nil {
+		member.RecordPurchase(unitType, count)
+	}
+	s.emit(types.GameEvent{
+		Type:           types.GameEventUnitPurchased,
+		PlayerID:       TeamForSeat(seat),
+		Seat:           seat,
+		UnitType:       unitType,
+		Count:          count,
+		TargetPlayerID: -1,
+	})
+}
+
+// distributeTeamMoney shares out the money a split-economy team has earned together
+// (passive income, kill and claim rewards) evenly between its members
+// Called on each income tick, so rewards wait in the team's money until then
+func (s *State) distributeTeamMoney() {
+	if s.Rules.Economy != types.EconomySplit {
+		return
+	}
+	for _, player := range s.Players {
+		if !player.IsTeam() {
+			continue
+		}
+		share := player.Money / len(player.Members)
+		if share <= 0 {
+			continue
+		}
+		for _, member := range player.Members {
+			member.AddMoney(share)
+			player.Spend(share)
+		}
+	}
+}
+
+// spawnSlotPosition returns where a team's nth member spawns, using the map's spawn slots
+// and spreading any extra members out along Z
+func spawnSlotPosition(config types.MapPlayerConfig, memberIndex int) types.Vector3 {
+	position := config.BasePosition
+	if memberIndex < len(config.SpawnSlots) {
+		offset := config.SpawnSlots[memberIndex]
+		position.X += offset.X
+		position.Z += offset.Z
+		return position
+	}
+
+	// 0, +1, -1, +2, -2... spacings from the base
+	step := float64((memberIndex + 1) / 2)
+	if memberIndex%2 == 0 {
+		step = -step
+	}
+	position.Z += step * types.TeamSpawnSpacing
+	return position
+}
+
 // GetTurretByID returns a turret by ID
 func (s *State) GetTurretByID(id string) *Turret {
 	for _, turret := range s.Turrets {
@@ -280,6 +535,36 @@ func (s *State) RemoveHealthPack(id string) {
 	}
 }
 
+// SetRules replaces the match rules, resetting each team's money if the rules set a starting amount
+// Must be called before the game starts
+func (s *State) SetRules(rules types.MatchRules) {
+	s.Rules = rules
+	if rules.StartingMoney > 0 {
+		for _, player := range s.Players {
+			player.Money = rules.StartingMoney * len(player.Members)
+		}
+	}
+}
+
+// SetGameMode switches the state to a game mode and creates the mode's objects
+func (s *State) SetGameMode(mode GameMode) {
+	s.Mode = mode
+	s.Hills = nil
+	s.Flags = [2]*Flag{}
+	s.ObjectiveScores = [2]float64{}
+	mode.Setup(s)
+}
+
+// GetBaseByID returns a base by ID
+func (s *State) GetBaseByID(id string) *Base {
+	for _, base := range s.Bases {
+		if base.ID == id {
+			return base
+		}
+	}
+	return nil
+}
+
 // GetBarracksByID returns a barracks by ID
 func (s *State) GetBarracksByID(id string) *Barracks {
 	for _, barracks := range s.Barracks {
@@ -0,0 +1,353 @@
+package game
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
+)
+
+// entityList is one kind of entity in a snapshot, indexed by ID so deltas can compare each entity
+// with its previous version field by field
+type entityList[T comparable] struct {
+	ids      []string // In state order
+	entities map[string]T
+}
+
+// entityField is a top-level field of an entity type, as it's named in JSON
+type entityField struct {
+	index     int
+	name      string
+	omitEmpty bool
+}
+
+// entityFieldCache holds the fields of each entity type: reflect.Type -> []entityField
+var entityFieldCache sync.Map
+
+// stateSnapshot is a game state sent to clients, kept so later updates can be encoded against it.
+// Each view of the match (see stateView) gets its own snapshot, all numbered by the tick's sequence.
+type stateSnapshot struct {
+	sequence    int64
+	view        stateView
+	state       *types.GameState
+	hiddenUnits map[string]bool // Units in the match the view can't see
+
+	units       entityList[types.Unit]
+	projectiles entityList[types.Projectile]
+	healthPacks entityList[types.HealthPack]
+	buyZones    entityList[types.BuyZone]
+	turrets     entityList[types.Turret]
+	barracks    entityList[types.Barracks]
+	bases       entityList[types.Base]
+	hills       entityList[types.Hill]
+}
+
+// snapshotAck is what a client receiving deltas has acknowledged
+type snapshotAck struct {
+	acked        int64 // Newest snapshot the client has applied
+	lastKeyframe int64 // Snapshot of the last keyframe sent to the client
+}
+
+// AcknowledgeSnapshot records the newest game_update snapshot a player or spectator has applied.
+// From then on their updates are deltas against the snapshots they acknowledge.
+func (r *GameRoom) AcknowledgeSnapshot(clientID string, snapshot int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if snapshot <= 0 || snapshot > r.snapshotSequence {
+		return
+	}
+
+	ack, exists := r.snapshotAcks[clientID]
+	if !exists {
+		// Everything sent before the first acknowledgement was a keyframe
+		r.snapshotAcks[clientID] = &snapshotAck{acked: snapshot, lastKeyframe: snapshot}
+		return
+	}
+	ack.acked = max(ack.acked, snapshot)
+}
+
+// forgetSnapshotAcks stops sending deltas to a client that left, so they start from a keyframe
+// if they come back
+// Note: Caller must hold the lock
+func (r *GameRoom) forgetSnapshotAcks(clientID string) {
+	delete(r.snapshotAcks, clientID)
+}
+
+// takeSnapshot keeps the current state, as a view sees it, for encoding later deltas against.
+// Snapshots are numbered with the current snapshotSequence, which is advanced once per broadcast.
+// Note: Caller must hold the lock
+func (r *GameRoom) takeSnapshot(view stateView, sight *teamSight) *stateSnapshot {
+	state := r.visibleState(view, sight)
+	state.Obstacles = nil // Static, sent once in game_start
+	roundState(&state)
+
+	hiddenUnits := make(map[string]bool)
+	if view != viewEverything {
+		for _, unit := range r.State.Units {
+			if !sight.sees(view, unit.GetID()) {
+				hiddenUnits[unit.GetID()] = true
+			}
+		}
+	}
+
+	// Keyframes say which of the units in the previous snapshot went out of sight, as deltas do
+	history := r.snapshots[view]
+	if len(history) > 0 {
+		for _, id := range history[len(history)-1].units.ids {
+			if hiddenUnits[id] {
+				state.HiddenUnits = append(state.HiddenUnits, id)
+			}
+		}
+	}
+
+	snapshot := stateSnapshot{
+		sequence:    r.snapshotSequence,
+		view:        view,
+		state:       &state,
+		hiddenUnits: hiddenUnits,
+		units:       listEntities(state.Units, func(u types.Unit) string { return u.ID }),
+		projectiles: listEntities(state.Projectiles, func(p types.Projectile) string { return p.ID }),
+		healthPacks: listEntities(state.HealthPacks, func(h types.HealthPack) string { return h.ID }),
+		buyZones:    listEntities(state.BuyZones, func(z types.BuyZone) string { return z.ID }),
+		turrets:     listEntities(state.Turrets, func(t types.Turret) string { return t.ID }),
+		barracks:    listEntities(state.Barracks, func(b types.Barracks) string { return b.ID }),
+		bases:       listEntities(state.Bases, func(b types.Base) string { return b.ID }),
+		hills:       listEntities(state.Hills, func(h types.Hill) string { return h.ID }),
+	}
+	if len(history) >= types.StateSnapshotHistory {
+		history = history[1:]
+	}
+	history = append(history, snapshot)
+	r.snapshots[view] = history
+	return &history[len(history)-1]
+}
+
+// findSnapshot returns a view's kept snapshot by sequence number, or nil if it's too old
+// Note: Caller must hold the lock
+func (r *GameRoom) findSnapshot(view stateView, sequence int64) *stateSnapshot {
+	history := r.snapshots[view]
+	for i := range history {
+		if history[i].sequence == sequence {
+			return &history[i]
+		}
+	}
+	return nil
+}
+
+// stateUpdateKey identifies a game_update that can be shared by several clients
+type stateUpdateKey struct {
+	view         stateView
+	baseSequence int64 // 0 for keyframes
+}
+
+// stateUpdateFor builds a client's game_update: a delta against the newest snapshot they
+// acknowledged, or a keyframe if they don't acknowledge snapshots, their acknowledgement is too
+// old, or a keyframe is due. Updates are shared by every client with the same view and base
+// snapshot, so each is only built and encoded once.
+// Note: Caller must hold the lock
+func (r *GameRoom) stateUpdateFor(clientID string, current *stateSnapshot, updates map[stateUpdateKey]*types.SharedPayload) *types.SharedPayload {
+	var base *stateSnapshot
+	if ack := r.snapshotAcks[clientID]; ack != nil {
+		base = r.findSnapshot(current.view, ack.acked)
+		if base == nil || current.sequence-ack.lastKeyframe >= types.StateKeyframeInterval {
+			base = nil
+			ack.lastKeyframe = current.sequence
+		}
+	}
+
+	key := stateUpdateKey{view: current.view}
+	if base != nil {
+		key.baseSequence = base.sequence
+	}
+	if update, exists := updates[key]; exists {
+		return update
+	}
+
+	payload := types.GameUpdatePayload{
+		Timestamp: current.state.Timestamp,
+		Snapshot:  current.sequence,
+	}
+	if base == nil {
+		payload.State = current.state
+	} else {
+		payload.Delta = diffSnapshots(base, current)
+		payload.BaseSnapshot = base.sequence
+	}
+	update := types.NewSharedPayload(payload)
+	updates[key] = update
+	return update
+}
+
+// diffSnapshots returns how current differs from base
+func diffSnapshots(base, current *stateSnapshot) *types.GameStateDelta {
+	delta := &types.GameStateDelta{
+		Players:         current.state.Players,
+		PendingSpawns:   current.state.PendingSpawns,
+		GameStatus:      current.state.GameStatus,
+		Winner:          current.state.Winner,
+		TimeRemaining:   current.state.TimeRemaining,
+		SuddenDeath:     current.state.SuddenDeath,
+		ObjectiveScores: current.state.ObjectiveScores,
+	}
+
+	delta.Units, delta.RemovedUnits = diffEntities(base.units, current.units)
+	for _, id := range delta.RemovedUnits {
+		if current.hiddenUnits[id] {
+			delta.HiddenUnits = append(delta.HiddenUnits, id)
+		}
+	}
+	delta.Projectiles, delta.RemovedProjectiles = diffEntities(base.projectiles, current.projectiles)
+	delta.HealthPacks, delta.RemovedHealthPacks = diffEntities(base.healthPacks, current.healthPacks)
+
+	// Structures and hills are fixed by the map, so they're only ever changed
+	delta.BuyZones, _ = diffEntities(base.buyZones, current.buyZones)
+	delta.Turrets, _ = diffEntities(base.turrets, current.turrets)
+	delta.Barracks, _ = diffEntities(base.barracks, current.barracks)
+	delta.Bases, _ = diffEntities(base.bases, current.bases)
+	delta.Hills, _ = diffEntities(base.hills, current.hills)
+
+	if !slices.Equal(base.state.Flags, current.state.Flags) {
+		delta.Flags = current.state.Flags
+	}
+
+	return delta
+}
+
+// listEntities indexes entities by ID
+func listEntities[T comparable](entities []T, id func(T) string) entityList[T] {
+	list := entityList[T]{
+		ids:      make([]string, 0, len(entities)),
+		entities: make(map[string]T, len(entities)),
+	}
+	for _, entity := range entities {
+		list.ids = append(list.ids, id(entity))
+		list.entities[id(entity)] = entity
+	}
+	return list
+}
+
+// diffEntities returns patches for the entities that are new in current or differ from base, and
+// the IDs of the entities in base that are no longer in current. Only the fields that changed are
+// encoded; fields left out of the JSON (omitempty) are patched to null.
+func diffEntities[T comparable](base, current entityList[T]) ([]types.EntityPatch, []string) {
+	var patches []types.EntityPatch
+	for _, id := range current.ids {
+		entity := current.entities[id]
+		previous, exists := base.entities[id]
+		if exists && previous == entity {
+			continue
+		}
+
+		value, previousValue := reflect.ValueOf(entity), reflect.ValueOf(previous)
+		patch := types.EntityPatch{}
+		for _, field := range fieldsOf(value.Type()) {
+			fieldValue := value.Field(field.index)
+			// Changed entities always carry their ID so clients know what to patch
+			if exists && field.name != "id" && fieldValue.Equal(previousValue.Field(field.index)) {
+				continue
+			}
+			if field.omitEmpty && fieldValue.IsZero() {
+				if exists {
+					patch[field.name] = json.RawMessage("null")
+				}
+				continue
+			}
+			data, err := json.Marshal(fieldValue.Interface())
+			if err != nil {
+				log.Printf("Error encoding %s of entity %s: %v", field.name, id, err)
+				continue
+			}
+			patch[field.name] = data
+		}
+		patches = append(patches, patch)
+	}
+
+	var removed []string
+	for _, id := range base.ids {
+		if _, kept := current.entities[id]; !kept {
+			removed = append(removed, id)
+		}
+	}
+	return patches, removed
+}
+
+// fieldsOf returns the JSON fields of an entity type, working them out the first time it's used
+func fieldsOf(entityType reflect.Type) []entityField {
+	if cached, exists := entityFieldCache.Load(entityType); exists {
+		return cached.([]entityField)
+	}
+
+	var fields []entityField
+	for i := 0; i < entityType.NumField(); i++ {
+		tag := entityType.Field(i).Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = entityType.Field(i).Name
+		}
+		fields = append(fields, entityField{
+			index:     i,
+			name:      name,
+			omitEmpty: strings.Contains(options, "omitempty"),
+		})
+	}
+	entityFieldCache.Store(entityType, fields)
+	return fields
+}
+
+// roundState rounds the positions and timers that change every tick to types.StatePrecision
+func roundState(state *types.GameState) {
+	for i := range state.Units {
+		unit := &state.Units[i]
+		unit.Position = roundVector(unit.Position)
+		unit.TargetPosition = roundVector(unit.TargetPosition)
+		unit.RespawnTime = roundFloat(unit.RespawnTime)
+	}
+	for i := range state.Projectiles {
+		projectile := &state.Projectiles[i]
+		projectile.Position = roundVector(projectile.Position)
+		projectile.StartPos = roundVector(projectile.StartPos)
+		projectile.EndPos = roundVector(projectile.EndPos)
+	}
+	for i := range state.Turrets {
+		state.Turrets[i].RespawnTime = roundFloat(state.Turrets[i].RespawnTime)
+		state.Turrets[i].TrackingProgress = roundFloat(state.Turrets[i].TrackingProgress)
+	}
+	for i := range state.Barracks {
+		state.Barracks[i].RespawnTime = roundFloat(state.Barracks[i].RespawnTime)
+	}
+	for i := range state.PendingSpawns {
+		state.PendingSpawns[i].SpawnPos = roundVector(state.PendingSpawns[i].SpawnPos)
+		state.PendingSpawns[i].WaitTime = roundFloat(state.PendingSpawns[i].WaitTime)
+	}
+	for i := range state.Flags {
+		state.Flags[i].Position = roundVector(state.Flags[i].Position)
+	}
+	if state.TimeRemaining != nil {
+		remaining := roundFloat(*state.TimeRemaining)
+		state.TimeRemaining = &remaining
+	}
+	for i := range state.ObjectiveScores {
+		state.ObjectiveScores[i] = roundFloat(state.ObjectiveScores[i])
+	}
+}
+
+// roundVector rounds each axis of a position to types.StatePrecision
+func roundVector(v types.Vector3) types.Vector3 {
+	return types.Vector3{X: roundFloat(v.X), Y: roundFloat(v.Y), Z: roundFloat(v.Z)}
+}
+
+// roundFloat rounds a value to types.StatePrecision
+func roundFloat(value float64) float64 {
+	// Dividing by the whole-number scale keeps values like 0.3 short when encoded
+	const scale = 1 / types.StatePrecision
+	return math.Round(value*scale) / scale
+}
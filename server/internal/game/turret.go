@@ -1,8 +1,6 @@
 package game
 
 import (
-	"time"
-
 	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
 )
 
@@ -46,12 +44,11 @@ func NewTurret(id string, position types.Vector3, defaultOwnerID int) *Turret {
 }
 
 // ToType converts Turret to types.Turret for JSON serialization
-func (t *Turret) ToType() types.Turret {
+func (t *Turret) ToType(now int64) types.Turret {
 	// Calculate tracking progress
 	isTracking := t.CurrentTargetID != ""
 	trackingProgress := 0.0
 	if isTracking && t.TargetAcquiredAt > 0 {
-		now := time.Now().UnixMilli()
 		elapsed := float64(now - t.TargetAcquiredAt)
 		trackingProgress = elapsed / float64(types.TurretTrackingTime)
 		if trackingProgress > 1.0 {
@@ -146,7 +143,7 @@ func (t *Turret) IsAlive() bool {
 }
 
 // CanAttack checks if the turret can attack (has owner and not on cooldown)
-func (t *Turret) CanAttack() bool {
+func (t *Turret) CanAttack(now int64) bool {
 	// Must have an owner to attack
 	if t.OwnerID == -1 {
 		return false
@@ -155,7 +152,6 @@ func (t *Turret) CanAttack() bool {
 		return false
 	}
 
-	now := time.Now().UnixMilli()
 	timeSinceLastAttack := now - t.LastAttackTime
 	attackCooldown := int64(1000.0 / t.AttackSpeed)
 
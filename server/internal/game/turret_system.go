@@ -1,8 +1,6 @@
 package game
 
 import (
-	"time"
-
 	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
 )
 
@@ -20,11 +18,13 @@ func NewTurretSystem(losSystem *LOSSystem) *TurretSystem {
 
 // Update processes turret updates including respawns and combat
 func (s *TurretSystem) Update(state *State, deltaTime float64) {
-	now := time.Now().UnixMilli()
+	now := state.Now()
 
 	for _, turret := range state.Turrets {
-		// Update respawn timers
-		turret.Update(deltaTime)
+		// Update respawn timers (destroyed turrets stay down during sudden death)
+		if !state.SuddenDeath {
+			turret.Update(deltaTime)
+		}
 
 		// Check for auto-claiming by tanks/helicopters passing by unclaimed turrets
 		if !turret.IsDestroyed && turret.OwnerID == -1 {
@@ -60,11 +60,12 @@ func (s *TurretSystem) checkAutoClaimByUnits(turret *Turret, state *State) {
 			// Claim the turret for this unit's team
 			ownerID := unit.GetOwnerID()
 			turret.Claim(ownerID)
+			state.recordClaim(ownerID, -1, unitType, "turret", turret.ID)
 
 			// Reward player for claiming turret
 			player := state.GetPlayer(ownerID)
 			if player != nil {
-				player.Money += types.TurretClaimReward
+				player.AddMoney(types.TurretClaimReward)
 			}
 			return // Only one unit can claim per tick
 		}
@@ -180,7 +181,7 @@ func (s *TurretSystem) handleTurretTargeting(turret *Turret, targetID string, st
 	}
 
 	// Check attack cooldown
-	if !turret.CanAttack() {
+	if !turret.CanAttack(now) {
 		return
 	}
 
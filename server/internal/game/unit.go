@@ -7,6 +7,7 @@ import (
 // Unit interface for all units
 type Unit interface {
 	GetID() string
+	SetID(id string)
 	GetType() string
 	GetOwnerID() int
 	GetPosition() types.Vector3
@@ -88,6 +89,10 @@ func (u *BaseUnit) GetID() string {
 	return u.ID
 }
 
+func (u *BaseUnit) SetID(id string) {
+	u.ID = id
+}
+
 func (u *BaseUnit) GetType() string {
 	return u.Type
 }
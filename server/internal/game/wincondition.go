@@ -15,25 +15,100 @@ func NewWinConditionSystem() *WinConditionSystem {
 // Check checks if any player has won
 // Returns (hasWinner, winnerID, reason)
 func (s *WinConditionSystem) Check(state *State) (bool, int, string) {
-	// Check if any tank has reached the enemy base
-	// Only tanks can capture bases (not players or helicopters)
-	for _, unit := range state.Units {
-		unitType := unit.GetType()
-		if unitType != "tank" && unitType != "super_tank" {
-			continue
+	// A match is won by destroying the enemy base
+	for _, base := range state.Bases {
+		if !base.IsAlive() {
+			return true, 1 - base.OwnerID, "Enemy base destroyed"
 		}
+	}
+
+	// Then the game mode's own objective
+	if hasWinner, winnerID, reason := state.Mode.Check(state); hasWinner {
+		return hasWinner, winnerID, reason
+	}
+
+	return s.checkTimeLimit(state)
+}
+
+// checkTimeLimit moves the match into sudden death or settles it with the tiebreak once time runs out
+// Returns (hasWinner, winnerID, reason) - a winnerID of -1 with hasWinner set is a draw
+func (s *WinConditionSystem) checkTimeLimit(state *State) (bool, int, string) {
+	remaining, limited := state.GetTimeRemaining()
+	if !limited || remaining > 0 {
+		return false, -1, ""
+	}
+
+	if state.Rules.SuddenDeath && !state.SuddenDeath && state.Rules.SuddenDeathDuration > 0 {
+		state.SuddenDeath = true
+		return false, -1, ""
+	}
+
+	return s.tiebreak(state)
+}
+
+// tiebreak decides a match that ran out of time, trying the configured tiebreak first
+// and then the others, so a match is only drawn if the players are level on all of them
+func (s *WinConditionSystem) tiebreak(state *State) (bool, int, string) {
+	order := []string{types.TiebreakPoints, types.TiebreakStructures, types.TiebreakKills}
+	if state.Rules.Tiebreak != "" {
+		order = append([]string{state.Rules.Tiebreak}, order...)
+	}
 
-		ownerID := unit.GetOwnerID()
-		enemyID := 1 - ownerID
-		enemyBase := state.Players[enemyID].BasePosition
+	// Modes with a score are decided on it before anything else
+	if state.Mode.ScoreToWin() > 0 {
+		if state.ObjectiveScores[0] > state.ObjectiveScores[1] {
+			return true, 0, "Time limit - highest objective score"
+		}
+		if state.ObjectiveScores[1] > state.ObjectiveScores[0] {
+			return true, 1, "Time limit - highest objective score"
+		}
+	}
 
-		// Check distance to enemy base
-		distance := calculateDistance(unit.GetPosition(), enemyBase)
+	for _, tiebreak := range order {
+		var score [2]int
+		var reason string
+		switch tiebreak {
+		case types.TiebreakPoints:
+			score = [2]int{state.Players[0].GetStats().TotalPoints, state.Players[1].GetStats().TotalPoints}
+			reason = "Time limit - most points"
+		case types.TiebreakStructures:
+			score = [2]int{countStructures(state, 0), countStructures(state, 1)}
+			reason = "Time limit - most structures held"
+		case types.TiebreakKills:
+			score = [2]int{state.Players[0].Kills, state.Players[1].Kills}
+			reason = "Time limit - most kills"
+		default:
+			continue
+		}
 
-		if distance < types.BaseRadius+5 {
-			return true, ownerID, "Tank captured enemy base"
+		if score[0] > score[1] {
+			return true, 0, reason
+		}
+		if score[1] > score[0] {
+			return true, 1, reason
 		}
 	}
 
-	return false, -1, ""
+	return true, -1, "Time limit - draw"
+}
+
+// countStructures counts the turrets, forward buy zones and barracks a player holds
+func countStructures(state *State, playerID int) int {
+	count := 0
+	for _, turret := range state.Turrets {
+		if turret.OwnerID == playerID && !turret.IsDestroyed {
+			count++
+		}
+	}
+	for _, zone := range state.BuyZones {
+		if zone.IsClaimable && zone.OwnerID == playerID {
+			count++
+		}
+	}
+	for _, barracks := range state.Barracks {
+		if barracks.OwnerID == playerID && !barracks.IsDestroyed {
+			count++
+		}
+	}
+	return count
 }
@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// BoltStore is a Store backed by a single BoltDB file
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// OpenBolt opens (or creates) the BoltDB file at path
+func OpenBolt(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %v", path, err)
+	}
+
+	// Create every bucket up front so reads never have to
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range []string{BucketMeta, BucketLeaderboard, BucketMatches, BucketSessions, BucketIdentities, BucketSeasons, BucketAchievements} {
+			if _, err := tx.CreateBucketIfNotExists([]byte(bucket)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating buckets in %s: %v", path, err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+// Get returns the value stored under a key, or ErrNotFound
+func (s *BoltStore) Get(bucket, key string) ([]byte, error) {
+	var value []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		value, err = (&boltTx{tx: tx}).Get(bucket, key)
+		return err
+	})
+	return value, err
+}
+
+// Put stores a value under a key, replacing any existing value
+func (s *BoltStore) Put(bucket, key string, value []byte) error {
+	return s.Update(func(tx Tx) error {
+		return tx.Put(bucket, key, value)
+	})
+}
+
+// Delete removes a key
+func (s *BoltStore) Delete(bucket, key string) error {
+	return s.Update(func(tx Tx) error {
+		return tx.Delete(bucket, key)
+	})
+}
+
+// ForEach calls fn for every key in a bucket, in key order
+func (s *BoltStore) ForEach(bucket string, fn func(key string, value []byte) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return fmt.Errorf("unknown bucket: %s", bucket)
+		}
+		return b.ForEach(func(k, v []byte) error {
+			return fn(string(k), v)
+		})
+	})
+}
+
+// Update runs fn in a single transaction
+func (s *BoltStore) Update(fn func(tx Tx) error) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return fn(&boltTx{tx: tx})
+	})
+}
+
+// Close releases the database file
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+// boltTx adapts a bolt transaction to Tx
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+func (t *boltTx) bucket(name string) (*bolt.Bucket, error) {
+	b := t.tx.Bucket([]byte(name))
+	if b == nil {
+		return nil, fmt.Errorf("unknown bucket: %s", name)
+	}
+	return b, nil
+}
+
+// Get returns a copy of the value (bolt's slices are only valid inside the transaction)
+func (t *boltTx) Get(bucket, key string) ([]byte, error) {
+	b, err := t.bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+	value := b.Get([]byte(key))
+	if value == nil {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), value...), nil
+}
+
+func (t *boltTx) Put(bucket, key string, value []byte) error {
+	b, err := t.bucket(bucket)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(key), value)
+}
+
+func (t *boltTx) Delete(bucket, key string) error {
+	b, err := t.bucket(bucket)
+	if err != nil {
+		return err
+	}
+	return b.Delete([]byte(key))
+}
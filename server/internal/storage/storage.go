@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Buckets group related records in the store
+const (
+	BucketMeta         = "meta"         // Store-wide values such as counters and migration markers
+	BucketLeaderboard  = "leaderboard"  // userID -> leaderboard entry
+	BucketMatches      = "matches"      // match ID -> match record
+	BucketSessions     = "sessions"     // auth or guest token -> session
+	BucketIdentities   = "identities"   // "github:<id>" or "bluesky:<did>" -> userID
+	BucketSeasons      = "seasons"      // season ID -> final standings, once the season has ended
+	BucketAchievements = "achievements" // userID -> unlocked achievements and chosen cosmetics
+)
+
+// ErrNotFound is returned when a key isn't in the store
+var ErrNotFound = errors.New("not found")
+
+// Store is a durable key-value store. Every write is committed to disk before it returns,
+// so a crash never leaves a half-written record behind.
+type Store interface {
+	// Get returns the value stored under a key, or ErrNotFound
+	Get(bucket, key string) ([]byte, error)
+
+	// Put stores a value under a key, replacing any existing value
+	Put(bucket, key string, value []byte) error
+
+	// Delete removes a key (deleting a missing key is not an error)
+	Delete(bucket, key string) error
+
+	// ForEach calls fn for every key in a bucket, in key order. value is only valid during the call.
+	ForEach(bucket string, fn func(key string, value []byte) error) error
+
+	// Update runs fn in a single transaction - either all of its writes are committed or none are
+	Update(fn func(tx Tx) error) error
+
+	// Close releases the store's file
+	Close() error
+}
+
+// Tx is a read-write transaction, see Store.Update
+type Tx interface {
+	Get(bucket, key string) ([]byte, error)
+	Put(bucket, key string, value []byte) error
+	Delete(bucket, key string) error
+}
+
+// GetJSON reads a JSON value from the store into v
+func GetJSON(s Store, bucket, key string, v interface{}) error {
+	data, err := s.Get(bucket, key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// PutJSON stores v as JSON
+func PutJSON(s Store, bucket, key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.Put(bucket, key, data)
+}
+
+// GetJSONTx reads a JSON value into v within a transaction
+func GetJSONTx(tx Tx, bucket, key string, v interface{}) error {
+	data, err := tx.Get(bucket, key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// PutJSONTx stores v as JSON within a transaction
+func PutJSONTx(tx Tx, bucket, key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return tx.Put(bucket, key, data)
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"log"
+	"sync"
+)
+
+// writerQueueSize is how many writes can be waiting before Queue blocks
+const writerQueueSize = 256
+
+// write is one queued transaction or task
+type write struct {
+	description string
+	fn          func() error
+}
+
+// Writer commits writes to a store on its own goroutine, one transaction at a time in the order
+// they were queued, so callers holding locks (such as a game room's tick) don't wait on the disk
+type Writer struct {
+	store  Store
+	writes chan write
+	done   chan struct{} // Closed once run has committed the last write
+	closed bool
+	mu     sync.RWMutex // Guards closed, so nothing is queued once the channel is closed
+}
+
+// NewWriter creates a writer for the store and starts its goroutine
+func NewWriter(store Store) *Writer {
+	w := &Writer{
+		store:  store,
+		writes: make(chan write, writerQueueSize),
+		done:   make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Queue commits fn in a transaction once the writes queued before it are done. fn runs on
+// another goroutine, so it must only use values the caller won't change (such as encoded JSON).
+// Errors are logged with the description. Writes queued after Close are dropped.
+func (w *Writer) Queue(description string, fn func(tx Tx) error) {
+	w.queue(write{description: description, fn: func() error {
+		return w.store.Update(fn)
+	}})
+}
+
+// QueueTask runs fn in order with the queued transactions, for writes that aren't to the store
+// (such as replay files) but still need saving before the program exits
+func (w *Writer) QueueTask(description string, fn func() error) {
+	w.queue(write{description: description, fn: fn})
+}
+
+// queue adds a write to the queue, unless the writer is closed
+func (w *Writer) queue(write write) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		log.Printf("Error saving %s: writer is closed", write.description)
+		return
+	}
+	w.writes <- write
+}
+
+// Close stops accepting writes and waits for the ones already queued to be committed, so they
+// aren't lost when the program exits. The store must stay open until Close returns.
+func (w *Writer) Close() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.writes)
+	}
+	w.mu.Unlock()
+
+	<-w.done
+}
+
+// run commits queued writes until the writer is closed
+func (w *Writer) run() {
+	defer close(w.done)
+	for write := range w.writes {
+		if err := write.fn(); err != nil {
+			log.Printf("Error saving %s: %v", write.description, err)
+		}
+	}
+}
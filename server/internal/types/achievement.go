@@ -0,0 +1,23 @@
+package types
+
+// Achievement is something a player can unlock by playing matches (ranked, AI or private lobbies)
+type Achievement struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Color       string `json:"color,omitempty"` // Player colour the achievement unlocks (empty = no cosmetic)
+}
+
+// UnlockedAchievement is an achievement a player has earned
+type UnlockedAchievement struct {
+	ID         string `json:"id"`
+	MatchID    string `json:"matchId"`    // Match it was earned in
+	UnlockedAt int64  `json:"unlockedAt"` // Unix timestamp
+}
+
+// UserAchievements is a player's unlocks and the cosmetics they've chosen
+type UserAchievements struct {
+	Unlocked []UnlockedAchievement `json:"unlocked"`
+	Colors   []string              `json:"colors"`          // Player colours the player has unlocked
+	Color    string                `json:"color,omitempty"` // Chosen player colour (empty = the map's colour)
+}
@@ -13,6 +13,121 @@ const (
 	TickRate     = 20 // Updates per second
 	TickDuration = time.Second / TickRate
 
+	// Overtime settings, for matches whose map or lobby sets a time limit
+	SuddenDeathDuration         = 120.0 // Seconds of sudden-death overtime
+	SuddenDeathIncomeMultiplier = 3.0   // Passive income multiplier during sudden death
+
+	// Tiebreaks for matches that reach the time limit
+	TiebreakPoints     = "points"     // Highest PlayerStats.TotalPoints
+	TiebreakStructures = "structures" // Most turrets, forward buy zones and barracks held
+	TiebreakKills      = "kills"      // Most enemy units destroyed
+
+	// Game modes
+	GameModeSiege          = "siege"            // Destroy the enemy base (default)
+	GameModeKingOfTheHill  = "king_of_the_hill" // Hold the hill zones to accrue score
+	GameModeCaptureTheFlag = "capture_the_flag" // Carry the enemy flag back to your base
+
+	// King of the Hill settings
+	KingOfTheHillScoreToWin = 120.0 // Seconds of uncontested hill control needed to win
+	HillRadius              = 12.0  // Default hill zone radius
+
+	// Capture the Flag settings
+	CaptureTheFlagCapturesToWin = 3    // Captures needed to win
+	FlagPickupRadius            = 3.0  // Distance at which a player picks up or returns a flag
+	FlagCaptureRadius           = 10.0 // Distance from your base at which a carried flag is captured
+	FlagReturnTime              = 20.0 // Seconds before a dropped flag returns to its stand
+
+	// Team settings
+	MaxTeamSize      = 2   // Largest team matchmaking will form (1 = 1v1, 2 = 2v2)
+	TeamSpawnSpacing = 6.0 // Distance between team members' default spawn points along Z
+
+	// Team economies
+	EconomyShared = "shared" // Each team spends from one pool (default)
+	EconomySplit  = "split"  // Each member has their own wallet and team rewards are divided
+
+	// Private lobby settings
+	LobbyCodeLength       = 6
+	LobbyCodeAlphabet     = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789" // No 0/O or 1/I lookalikes
+	LobbyMinStartingMoney = 100
+	LobbyMaxStartingMoney = 10000
+	LobbyMinTimeLimit     = 60.0   // Shortest time limit a host can set (0 = the map's limit is also allowed)
+	LobbyMaxTimeLimit     = 3600.0 // Longest time limit a host can set
+
+	// Skill rating settings (Elo)
+	DefaultRating      = 1500.0
+	RatingKFactor      = 24.0 // Most rating points that can change hands in a match once a player is established
+	ProvisionalKFactor = 40.0 // Bigger swings while a player has few rated games, so new players settle quickly
+	ProvisionalGames   = 10   // Rated games before a player counts as established
+	AIRatingEasy       = 1100.0
+	AIRatingMedium     = 1400.0
+	AIRatingHard       = 1700.0
+
+	// Match history settings
+	ProfileRecentMatches = 20 // Matches listed on a player's profile
+
+	// Leaderboard windows
+	LeaderboardWindowAll    = "all"    // All-time totals (default)
+	LeaderboardWindowDay    = "day"    // Matches in the last 24 hours
+	LeaderboardWindowWeek   = "week"   // Matches in the last 7 days
+	LeaderboardWindowMonth  = "month"  // Matches in the last 30 days
+	LeaderboardWindowSeason = "season" // Matches in a season (the current one unless another is asked for)
+
+	// Leaderboard sort orders
+	LeaderboardSortPoints         = "points" // Default
+	LeaderboardSortRating         = "rating"
+	LeaderboardSortWinRate        = "winRate"
+	LeaderboardSortGamesWon       = "gamesWon"
+	LeaderboardSortKillsPerMinute = "killsPerMinute"
+
+	// Leaderboard settings
+	LeaderboardPageSize    = 50          // Entries per page unless a limit is given
+	LeaderboardMaxPageSize = 100         // Largest limit a request can ask for
+	SeasonArchiveInterval  = time.Minute // How often ended seasons are checked for archiving
+
+	// Matchmaking settings
+	MatchmakingRatingWindow       = 100.0       // Largest rating gap between two players who just joined the queue
+	MatchmakingRatingWindowGrowth = 10.0        // Extra rating gap allowed per second a player has waited
+	MatchmakingInterval           = time.Second // How often the queue is re-checked as rating windows widen
+
+	// Session settings
+	SessionSweepInterval = time.Hour // How often expired sign-in and guest sessions are removed
+
+	// Connection settings
+	ReconnectGracePeriod = 30.0 // Seconds a disconnected player's seat is held before they forfeit
+
+	// Wire protocol settings
+	ProtocolVersion    = 2 // Version of the message protocol, bumped when a change would break existing clients
+	MinProtocolVersion = 2 // Oldest protocol version clients can connect with (2 added the hello handshake)
+
+	// Client features, announced in the hello handshake
+	FeatureDeltaUpdates = "delta_updates" // Applies game_update deltas and acknowledges snapshots
+	FeatureGameEvents   = "game_events"   // Handles game_events messages
+
+	// Error codes, sent in error messages so clients can tell errors apart without matching their text
+	ErrorCodeUnknownMessage  = "unknown_message"   // The server doesn't handle the message type
+	ErrorCodeInvalidPayload  = "invalid_payload"   // The message's payload couldn't be decoded
+	ErrorCodeMissingField    = "missing_field"     // The message's payload is missing a required field
+	ErrorCodeAlreadyInGame   = "already_in_game"   // The client is already playing in a game
+	ErrorCodeNotInGame       = "not_in_game"       // The message is only for players in a game
+	ErrorCodeNotFound        = "not_found"         // The game or replay doesn't exist (or has ended)
+	ErrorCodeRejected        = "rejected"          // The request isn't allowed, for the reason in the message
+	ErrorCodeClientTooOld    = "client_too_old"    // The client doesn't speak MinProtocolVersion or newer
+	ErrorCodeTooManyMessages = "too_many_messages" // The client was kicked for sending too many messages
+	ErrorCodeSuspiciousInput = "suspicious_input"  // The client was kicked for sending inputs no unmodified client sends
+
+	// State update settings
+	StateKeyframeInterval = 100  // Snapshots between full-state keyframes for clients receiving deltas (5 seconds)
+	StateSnapshotHistory  = 32   // Snapshots kept to encode deltas against; older acknowledgements get a keyframe
+	StatePrecision        = 0.01 // Positions and timers in game updates are rounded to this, so idle entities don't resend noise
+
+	// Lag compensation settings
+	DefaultMaxShotRewind = 200 * time.Millisecond // Furthest back player shots are resolved, unless MAX_SHOT_REWIND is set
+
+	// Fog of war settings (for maps that enable it)
+	MinSightRadius       = 30.0 // Units see at least this far
+	SightRangeMargin     = 10.0 // Units see this far beyond their attack range
+	StructureSightRadius = 25.0 // Bases and claimed turrets see this far
+
 	// Economy settings
 	StartingMoney          = 1000
 	PassiveIncomePerSecond = 10
@@ -75,6 +190,10 @@ const (
 	// Infantry positioning (same as tanks - ground level)
 	InfantryYPosition = 1.0
 
+	// Base stats (tanks, super tanks, rocket launchers and players deal siege damage)
+	BaseHealth = 300 // Default hit points, maps can override per player
+	BaseArmor  = 0   // Default flat damage reduction per hit
+
 	// Barracks stats
 	BarracksHealth        = 40   // Takes more hits than turrets
 	BarracksRespawnTime   = 30.0 // Seconds to respawn as neutral after destruction
@@ -128,4 +247,8 @@ var (
 	Base2Position = Vector3{X: 90, Y: 0, Z: 0}
 
 	PlayerColors = [2]string{"#3b82f6", "#ef4444"} // Blue and Red
+
+	// ServerBuild identifies the server in the hello handshake, set when building with
+	// -ldflags "-X github.com/tombuildsstuff/web-arena-game/server/internal/types.ServerBuild=<build>"
+	ServerBuild = "dev"
 )
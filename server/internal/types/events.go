@@ -6,28 +6,64 @@ type Message struct {
 	Payload interface{} `json:"payload"`
 }
 
+// HelloPayload is the first message a client sends, saying which protocol version it speaks
+// and what it supports. Clients older than MinProtocolVersion are sent an error and disconnected.
+type HelloPayload struct {
+	ProtocolVersion int      `json:"protocolVersion"`
+	ClientBuild     string   `json:"clientBuild,omitempty"`
+	Encodings       []string `json:"encodings"` // Encodings the client can decode
+	Features        []string `json:"features"`  // Optional messages the client handles, e.g. FeatureDeltaUpdates
+}
+
+// WelcomePayload is the server's reply to a hello
+type WelcomePayload struct {
+	ProtocolVersion    int      `json:"protocolVersion"` // Version used for the connection: the older of the client's and the server's
+	MinProtocolVersion int      `json:"minProtocolVersion"`
+	ServerBuild        string   `json:"serverBuild"`
+	Encoding           string   `json:"encoding"`  // Encoding the server sends messages in
+	Encodings          []string `json:"encodings"` // Encodings the server can send, most preferred first
+	Features           []string `json:"features"`  // Features both sides support, which are enabled for the connection
+}
+
 // JoinQueuePayload represents a request to join the matchmaking queue
 type JoinQueuePayload struct {
-	MapID string `json:"mapId,omitempty"` // Preferred map ID (empty = no preference)
+	MapID    string `json:"mapId,omitempty"`    // Preferred map ID (empty = no preference)
+	GameMode string `json:"gameMode,omitempty"` // "siege", "king_of_the_hill" or "capture_the_flag" (empty = siege)
+	TeamSize int    `json:"teamSize,omitempty"` // Players per team, up to MaxTeamSize (0 = 1v1)
 }
 
 // PurchaseUnitPayload represents a request to purchase a unit
 type PurchaseUnitPayload struct {
-	UnitType string `json:"unitType"` // "tank" or "airplane"
+	UnitType string `json:"unitType" required:"true"` // "tank" or "airplane"
 }
 
 // GameStartPayload is sent when a game begins
 type GameStartPayload struct {
 	GameID   string         `json:"gameId"`
-	PlayerID int            `json:"playerId"`
+	PlayerID int            `json:"playerId"` // Team the client plays for
+	Seat     int            `json:"seat"`     // Seat the client controls (equal to PlayerID in 1v1)
 	State    GameState      `json:"state"`
 	Map      *MapDefinition `json:"map,omitempty"`
+
+	// Reconnected is true when this snapshot re-binds a player to a game already in progress
+	Reconnected bool `json:"reconnected,omitempty"`
 }
 
-// GameUpdatePayload is sent periodically with the current game state
+// GameUpdatePayload is sent every tick with the current game state. Clients that acknowledge
+// snapshots (see StateAckPayload) receive a delta against the newest one they acknowledged, with
+// a full-state keyframe every StateKeyframeInterval snapshots; everyone else gets a keyframe
+// every time. Obstacles never change, so they're only sent in game_start and spectate_start.
 type GameUpdatePayload struct {
-	Timestamp int64     `json:"timestamp"`
-	State     GameState `json:"state"`
+	Timestamp    int64           `json:"timestamp"`
+	Snapshot     int64           `json:"snapshot"`               // Sequence number of this snapshot
+	State        *GameState      `json:"state,omitempty"`        // Keyframe (nil when Delta is set)
+	Delta        *GameStateDelta `json:"delta,omitempty"`        // Changes since BaseSnapshot
+	BaseSnapshot int64           `json:"baseSnapshot,omitempty"` // Snapshot the delta applies to
+}
+
+// StateAckPayload acknowledges the newest game_update snapshot a client has applied
+type StateAckPayload struct {
+	Snapshot int64 `json:"snapshot" required:"true"`
 }
 
 // GameOverPayload is sent when the game ends
@@ -36,18 +72,38 @@ type GameOverPayload struct {
 	Reason        string     `json:"reason"`
 	MatchDuration int        `json:"matchDuration"` // Duration in seconds
 	Stats         MatchStats `json:"stats"`
+
+	// Ratings are indexed by player ID, nil for sides that weren't rated (guests, AI players, team and private games)
+	Ratings []*RatingChange `json:"ratings,omitempty"`
+}
+
+// RatingChange is how a player's skill rating moved as a result of a match
+type RatingChange struct {
+	Rating float64 `json:"rating"` // Rating after the match
+	Change float64 `json:"change"`
+	VsAI   bool    `json:"vsAi"` // Rated in the vs-AI pool rather than against other players
 }
 
-// PlayerStats contains detailed kill statistics for a player
+// PlayerStats contains detailed statistics for a player
 type PlayerStats struct {
-	TankKills           int `json:"tankKills"`
-	AirplaneKills       int `json:"airplaneKills"`
-	SniperKills         int `json:"sniperKills"`
-	RocketLauncherKills int `json:"rocketLauncherKills"`
-	TurretKills         int `json:"turretKills"`
-	BarracksKills       int `json:"barracksKills"`
-	PlayerKills         int `json:"playerKills"`
-	TotalPoints         int `json:"totalPoints"`
+	TankKills            int            `json:"tankKills"` // Including super tanks
+	SuperTankKills       int            `json:"superTankKills"`
+	AirplaneKills        int            `json:"airplaneKills"`
+	SniperKills          int            `json:"sniperKills"`
+	RocketLauncherKills  int            `json:"rocketLauncherKills"`
+	TurretKills          int            `json:"turretKills"`
+	BarracksKills        int            `json:"barracksKills"`
+	PlayerKills          int            `json:"playerKills"`
+	TotalPoints          int            `json:"totalPoints"`
+	Deaths               int            `json:"deaths"`
+	DamageDealt          int            `json:"damageDealt"`
+	MoneyEarned          int            `json:"moneyEarned"`
+	MoneySpent           int            `json:"moneySpent"`
+	UnitsPurchased       map[string]int `json:"unitsPurchased,omitempty"` // Unit type -> number bought
+	TurretsClaimed       int            `json:"turretsClaimed"`
+	BuyZonesClaimed      int            `json:"buyZonesClaimed"`
+	BarracksClaimed      int            `json:"barracksClaimed"`
+	HealthPacksCollected int            `json:"healthPacksCollected"`
 }
 
 // MatchStats contains end-of-match statistics
@@ -60,54 +116,96 @@ type MatchStats struct {
 
 // ErrorPayload is sent when an error occurs
 type ErrorPayload struct {
-	Message string `json:"message"`
+	Message     string `json:"message"`
+	Code        string `json:"code,omitempty"`        // One of the ErrorCode constants
+	MessageType string `json:"messageType,omitempty"` // Type of the message that failed, if the error is about one
+}
+
+// PlayerConnectionPayload is sent when a player's connection drops or is restored mid-game
+type PlayerConnectionPayload struct {
+	PlayerID    int     `json:"playerId"`
+	Seat        int     `json:"seat"`
+	GracePeriod float64 `json:"gracePeriod,omitempty"` // Seconds the seat is held before forfeiting
+}
+
+// InputSequence numbers a player's move and shoot inputs, so they can tell from input acks
+// which of their inputs the server has processed (see PlayerInputAck)
+type InputSequence struct {
+	Sequence   int64 `json:"sequence,omitempty"`   // Increases with every input the client sends (0 = unnumbered)
+	ClientTime int64 `json:"clientTime,omitempty"` // Client clock when the input was sent (Unix millis)
 }
 
 // PlayerMovePayload represents player movement input
 type PlayerMovePayload struct {
+	InputSequence
 	Direction Vector3 `json:"direction"` // Movement direction (normalized by client)
 }
 
 // PlayerShootPayload represents player shoot command
 type PlayerShootPayload struct {
-	TargetX float64 `json:"targetX"` // World X coordinate to shoot at
-	TargetZ float64 `json:"targetZ"` // World Z coordinate to shoot at
+	InputSequence
+	TargetX      float64 `json:"targetX"`                // World X coordinate to shoot at
+	TargetZ      float64 `json:"targetZ"`                // World Z coordinate to shoot at
+	ViewSnapshot int64   `json:"viewSnapshot,omitempty"` // Newest game_update snapshot the shooter had applied, for lag compensation
+}
+
+// PlayerInputAck is sent to each player as an input_ack straight after each game update: the newest
+// of their inputs the update includes, with their player unit's authoritative movement and
+// cooldowns, so they can reconcile the movement they predicted. It's a message of its own so the
+// game update can be encoded once and shared.
+type PlayerInputAck struct {
+	Snapshot int64 `json:"snapshot"` // The game update this goes with
+	InputSequence
+	Position        Vector3 `json:"position"`
+	Velocity        Vector3 `json:"velocity"`        // Units per second moved in the last tick
+	ShootCooldown   float64 `json:"shootCooldown"`   // Seconds until the player can shoot again (0 = ready)
+	RespawnCooldown float64 `json:"respawnCooldown"` // Seconds until the player respawns (0 = alive)
 }
 
 // BuyFromZonePayload represents a request to buy from a buy zone
 type BuyFromZonePayload struct {
-	ZoneID string `json:"zoneId"` // ID of the buy zone to purchase from
+	ZoneID string `json:"zoneId" required:"true"` // ID of the buy zone to purchase from
 }
 
 // BulkBuyFromZonePayload represents a request to buy 10 units at a discount
 type BulkBuyFromZonePayload struct {
-	ZoneID string `json:"zoneId"` // ID of the buy zone to purchase from
+	ZoneID string `json:"zoneId" required:"true"` // ID of the buy zone to purchase from
 }
 
 // ClaimTurretPayload represents a request to claim a turret
 type ClaimTurretPayload struct {
-	TurretID string `json:"turretId"` // ID of the turret to claim
+	TurretID string `json:"turretId" required:"true"` // ID of the turret to claim
 }
 
 // ClaimBuyZonePayload represents a request to claim a buy zone
 type ClaimBuyZonePayload struct {
-	ZoneID string `json:"zoneId"` // ID of the buy zone to claim
+	ZoneID string `json:"zoneId" required:"true"` // ID of the buy zone to claim
 }
 
 // ClaimBarracksPayload represents a request to claim a barracks
 type ClaimBarracksPayload struct {
-	BarracksID string `json:"barracksId"` // ID of the barracks to claim
+	BarracksID string `json:"barracksId" required:"true"` // ID of the barracks to claim
 }
 
 // SpectateStartPayload is sent when a spectator joins a game
 type SpectateStartPayload struct {
 	GameID string    `json:"gameId"`
 	State  GameState `json:"state"`
+
+	// Set when spectating a recorded replay rather than a live game
+	ReplayID string `json:"replayId,omitempty"`
+	Speed    int    `json:"speed,omitempty"` // Playback speed multiplier (1, 2 or 4)
 }
 
 // SpectateGamePayload represents a request to spectate a game
 type SpectateGamePayload struct {
-	GameID string `json:"gameId"` // ID of the game to spectate
+	GameID string `json:"gameId" required:"true"` // ID of the game to spectate
+}
+
+// SpectateReplayPayload represents a request to watch a recorded replay
+type SpectateReplayPayload struct {
+	ReplayID string `json:"replayId" required:"true"` // ID of the replay to watch
+	Speed    int    `json:"speed,omitempty"`          // Playback speed multiplier (1, 2 or 4, default 1)
 }
 
 // ActiveGame represents a game available for spectating
@@ -128,4 +226,6 @@ type LobbyStatusPayload struct {
 type StartVsAIPayload struct {
 	Difficulty string `json:"difficulty"`        // "easy", "medium", or "hard"
 	MapID      string `json:"mapId,omitempty"`   // Preferred map ID (empty = default)
+	GameMode   string `json:"gameMode,omitempty"` // "siege", "king_of_the_hill" or "capture_the_flag" (empty = siege)
+	TeamSize   int    `json:"teamSize,omitempty"` // Players per team; AI fills the other seats (0 = 1v1)
 }
@@ -17,10 +17,42 @@ type GameState struct {
 	BuyZones      []BuyZone      `json:"buyZones"`
 	Turrets       []Turret       `json:"turrets"`
 	Barracks      []Barracks     `json:"barracks"`
+	Bases         []Base         `json:"bases"`
 	HealthPacks   []HealthPack   `json:"healthPacks"`
 	PendingSpawns []PendingSpawn `json:"pendingSpawns"`
 	GameStatus    string         `json:"gameStatus"` // "waiting", "playing", "finished"
 	Winner        *int           `json:"winner"`
+	TimeRemaining *float64       `json:"timeRemaining,omitempty"` // Seconds left in the current phase (nil if there's no time limit)
+	SuddenDeath   bool           `json:"suddenDeath,omitempty"`   // True during sudden-death overtime
+
+	// Units that were visible in the previous update but have gone out of sight (fog of war),
+	// rather than being destroyed
+	HiddenUnits []string `json:"hiddenUnits,omitempty"`
+
+	// Game mode objective
+	GameMode        string     `json:"gameMode"`
+	Hills           []Hill     `json:"hills,omitempty"`           // King of the Hill
+	Flags           []Flag     `json:"flags,omitempty"`           // Capture the Flag
+	ObjectiveScores [2]float64 `json:"objectiveScores"`           // Hill seconds or flag captures per player
+	ObjectiveTarget float64    `json:"objectiveTarget,omitempty"` // Score needed to win (0 if the mode has no score)
+}
+
+// Hill represents a King of the Hill zone
+type Hill struct {
+	ID           string  `json:"id"`
+	Position     Vector3 `json:"position"`
+	Radius       float64 `json:"radius"`
+	ControllerID int     `json:"controllerId"` // -1 = nobody, 0 = player 1, 1 = player 2
+	IsContested  bool    `json:"isContested"`
+}
+
+// Flag represents a Capture the Flag flag
+type Flag struct {
+	OwnerID       int     `json:"ownerId"`
+	StandPosition Vector3 `json:"standPosition"`
+	Position      Vector3 `json:"position"`
+	CarrierID     string  `json:"carrierId,omitempty"` // PlayerUnit carrying the flag
+	IsDropped     bool    `json:"isDropped"`
 }
 
 // Obstacle represents a static obstacle in the arena
@@ -49,13 +81,23 @@ type Projectile struct {
 
 // Player represents a player in the game
 type Player struct {
-	ID           int     `json:"id"`
-	Money        int     `json:"money"`
-	BasePosition Vector3 `json:"basePosition"`
-	Color        string  `json:"color"`
-	DisplayName  string  `json:"displayName"`
-	IsGuest      bool    `json:"isGuest"`
-	Kills        int     `json:"kills"`
+	ID           int      `json:"id"`
+	Money        int      `json:"money"`
+	BasePosition Vector3  `json:"basePosition"`
+	Color        string   `json:"color"`
+	DisplayName  string   `json:"displayName"`
+	IsGuest      bool     `json:"isGuest"`
+	Kills        int      `json:"kills"`
+	Members      []Member `json:"members,omitempty"` // Seats on this team (team games only)
+}
+
+// Member represents one seat on a team
+type Member struct {
+	Seat           int    `json:"seat"`
+	DisplayName    string `json:"displayName"`
+	IsGuest        bool   `json:"isGuest"`
+	Money          int    `json:"money,omitempty"` // Personal wallet when the economy is split
+	IsDisconnected bool   `json:"isDisconnected,omitempty"`
 }
 
 // BuyZone represents a location where players can purchase units
@@ -81,6 +123,7 @@ type Unit struct {
 	TargetPosition Vector3 `json:"targetPosition"`
 	IsRespawning   bool    `json:"isRespawning,omitempty"`
 	RespawnTime    float64 `json:"respawnTime,omitempty"` // Seconds remaining until respawn
+	Seat           int     `json:"seat,omitempty"`        // Controlling seat, for player units in team games
 }
 
 // Turret represents a claimable turret that auto-attacks enemies
@@ -113,6 +156,17 @@ type HealthPack struct {
 	Position Vector3 `json:"position"`
 }
 
+// Base represents a player's home base, which loses the match when destroyed
+type Base struct {
+	ID        string  `json:"id"`
+	OwnerID   int     `json:"ownerId"`
+	Position  Vector3 `json:"position"`
+	Radius    float64 `json:"radius"`
+	Health    int     `json:"health"`
+	MaxHealth int     `json:"maxHealth"`
+	Armor     int     `json:"armor"` // Flat damage reduction per hit
+}
+
 // Barracks represents a claimable spawn point for infantry units
 type Barracks struct {
 	ID            string  `json:"id"`
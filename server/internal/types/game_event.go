@@ -0,0 +1,34 @@
+package types
+
+// Game event types
+const (
+	GameEventUnitKilled          = "unit_killed"           // A unit or player unit was killed
+	GameEventStructureDestroyed  = "structure_destroyed"   // A turret or barracks was destroyed
+	GameEventStructureClaimed    = "structure_claimed"     // A turret, buy zone or barracks was claimed
+	GameEventBaseDestroyed       = "base_destroyed"        // A base was destroyed
+	GameEventBarracksScattered   = "barracks_scattered"    // A destroyed barracks threw its infantry out
+	GameEventHealthPackCollected = "health_pack_collected" // A player unit picked up a health pack
+	GameEventUnitPurchased       = "unit_purchased"        // Units were bought from a base or buy zone
+	GameEventPlayerRespawned     = "player_respawned"      // A player unit came back after being killed
+	GameEventMatchEnded          = "match_ended"           // The match finished (PlayerID is the winner, -1 for a draw)
+)
+
+// GameEvent is a notable moment in a match
+type GameEvent struct {
+	Type           string `json:"type"`
+	Time           int64  `json:"time"`                 // Simulation time (Unix millis)
+	PlayerID       int    `json:"playerId"`             // Team that caused the event (-1 = nobody, e.g. a neutral turret)
+	Seat           int    `json:"seat"`                 // Seat whose player unit or command caused it (-1 = not a seat)
+	UnitType       string `json:"unitType,omitempty"`   // What caused it, e.g. the shooter's unit type ("turret" for turret fire) or the unit bought
+	Count          int    `json:"count,omitempty"`      // Units bought or infantry scattered
+	TargetID       string `json:"targetId,omitempty"`   // Entity the event happened to
+	TargetType     string `json:"targetType,omitempty"` // e.g. "sniper", "turret", "buy_zone" or "base"
+	TargetPlayerID int    `json:"targetPlayerId"`       // Team that owned the target (-1 = neutral)
+	Reason         string `json:"reason,omitempty"`     // Win reason, for match_ended
+}
+
+// GameEventsPayload is sent after each tick in which something notable happened
+type GameEventsPayload struct {
+	Timestamp int64       `json:"timestamp"`
+	Events    []GameEvent `json:"events"`
+}
@@ -27,6 +27,53 @@ type MapDefinition struct {
 
 	// Health pack spawn configuration
 	HealthPackSpawnBounds MapBounds `json:"healthPackSpawnBounds"`
+
+	// Game mode objects (modes fall back to sensible positions if a map doesn't declare them)
+	HillZones  []MapHillZone  `json:"hillZones,omitempty"`  // King of the Hill zones
+	FlagStands []MapFlagStand `json:"flagStands,omitempty"` // Capture the Flag stands, one per player
+
+	// Match rules (nil uses DefaultMatchRules: no time limit, so matches only end when a base falls)
+	Rules *MatchRules `json:"rules,omitempty"`
+
+	// Fog of war (nil = everyone sees everything)
+	FogOfWar *MapFogOfWar `json:"fogOfWar,omitempty"`
+}
+
+// MapFogOfWar hides the enemy units, projectiles and health packs a team's units and structures
+// can't see from that team's game updates. Structures and objectives are always visible.
+type MapFogOfWar struct {
+	LimitSpectators bool `json:"limitSpectators,omitempty"` // Spectators only see what either team can see, rather than everything
+}
+
+// MatchRules decides how a match ends when no base is captured
+type MatchRules struct {
+	TimeLimit                   float64 `json:"timeLimit"`                   // Seconds of regular time (0 = no limit)
+	Tiebreak                    string  `json:"tiebreak"`                    // "points", "structures" or "kills"
+	SuddenDeath                 bool    `json:"suddenDeath"`                 // Play sudden-death overtime before the tiebreak
+	SuddenDeathDuration         float64 `json:"suddenDeathDuration"`         // Seconds of sudden death
+	SuddenDeathIncomeMultiplier float64 `json:"suddenDeathIncomeMultiplier"` // Passive income multiplier during sudden death
+	Economy                     string  `json:"economy,omitempty"`           // Team money: "shared" (default) or "split"
+	StartingMoney               int     `json:"startingMoney,omitempty"`     // Money per player at the start (0 = types.StartingMoney)
+}
+
+// DefaultMatchRules returns the rules used when a map doesn't set its own. There's no time limit,
+// so the overtime settings only apply if a lobby opts in to one.
+func DefaultMatchRules() MatchRules {
+	return MatchRules{
+		TimeLimit:                   0,
+		Tiebreak:                    TiebreakPoints,
+		SuddenDeath:                 true,
+		SuddenDeathDuration:         SuddenDeathDuration,
+		SuddenDeathIncomeMultiplier: SuddenDeathIncomeMultiplier,
+	}
+}
+
+// MatchRules returns the map's match rules, falling back to the defaults
+func (m *MapDefinition) MatchRules() MatchRules {
+	if m.Rules != nil {
+		return *m.Rules
+	}
+	return DefaultMatchRules()
 }
 
 // MapPlayerConfig defines player-specific map settings
@@ -34,6 +81,12 @@ type MapPlayerConfig struct {
 	BasePosition Vector3 `json:"basePosition"`
 	SpawnOffset  Vector3 `json:"spawnOffset"` // Offset from base for unit spawning
 	Color        string  `json:"color"`
+	BaseHealth   int     `json:"baseHealth,omitempty"` // Base hit points (0 = types.BaseHealth)
+	BaseArmor    int     `json:"baseArmor,omitempty"`  // Flat damage reduction per hit (0 = types.BaseArmor)
+
+	// SpawnSlots are offsets from BasePosition where each team member's PlayerUnit spawns,
+	// in seat order. Members beyond the declared slots are spread along Z.
+	SpawnSlots []Vector3 `json:"spawnSlots,omitempty"`
 }
 
 // MapBuyZone defines a buy zone in the map
@@ -73,6 +126,19 @@ type MapObstacle struct {
 	ElevationEnd   float64 `json:"elevationEnd,omitempty"`   // For ramps
 }
 
+// MapHillZone defines a King of the Hill zone in the map
+type MapHillZone struct {
+	ID       string  `json:"id"`
+	Position Vector3 `json:"position"`
+	Radius   float64 `json:"radius"`
+}
+
+// MapFlagStand defines where a player's flag starts in Capture the Flag
+type MapFlagStand struct {
+	OwnerID  int     `json:"ownerId"`
+	Position Vector3 `json:"position"`
+}
+
 // MapBounds defines a rectangular area for spawning
 type MapBounds struct {
 	MinX float64 `json:"minX"`
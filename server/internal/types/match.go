@@ -0,0 +1,42 @@
+package types
+
+// MatchRecord is the stored result of a finished match
+type MatchRecord struct {
+	ID       string               `json:"id"` // Same as the game ID it was played in
+	MapID    string               `json:"mapId"`
+	MapName  string               `json:"mapName"`
+	GameMode string               `json:"gameMode"`
+	Ranked   bool                 `json:"ranked"`  // Counted on the leaderboard (1v1 matchmaking and vs-AI games)
+	Players  [2]MatchRecordPlayer `json:"players"` // Indexed by player ID
+	Winner   int                  `json:"winner"`  // Player ID of the winner (-1 = draw)
+	Reason   string               `json:"reason"`
+	Duration int                  `json:"duration"` // Match duration in seconds
+	EndedAt  int64                `json:"endedAt"`  // Unix millis
+}
+
+// MatchRecordPlayer is one side of a recorded match
+type MatchRecordPlayer struct {
+	DisplayName string              `json:"displayName"`
+	Members     []MatchRecordMember `json:"members"` // Everyone who played for this side, in seat order
+	Stats       PlayerStats         `json:"stats"`
+	Rating      *RatingChange       `json:"rating,omitempty"` // nil if the match wasn't rated for this side
+}
+
+// MatchRecordMember is one seat in a recorded match
+type MatchRecordMember struct {
+	Seat           int            `json:"seat"`
+	UserID         string         `json:"userId,omitempty"` // Empty for AI players
+	DisplayName    string         `json:"displayName"`
+	IsGuest        bool           `json:"isGuest"`
+	AIDifficulty   string         `json:"aiDifficulty,omitempty"`   // Set when this seat was AI-controlled
+	UnitsPurchased map[string]int `json:"unitsPurchased,omitempty"` // Unit type -> number bought
+}
+
+// MapWinRate is how a player has done on one map
+type MapWinRate struct {
+	MapID   string  `json:"mapId"`
+	MapName string  `json:"mapName"`
+	Played  int     `json:"played"`
+	Won     int     `json:"won"`
+	WinRate float64 `json:"winRate"` // 0-1
+}
@@ -0,0 +1,64 @@
+package types
+
+// LobbySettings are the match options the host of a private lobby can change
+type LobbySettings struct {
+	MapID         string  `json:"mapId"`
+	GameMode      string  `json:"gameMode"`      // "siege", "king_of_the_hill" or "capture_the_flag"
+	TeamSize      int     `json:"teamSize"`      // Players per team, up to MaxTeamSize
+	StartingMoney int     `json:"startingMoney"` // Money each player starts with
+	TimeLimit     float64 `json:"timeLimit"`     // Seconds of regular time (0 = the map's limit, which most maps don't have)
+	AIFill        bool    `json:"aiFill"`        // Fill empty seats with AI players when the match starts
+	AIDifficulty  string  `json:"aiDifficulty"`  // "easy", "medium" or "hard"
+}
+
+// DefaultLobbySettings returns the settings a new private lobby starts with
+func DefaultLobbySettings(mapID string) LobbySettings {
+	return LobbySettings{
+		MapID:         mapID,
+		GameMode:      GameModeSiege,
+		TeamSize:      1,
+		StartingMoney: StartingMoney,
+		TimeLimit:     0, // The map's limit unless the host sets one
+		AIFill:        false,
+		AIDifficulty:  "medium",
+	}
+}
+
+// PrivateLobby is the state of a private lobby, sent to its members in lobby_update
+type PrivateLobby struct {
+	Code     string        `json:"code"` // Join code to share with friends
+	Settings LobbySettings `json:"settings"`
+	Members  []LobbyMember `json:"members"` // In seat order
+	Maps     []string      `json:"maps"`    // Map IDs the host can pick from
+	CanStart bool          `json:"canStart"`
+}
+
+// LobbyMember is a player waiting in a private lobby
+type LobbyMember struct {
+	DisplayName string `json:"displayName"`
+	IsGuest     bool   `json:"isGuest"`
+	IsHost      bool   `json:"isHost"`
+	Ready       bool   `json:"ready"`
+	Seat        int    `json:"seat"`
+	Team        int    `json:"team"`
+}
+
+// CreateLobbyPayload represents a request to create a private lobby
+type CreateLobbyPayload struct {
+	Settings *LobbySettings `json:"settings,omitempty"` // Initial settings (nil = defaults)
+}
+
+// JoinLobbyPayload represents a request to join a private lobby by its code
+type JoinLobbyPayload struct {
+	Code string `json:"code" required:"true"`
+}
+
+// UpdateLobbyPayload represents the host changing a private lobby's settings
+type UpdateLobbyPayload struct {
+	Settings LobbySettings `json:"settings"`
+}
+
+// SetReadyPayload represents a lobby member marking themselves ready (or not)
+type SetReadyPayload struct {
+	Ready bool `json:"ready"`
+}
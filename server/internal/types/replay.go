@@ -0,0 +1,77 @@
+package types
+
+// Replay is a recorded match that can be re-simulated from its inputs
+type Replay struct {
+	ID         string          `json:"id"` // Same as the game ID it was recorded from
+	Map        *MapDefinition  `json:"map"`
+	Seed       int64           `json:"seed"` // Seed for the room's random number generator
+	Rules      *MatchRules     `json:"rules,omitempty"`
+	GameMode   string          `json:"gameMode,omitempty"`
+	Players    [2]ReplayPlayer `json:"players"`
+	Seats      []ReplaySeat    `json:"seats,omitempty"` // Every seat in team games (nil for 1v1)
+	StartedAt  int64           `json:"startedAt"`       // Unix millis
+	Duration   int             `json:"duration"`        // Match duration in seconds
+	TotalTicks int64           `json:"totalTicks"`
+	Winner     int             `json:"winner"`
+	Reason     string          `json:"reason"`
+	Inputs     []ReplayInput   `json:"inputs"` // Ordered by tick
+}
+
+// ReplayPlayer describes one side of a recorded match
+type ReplayPlayer struct {
+	DisplayName  string `json:"displayName"`
+	IsGuest      bool   `json:"isGuest"`
+	AIDifficulty string `json:"aiDifficulty,omitempty"` // Set when this side was AI-controlled
+}
+
+// ReplaySeat describes one team member's seat in a recorded team match
+type ReplaySeat struct {
+	Seat         int    `json:"seat"`
+	DisplayName  string `json:"displayName"`
+	IsGuest      bool   `json:"isGuest"`
+	AIDifficulty string `json:"aiDifficulty,omitempty"` // Set when this seat was AI-controlled
+}
+
+// ReplayInput is a single player input routed into a game room
+type ReplayInput struct {
+	Tick      int64    `json:"tick"`     // Tick the input was received after
+	PlayerID  int      `json:"playerId"` // Seat that sent the input (the player ID in 1v1)
+	Type      string   `json:"type"`     // Same as the WebSocket message type, e.g. "player_move"
+	Direction *Vector3 `json:"direction,omitempty"`
+	TargetX   float64  `json:"targetX,omitempty"`
+	TargetZ   float64  `json:"targetZ,omitempty"`
+	UnitType  string   `json:"unitType,omitempty"`
+	TargetID  string   `json:"targetId,omitempty"` // Buy zone, turret or barracks ID
+	Rewind    int64    `json:"rewind,omitempty"`   // Milliseconds a shot was rewound by to make up for the shooter's latency
+}
+
+// ReplaySummary is the listing entry for a stored replay
+type ReplaySummary struct {
+	ID          string `json:"id"`
+	MapID       string `json:"mapId"`
+	MapName     string `json:"mapName"`
+	Player1Name string `json:"player1Name"`
+	Player2Name string `json:"player2Name"`
+	StartedAt   int64  `json:"startedAt"`
+	Duration    int    `json:"duration"`
+	Winner      int    `json:"winner"`
+	Reason      string `json:"reason"`
+}
+
+// Summary returns the listing entry for this replay
+func (r *Replay) Summary() ReplaySummary {
+	summary := ReplaySummary{
+		ID:          r.ID,
+		Player1Name: r.Players[0].DisplayName,
+		Player2Name: r.Players[1].DisplayName,
+		StartedAt:   r.StartedAt,
+		Duration:    r.Duration,
+		Winner:      r.Winner,
+		Reason:      r.Reason,
+	}
+	if r.Map != nil {
+		summary.MapID = r.Map.ID
+		summary.MapName = r.Map.Name
+	}
+	return summary
+}
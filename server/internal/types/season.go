@@ -0,0 +1,23 @@
+package types
+
+import "time"
+
+// Season is a named stretch of time with its own leaderboard
+type Season struct {
+	ID    string    `json:"id"`
+	Name  string    `json:"name"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"` // Exclusive
+}
+
+// Contains returns true if the time falls within the season
+func (s Season) Contains(t time.Time) bool {
+	return !t.Before(s.Start) && t.Before(s.End)
+}
+
+// SeasonInfo is the listing entry for a season
+type SeasonInfo struct {
+	Season
+	Active   bool `json:"active"`   // The season is running now
+	Archived bool `json:"archived"` // The season has ended and its final standings are stored
+}
@@ -0,0 +1,37 @@
+package types
+
+import "sync"
+
+// SharedPayload wraps a payload that is sent to many clients, so each wire format only has to
+// encode the message once. A SharedPayload must always be sent with the same message type.
+type SharedPayload struct {
+	Payload interface{}
+
+	mu      sync.Mutex
+	encoded map[string][]byte // Wire format -> encoded message
+}
+
+// NewSharedPayload wraps a payload for sending to many clients
+func NewSharedPayload(payload interface{}) *SharedPayload {
+	return &SharedPayload{
+		Payload: payload,
+		encoded: make(map[string][]byte),
+	}
+}
+
+// Encode returns the message encoded in a wire format, using encode the first time that format
+// is asked for
+func (s *SharedPayload) Encode(format string, encode func(payload interface{}) ([]byte, error)) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if data, exists := s.encoded[format]; exists {
+		return data, nil
+	}
+	data, err := encode(s.Payload)
+	if err != nil {
+		return nil, err
+	}
+	s.encoded[format] = data
+	return data, nil
+}
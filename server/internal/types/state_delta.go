@@ -0,0 +1,35 @@
+package types
+
+import "encoding/json"
+
+// EntityPatch is a JSON merge patch (RFC 7386) of one entity's top-level fields. New entities
+// are sent whole; changed ones carry their "id" and only the fields that changed, where null
+// means the field went back to its zero value (and is left out of the full entity).
+type EntityPatch map[string]json.RawMessage
+
+// GameStateDelta is how a game state differs from an earlier snapshot.
+// Entity lists hold patches for what is new or changed, with the IDs of anything that is gone;
+// the players, pending spawns and match status are always sent whole. Fields not listed here
+// (obstacles, game mode, objective target) don't change during a match.
+type GameStateDelta struct {
+	Players            [2]Player      `json:"players"`
+	Units              []EntityPatch  `json:"units,omitempty"`
+	RemovedUnits       []string       `json:"removedUnits,omitempty"`
+	HiddenUnits        []string       `json:"hiddenUnits,omitempty"` // Removed units that went out of sight (fog of war) rather than being destroyed
+	Projectiles        []EntityPatch  `json:"projectiles,omitempty"`
+	RemovedProjectiles []string       `json:"removedProjectiles,omitempty"`
+	HealthPacks        []EntityPatch  `json:"healthPacks,omitempty"`
+	RemovedHealthPacks []string       `json:"removedHealthPacks,omitempty"`
+	BuyZones           []EntityPatch  `json:"buyZones,omitempty"`
+	Turrets            []EntityPatch  `json:"turrets,omitempty"`
+	Barracks           []EntityPatch  `json:"barracks,omitempty"`
+	Bases              []EntityPatch  `json:"bases,omitempty"`
+	Hills              []EntityPatch  `json:"hills,omitempty"`
+	Flags              []Flag         `json:"flags,omitempty"` // Every flag, sent when any of them changed
+	PendingSpawns      []PendingSpawn `json:"pendingSpawns"`
+	GameStatus         string         `json:"gameStatus"`
+	Winner             *int           `json:"winner"`
+	TimeRemaining      *float64       `json:"timeRemaining"`
+	SuddenDeath        bool           `json:"suddenDeath"`
+	ObjectiveScores    [2]float64     `json:"objectiveScores"`
+}
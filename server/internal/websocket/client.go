@@ -1,7 +1,6 @@
 package websocket
 
 import (
-	"encoding/json"
 	"log"
 	"sync"
 	"time"
@@ -28,23 +27,40 @@ const (
 type Client struct {
 	hub         *Hub
 	conn        *websocket.Conn
+	codec       Codec // Wire format negotiated when connecting
 	send        chan []byte
 	ID          string
+	UserID      string // Persistent user ID from the auth or guest session
 	DisplayName string // GitHub username or "Guest_XXXX"
+	AvatarURL   string
 	IsGuest     bool
-	mu          sync.Mutex
-	closed      bool
+
+	// Negotiated in the hello handshake
+	ProtocolVersion int // 0 until the client says hello
+	ClientBuild     string
+	features        map[string]bool
+
+	// Message limits and suspicious input checks (only used by the hub)
+	validator *inputValidator
+
+	mu           sync.Mutex
+	closed       bool
+	closeMessage []byte // Close frame sent once the queued messages are written
 }
 
 // NewClient creates a new client
-func NewClient(hub *Hub, conn *websocket.Conn, id string, displayName string, isGuest bool) *Client {
+func NewClient(hub *Hub, conn *websocket.Conn, codec Codec, id string, userID string, displayName string, avatarURL string, isGuest bool) *Client {
 	return &Client{
 		hub:         hub,
 		conn:        conn,
+		codec:       codec,
 		send:        make(chan []byte, 256),
 		ID:          id,
+		UserID:      userID,
 		DisplayName: displayName,
+		AvatarURL:   avatarURL,
 		IsGuest:     isGuest,
+		validator:   newInputValidator(time.Now()),
 	}
 }
 
@@ -63,7 +79,7 @@ func (c *Client) ReadPump() {
 	})
 
 	for {
-		_, message, err := c.conn.ReadMessage()
+		frameType, message, err := c.conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Printf("error: %v", err)
@@ -71,9 +87,9 @@ func (c *Client) ReadPump() {
 			break
 		}
 
-		// Parse the message
-		var msg types.Message
-		if err := json.Unmarshal(message, &msg); err != nil {
+		// Parse the message, leaving the payload for the handler to decode
+		msg, err := codecForFrame(frameType).Decode(message)
+		if err != nil {
 			log.Printf("error parsing message: %v", err)
 			continue
 		}
@@ -100,10 +116,18 @@ func (c *Client) WritePump() {
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if !ok {
 				// The hub closed the channel
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				c.conn.WriteMessage(websocket.CloseMessage, c.closeMessage)
 				return
 			}
 
+			if c.codec.FrameType() == websocket.BinaryMessage {
+				// Binary messages can't be joined with newlines, so each goes in its own frame
+				if err := c.conn.WriteMessage(websocket.BinaryMessage, message); err != nil {
+					return
+				}
+				continue
+			}
+
 			w, err := c.conn.NextWriter(websocket.TextMessage)
 			if err != nil {
 				return
@@ -132,12 +156,20 @@ func (c *Client) WritePump() {
 
 // SendMessage sends a message to the client
 func (c *Client) SendMessage(msgType string, payload interface{}) {
-	msg := types.Message{
-		Type:    msgType,
-		Payload: payload,
+	// Optional messages are only sent to clients that said they handle them
+	if feature, optional := optionalMessages[msgType]; optional && !c.Supports(feature) {
+		return
 	}
 
-	data, err := json.Marshal(msg)
+	var data []byte
+	var err error
+	if shared, ok := payload.(*types.SharedPayload); ok {
+		data, err = shared.Encode(c.codec.Name(), func(payload interface{}) ([]byte, error) {
+			return c.codec.Marshal(types.Message{Type: msgType, Payload: payload})
+		})
+	} else {
+		data, err = c.codec.Marshal(types.Message{Type: msgType, Payload: payload})
+	}
 	if err != nil {
 		log.Printf("error marshaling message: %v", err)
 		return
@@ -170,3 +202,32 @@ func (c *Client) Close() {
 		close(c.send)
 	}
 }
+
+// CloseWithReason closes the client like Close, telling them why in the close frame.
+// This should only be called by the hub.
+func (c *Client) CloseWithReason(code int, reason string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.closed = true
+		c.closeMessage = websocket.FormatCloseMessage(code, reason)
+		close(c.send)
+	}
+}
+
+// SetFeatures records the optional features enabled for the client in the hello handshake
+func (c *Client) SetFeatures(features []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.features = make(map[string]bool, len(features))
+	for _, feature := range features {
+		c.features[feature] = true
+	}
+}
+
+// Supports returns true if a feature was enabled for the client in the hello handshake
+func (c *Client) Supports(feature string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.features[feature]
+}
@@ -0,0 +1,184 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gorilla/websocket"
+	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec is a wire format for WebSocket messages.
+// Clients pick one when connecting, either with a "arena.v<schema>.<name>" subprotocol or an
+// ?encoding=<name> query parameter; anything else gets JSON. Whatever was negotiated, the server
+// still reads text frames as JSON, so clients only need to decode the format they asked for.
+type Codec interface {
+	// Name is how clients ask for the codec
+	Name() string
+
+	// FrameType is the WebSocket frame type the codec's messages are sent in
+	FrameType() int
+
+	// Marshal encodes a value
+	Marshal(v interface{}) ([]byte, error)
+
+	// Unmarshal decodes data into v
+	Unmarshal(data []byte, v interface{}) error
+
+	// Decode decodes a received message, leaving its payload encoded until a handler decodes it
+	Decode(data []byte) (*IncomingMessage, error)
+}
+
+// IncomingMessage is a message received from a client
+type IncomingMessage struct {
+	Type    string
+	Payload Payload
+}
+
+// Payload is a received message's payload, still in the wire format it arrived in
+type Payload struct {
+	data  []byte
+	codec Codec
+}
+
+// Decode decodes the payload into v. Messages without a payload leave v unchanged.
+func (p Payload) Decode(v interface{}) error {
+	if len(p.data) == 0 {
+		return nil
+	}
+	return p.codec.Unmarshal(p.data, v)
+}
+
+// jsonCodec sends messages as JSON text frames (the default)
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string {
+	return "json"
+}
+
+func (jsonCodec) FrameType() int {
+	return websocket.TextMessage
+}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (c jsonCodec) Decode(data []byte) (*IncomingMessage, error) {
+	var envelope struct {
+		Type    string          `json:"type"`
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, err
+	}
+	return &IncomingMessage{
+		Type:    envelope.Type,
+		Payload: Payload{data: envelope.Payload, codec: c},
+	}, nil
+}
+
+// msgpackCodec sends messages as MessagePack binary frames, keyed by the same field names as JSON
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string {
+	return "msgpack"
+}
+
+func (msgpackCodec) FrameType() int {
+	return websocket.BinaryMessage
+}
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.GetEncoder()
+	defer msgpack.PutEncoder(enc)
+
+	enc.Reset(&buf)
+	enc.SetCustomStructTag("json")
+	enc.UseCompactInts(true)
+	enc.UseCompactFloats(true)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
+
+func (c msgpackCodec) Decode(data []byte) (*IncomingMessage, error) {
+	var envelope struct {
+		Type    string             `msgpack:"type"`
+		Payload msgpack.RawMessage `msgpack:"payload"`
+	}
+	if err := msgpack.Unmarshal(data, &envelope); err != nil {
+		return nil, err
+	}
+	return &IncomingMessage{
+		Type:    envelope.Type,
+		Payload: Payload{data: envelope.Payload, codec: c},
+	}, nil
+}
+
+func init() {
+	// Already-encoded JSON (such as the entity patches in state deltas) is sent as the values it
+	// holds, rather than as a blob of JSON text
+	msgpack.Register(json.RawMessage(nil), func(enc *msgpack.Encoder, v reflect.Value) error {
+		var value interface{}
+		if err := json.Unmarshal(v.Bytes(), &value); err != nil {
+			return err
+		}
+		return enc.Encode(value)
+	}, nil)
+}
+
+// codecs are the wire formats clients can ask for, in the server's order of preference
+var codecs = []Codec{msgpackCodec{}, jsonCodec{}}
+
+// subprotocol is the WebSocket subprotocol that selects a codec for the current message schemas
+func subprotocol(codec Codec) string {
+	return fmt.Sprintf("arena.v%d.%s", types.ProtocolVersion, codec.Name())
+}
+
+// subprotocols lists the subprotocols the server accepts, in order of preference
+func subprotocols() []string {
+	names := make([]string, len(codecs))
+	for i, codec := range codecs {
+		names[i] = subprotocol(codec)
+	}
+	return names
+}
+
+// negotiateCodec picks the codec for a connection from its agreed subprotocol or its
+// encoding query parameter, falling back to JSON
+func negotiateCodec(r *http.Request, agreedSubprotocol string) Codec {
+	encoding := strings.ToLower(r.URL.Query().Get("encoding"))
+	for _, codec := range codecs {
+		if agreedSubprotocol == subprotocol(codec) || (agreedSubprotocol == "" && encoding == codec.Name()) {
+			return codec
+		}
+	}
+	return jsonCodec{}
+}
+
+// codecForFrame returns the codec a received frame is encoded with: text frames are always
+// JSON and binary frames MessagePack
+func codecForFrame(frameType int) Codec {
+	if frameType == websocket.BinaryMessage {
+		return msgpackCodec{}
+	}
+	return jsonCodec{}
+}
@@ -17,6 +17,7 @@ var upgrader = websocket.Upgrader{
 		// In production, restrict this to your client domain
 		return true
 	},
+	Subprotocols: subprotocols(),
 }
 
 // HandleWebSocket upgrades HTTP connections to WebSocket
@@ -42,10 +43,12 @@ func HandleWebSocket(hub *Hub, authHandler *auth.Handler, w http.ResponseWriter,
 		log.Printf("Warning: WebSocket connected without guest session, created ephemeral guest")
 	}
 
-	client := NewClient(hub, conn, clientID, userInfo.DisplayName, userInfo.IsGuest)
+	codec := negotiateCodec(r, conn.Subprotocol())
+
+	client := NewClient(hub, conn, codec, clientID, userInfo.UserID, userInfo.DisplayName, userInfo.AvatarURL, userInfo.IsGuest)
 	hub.Register <- client
 
-	log.Printf("Client connected: %s (%s, guest=%v)", clientID, userInfo.DisplayName, userInfo.IsGuest)
+	log.Printf("Client connected: %s (%s, guest=%v, encoding=%s)", clientID, userInfo.DisplayName, userInfo.IsGuest, codec.Name())
 
 	// Start client goroutines
 	go client.WritePump()
@@ -0,0 +1,96 @@
+package websocket
+
+import (
+	"log"
+	"slices"
+
+	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
+)
+
+// Close codes for connections the server ends (4000-4999 are reserved for applications)
+const (
+	closeClientTooOld    = 4000 // The client doesn't speak types.MinProtocolVersion or newer
+	closeTooManyMessages = 4001 // The client kept sending messages over their limits (see messageLimits)
+	closeSuspiciousInput = 4002 // The client kept sending inputs no unmodified client sends (see suspicionLimits)
+)
+
+// clientTooOldReason is shown to players whose client is older than the server supports
+const clientTooOldReason = "This version of the game is out of date, reload the page to update"
+
+// serverFeatures are the optional features the server supports
+var serverFeatures = []string{types.FeatureDeltaUpdates, types.FeatureGameEvents}
+
+// optionalMessages are the message types only sent to clients that enabled a feature
+var optionalMessages = map[string]string{
+	"game_events": types.FeatureGameEvents,
+}
+
+// messageRegistries hold the messages of each protocol version from types.MinProtocolVersion to
+// types.ProtocolVersion. A version that changes some messages starts from a copy of the previous
+// version's registry and registers the messages it changes.
+var messageRegistries = map[int]messageRegistry{
+	2: messagesV2(),
+}
+
+// handleHello agrees a protocol version and features with a client, disconnecting clients that
+// are too old, then puts them back into a game they dropped out of (if any)
+func (h *Hub) handleHello(client *Client, payload Payload) {
+	if client.ProtocolVersion != 0 {
+		sendError(client, "hello", newMessageError(types.ErrorCodeRejected, "Already said hello"))
+		return
+	}
+
+	var hello types.HelloPayload
+	if err := payload.Decode(&hello); err != nil {
+		log.Printf("error parsing hello from %s: %v", client.ID, err)
+	}
+
+	version := min(hello.ProtocolVersion, types.ProtocolVersion)
+	if version < types.MinProtocolVersion {
+		log.Printf("Client %s is too old (protocol %d, build %q)", client.ID, hello.ProtocolVersion, hello.ClientBuild)
+		h.rejectClient(client, closeClientTooOld, types.ErrorCodeClientTooOld, clientTooOldReason)
+		return
+	}
+
+	features := make([]string, 0, len(serverFeatures))
+	for _, feature := range serverFeatures {
+		if slices.Contains(hello.Features, feature) {
+			features = append(features, feature)
+		}
+	}
+
+	client.ProtocolVersion = version
+	client.ClientBuild = hello.ClientBuild
+	client.SetFeatures(features)
+
+	if !slices.Contains(hello.Encodings, client.codec.Name()) {
+		log.Printf("Warning: client %s doesn't list the %s encoding it connected with", client.ID, client.codec.Name())
+	}
+
+	encodings := make([]string, len(codecs))
+	for i, codec := range codecs {
+		encodings[i] = codec.Name()
+	}
+	client.SendMessage("welcome", types.WelcomePayload{
+		ProtocolVersion:    version,
+		MinProtocolVersion: types.MinProtocolVersion,
+		ServerBuild:        types.ServerBuild,
+		Encoding:           client.codec.Name(),
+		Encodings:          encodings,
+		Features:           features,
+	})
+	log.Printf("Client %s said hello (protocol %d, build %q, features %v)", client.ID, version, hello.ClientBuild, features)
+
+	// Put the client back into a game they dropped out of (if any)
+	h.gameManager.ReconnectClient(client.ID, client.UserID, client)
+}
+
+// rejectClient tells a client why they can't continue, then disconnects them
+func (h *Hub) rejectClient(client *Client, closeCode int, errorCode string, reason string) {
+	client.SendMessage("error", types.ErrorPayload{
+		Message: reason,
+		Code:    errorCode,
+	})
+	client.CloseWithReason(closeCode, reason)
+	h.removeClient(client)
+}
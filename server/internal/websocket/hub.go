@@ -1,8 +1,8 @@
 package websocket
 
 import (
-	"encoding/json"
 	"log"
+	"time"
 
 	"github.com/tombuildsstuff/web-arena-game/server/internal/game"
 	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
@@ -11,7 +11,7 @@ import (
 // ClientMessage pairs a client with their message
 type ClientMessage struct {
 	Client  *Client
-	Message types.Message
+	Message *IncomingMessage
 }
 
 // Hub maintains the set of active clients and broadcasts messages to clients
@@ -57,16 +57,7 @@ func (h *Hub) Run() {
 			log.Printf("Client connected: %s (total: %d)", client.ID, len(h.clients))
 
 		case client := <-h.Unregister:
-			if _, ok := h.clients[client]; ok {
-				delete(h.clients, client)
-				delete(h.clientsByID, client.ID)
-				client.Close()
-
-				// Remove from game manager (handles both queue and active games)
-				h.gameManager.RemoveClient(client.ID)
-
-				log.Printf("Client disconnected: %s (total: %d)", client.ID, len(h.clients))
-			}
+			h.removeClient(client)
 
 		case clientMsg := <-h.HandleMessage:
 			h.handleClientMessage(clientMsg)
@@ -74,6 +65,20 @@ func (h *Hub) Run() {
 	}
 }
 
+// removeClient disconnects a client, if they haven't been already
+func (h *Hub) removeClient(client *Client) {
+	if _, ok := h.clients[client]; ok {
+		delete(h.clients, client)
+		delete(h.clientsByID, client.ID)
+		client.Close()
+
+		// Remove from game manager (active games hold the seat for a reconnect)
+		h.gameManager.DisconnectClient(client.ID)
+
+		log.Printf("Client disconnected: %s (total: %d)", client.ID, len(h.clients))
+	}
+}
+
 // handleClientMessage processes messages from clients
 func (h *Hub) handleClientMessage(clientMsg *ClientMessage) {
 	msg := clientMsg.Message
@@ -81,356 +86,252 @@ func (h *Hub) handleClientMessage(clientMsg *ClientMessage) {
 
 	// Verbose: log.Printf("Received message from %s: %s", client.ID, msg.Type)
 
-	switch msg.Type {
-	case "join_queue":
-		h.handleJoinQueue(client, msg.Payload)
-
-	case "start_vs_ai":
-		h.handleStartVsAI(client, msg.Payload)
-
-	case "purchase_unit":
-		h.handlePurchaseUnit(client, msg.Payload)
-
-	case "player_move":
-		h.handlePlayerMove(client, msg.Payload)
-
-	case "player_shoot":
-		h.handlePlayerShoot(client, msg.Payload)
-
-	case "buy_from_zone":
-		h.handleBuyFromZone(client, msg.Payload)
-
-	case "bulk_buy_from_zone":
-		h.handleBulkBuyFromZone(client, msg.Payload)
-
-	case "claim_turret":
-		h.handleClaimTurret(client, msg.Payload)
-
-	case "claim_buy_zone":
-		h.handleClaimBuyZone(client, msg.Payload)
-
-	case "claim_barracks":
-		h.handleClaimBarracks(client, msg.Payload)
-
-	case "leave_game":
-		h.handleLeaveGame(client)
+	// Ignore messages that were already on their way from a client who has been disconnected
+	if !h.clients[client] {
+		return
+	}
 
-	case "get_lobby_status":
-		h.handleGetLobbyStatus(client)
+	// Drop messages over their type's limit, and kick clients that keep sending them
+	if !h.checkMessage(client, msg, time.Now()) {
+		return
+	}
 
-	case "spectate_game":
-		h.handleSpectateGame(client, msg.Payload)
+	if msg.Type == "hello" {
+		h.handleHello(client, msg.Payload)
+		return
+	}
 
-	case "stop_spectating":
-		h.handleStopSpectating(client)
+	// Clients say hello before anything else, so this one predates the handshake
+	if client.ProtocolVersion == 0 {
+		log.Printf("Client %s sent %s without saying hello", client.ID, msg.Type)
+		h.rejectClient(client, closeClientTooOld, types.ErrorCodeClientTooOld, clientTooOldReason)
+		return
+	}
 
-	default:
+	route, exists := messageRegistries[client.ProtocolVersion][msg.Type]
+	if !exists {
 		log.Printf("Unknown message type: %s", msg.Type)
-		client.SendMessage("error", types.ErrorPayload{
-			Message: "Unknown message type",
-		})
+		sendError(client, msg.Type, newMessageError(types.ErrorCodeUnknownMessage, "Unknown message type"))
+		return
 	}
+	route(h, client, msg)
 }
 
+// messagesV2 are the messages of protocol version 2
+func messagesV2() messageRegistry {
+	messages := make(messageRegistry)
+	registerMessage(messages, "join_queue", (*Hub).handleJoinQueue)
+	registerMessage(messages, "start_vs_ai", (*Hub).handleStartVsAI)
+	registerMessage(messages, "purchase_unit", (*Hub).handlePurchaseUnit)
+	registerMessage(messages, "player_move", (*Hub).handlePlayerMove)
+	registerMessage(messages, "player_shoot", (*Hub).handlePlayerShoot)
+	registerMessage(messages, "state_ack", (*Hub).handleStateAck)
+	registerMessage(messages, "buy_from_zone", (*Hub).handleBuyFromZone)
+	registerMessage(messages, "bulk_buy_from_zone", (*Hub).handleBulkBuyFromZone)
+	registerMessage(messages, "claim_turret", (*Hub).handleClaimTurret)
+	registerMessage(messages, "claim_buy_zone", (*Hub).handleClaimBuyZone)
+	registerMessage(messages, "claim_barracks", (*Hub).handleClaimBarracks)
+	registerSignal(messages, "leave_game", (*Hub).handleLeaveGame)
+	registerSignal(messages, "get_lobby_status", (*Hub).handleGetLobbyStatus)
+	registerMessage(messages, "spectate_game", (*Hub).handleSpectateGame)
+	registerSignal(messages, "stop_spectating", (*Hub).handleStopSpectating)
+	registerMessage(messages, "spectate_replay", (*Hub).handleSpectateReplay)
+	registerMessage(messages, "create_lobby", (*Hub).handleCreateLobby)
+	registerMessage(messages, "join_lobby", (*Hub).handleJoinLobby)
+	registerMessage(messages, "update_lobby", (*Hub).handleUpdateLobby)
+	registerMessage(messages, "set_ready", (*Hub).handleSetReady)
+	registerSignal(messages, "start_lobby", (*Hub).handleStartLobby)
+	registerSignal(messages, "leave_lobby", (*Hub).handleLeaveLobby)
+	return messages
+}
+
+// errAlreadyInGame is returned for messages that can't be sent while playing in a game
+var errAlreadyInGame = newMessageError(types.ErrorCodeAlreadyInGame, "Already in a game")
+
+// errSpectating is returned for lobby messages sent while spectating a game or replay
+var errSpectating = newMessageError(types.ErrorCodeRejected, "Stop spectating first")
+
 // handleJoinQueue adds a client to the matchmaking queue
-func (h *Hub) handleJoinQueue(client *Client, payload interface{}) {
-	// Parse payload for map preference
-	var mapID string
-	if payload != nil {
-		data, err := json.Marshal(payload)
-		if err == nil {
-			var joinQueue types.JoinQueuePayload
-			if json.Unmarshal(data, &joinQueue) == nil {
-				mapID = joinQueue.MapID
-			}
-		}
+func (h *Hub) handleJoinQueue(client *Client, joinQueue *types.JoinQueuePayload) error {
+	// Use the map preference, game mode and team size if they're valid
+	gameMode := types.GameModeSiege
+	if game.IsValidGameMode(joinQueue.GameMode) {
+		gameMode = joinQueue.GameMode
+	}
+	teamSize := 1
+	if joinQueue.TeamSize > 1 && joinQueue.TeamSize <= types.MaxTeamSize {
+		teamSize = joinQueue.TeamSize
 	}
 
-	h.gameManager.AddToQueue(client.ID, client, client.DisplayName, client.IsGuest, mapID)
+	// Queueing for a public match leaves any private lobby
+	h.gameManager.LeaveLobby(client.ID)
+
+	h.gameManager.AddToQueue(client.ID, client.UserID, client, client.DisplayName, client.AvatarURL, client.IsGuest, joinQueue.MapID, gameMode, teamSize)
+	return nil
 }
 
 // handleStartVsAI starts a game against AI
-func (h *Hub) handleStartVsAI(client *Client, payload interface{}) {
-	// Parse payload
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return
-	}
-
-	var startAI types.StartVsAIPayload
-	if err := json.Unmarshal(data, &startAI); err != nil {
-		return
-	}
-
+func (h *Hub) handleStartVsAI(client *Client, startAI *types.StartVsAIPayload) error {
 	// Validate difficulty
 	difficulty := startAI.Difficulty
 	if difficulty != "easy" && difficulty != "medium" && difficulty != "hard" {
 		difficulty = "medium" // Default to medium
 	}
 
+	// Validate game mode
+	gameMode := startAI.GameMode
+	if !game.IsValidGameMode(gameMode) {
+		gameMode = types.GameModeSiege // Default to siege
+	}
+
+	// Validate team size
+	teamSize := startAI.TeamSize
+	if teamSize < 1 || teamSize > types.MaxTeamSize {
+		teamSize = 1 // Default to 1v1
+	}
+
 	// Check if client is already in a game
 	if h.gameManager.GetRoomByClient(client.ID) != nil {
-		client.SendMessage("error", types.ErrorPayload{
-			Message: "Already in a game",
-		})
-		return
+		return errAlreadyInGame
 	}
 
+	h.gameManager.LeaveLobby(client.ID)
+
 	// Create AI game with map preference
-	h.gameManager.CreateAIGame(client.ID, client, client.DisplayName, client.IsGuest, difficulty, startAI.MapID)
+	h.gameManager.CreateAIGame(client.ID, client.UserID, client, client.DisplayName, client.AvatarURL, client.IsGuest, difficulty, startAI.MapID, gameMode, teamSize)
+	return nil
 }
 
 // handlePurchaseUnit processes a unit purchase request
-func (h *Hub) handlePurchaseUnit(client *Client, payload interface{}) {
-	// Parse payload
-	data, err := json.Marshal(payload)
-	if err != nil {
-		log.Printf("error marshaling payload: %v", err)
-		return
-	}
-
-	var purchase types.PurchaseUnitPayload
-	if err := json.Unmarshal(data, &purchase); err != nil {
-		log.Printf("error unmarshaling purchase: %v", err)
-		client.SendMessage("error", types.ErrorPayload{
-			Message: "Invalid purchase payload",
-		})
-		return
-	}
-
-	// Get the game room for this client
-	room := h.gameManager.GetRoomByClient(client.ID)
+func (h *Hub) handlePurchaseUnit(client *Client, purchase *types.PurchaseUnitPayload) error {
+	room, seat := h.playerSeat(client)
 	if room == nil {
-		client.SendMessage("error", types.ErrorPayload{
-			Message: "You are not in a game",
-		})
-		return
-	}
-
-	// Get player ID
-	playerID := h.gameManager.GetPlayerIDInRoom(client.ID)
-	if playerID < 0 {
-		client.SendMessage("error", types.ErrorPayload{
-			Message: "Invalid player",
-		})
-		return
+		return newMessageError(types.ErrorCodeNotInGame, "You are not in a game")
 	}
 
 	// Forward to game room
-	room.HandlePurchase(playerID, purchase.UnitType)
+	room.HandlePurchase(seat, purchase.UnitType)
+	return nil
 }
 
 // handlePlayerMove processes player movement input
-func (h *Hub) handlePlayerMove(client *Client, payload interface{}) {
-	// Parse payload
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return
-	}
-
-	var move types.PlayerMovePayload
-	if err := json.Unmarshal(data, &move); err != nil {
-		return
+func (h *Hub) handlePlayerMove(client *Client, move *types.PlayerMovePayload) error {
+	if !h.normaliseMove(client, move, time.Now()) {
+		return nil
 	}
 
-	// Get the game room for this client
-	room := h.gameManager.GetRoomByClient(client.ID)
+	// Moves are sent constantly, so ones from clients not in a game are ignored
+	room, seat := h.playerSeat(client)
 	if room == nil {
-		return
-	}
-
-	// Get player ID
-	playerID := h.gameManager.GetPlayerIDInRoom(client.ID)
-	if playerID < 0 {
-		return
+		return nil
 	}
 
 	// Forward to game room
-	room.HandlePlayerMove(playerID, move.Direction)
+	room.HandlePlayerMove(seat, move.Direction, move.InputSequence)
+	return nil
 }
 
 // handlePlayerShoot processes player shoot command
-func (h *Hub) handlePlayerShoot(client *Client, payload interface{}) {
-	// Parse payload
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return
-	}
-
-	var shoot types.PlayerShootPayload
-	if err := json.Unmarshal(data, &shoot); err != nil {
-		return
+func (h *Hub) handlePlayerShoot(client *Client, shoot *types.PlayerShootPayload) error {
+	if !h.normaliseShot(client, shoot, time.Now()) {
+		return nil
 	}
 
-	// Get the game room for this client
-	room := h.gameManager.GetRoomByClient(client.ID)
+	// Shots are sent constantly, so ones from clients not in a game are ignored
+	room, seat := h.playerSeat(client)
 	if room == nil {
-		return
-	}
-
-	// Get player ID
-	playerID := h.gameManager.GetPlayerIDInRoom(client.ID)
-	if playerID < 0 {
-		return
+		return nil
 	}
 
 	// Forward to game room
-	room.HandlePlayerShoot(playerID, shoot.TargetX, shoot.TargetZ)
+	room.HandlePlayerShoot(seat, shoot.TargetX, shoot.TargetZ, shoot.ViewSnapshot, shoot.InputSequence)
+	return nil
 }
 
 // handleBuyFromZone processes a buy from zone request
-func (h *Hub) handleBuyFromZone(client *Client, payload interface{}) {
-	// Parse payload
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return
-	}
-
-	var buy types.BuyFromZonePayload
-	if err := json.Unmarshal(data, &buy); err != nil {
-		return
-	}
-
-	// Get the game room for this client
-	room := h.gameManager.GetRoomByClient(client.ID)
+func (h *Hub) handleBuyFromZone(client *Client, buy *types.BuyFromZonePayload) error {
+	room, seat := h.playerSeat(client)
 	if room == nil {
-		return
-	}
-
-	// Get player ID
-	playerID := h.gameManager.GetPlayerIDInRoom(client.ID)
-	if playerID < 0 {
-		return
+		return nil
 	}
 
 	// Forward to game room
-	room.HandleBuyFromZone(playerID, buy.ZoneID, client)
+	room.HandleBuyFromZone(seat, buy.ZoneID, client)
+	return nil
 }
 
 // handleBulkBuyFromZone processes a bulk buy from zone request (10 units at 10% discount)
-func (h *Hub) handleBulkBuyFromZone(client *Client, payload interface{}) {
-	// Parse payload
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return
-	}
-
-	var buy types.BulkBuyFromZonePayload
-	if err := json.Unmarshal(data, &buy); err != nil {
-		return
-	}
-
-	// Get the game room for this client
-	room := h.gameManager.GetRoomByClient(client.ID)
+func (h *Hub) handleBulkBuyFromZone(client *Client, buy *types.BulkBuyFromZonePayload) error {
+	room, seat := h.playerSeat(client)
 	if room == nil {
-		return
-	}
-
-	// Get player ID
-	playerID := h.gameManager.GetPlayerIDInRoom(client.ID)
-	if playerID < 0 {
-		return
+		return nil
 	}
 
 	// Forward to game room
-	room.HandleBulkBuyFromZone(playerID, buy.ZoneID, client)
+	room.HandleBulkBuyFromZone(seat, buy.ZoneID, client)
+	return nil
 }
 
 // handleClaimTurret processes a turret claiming request
-func (h *Hub) handleClaimTurret(client *Client, payload interface{}) {
-	// Parse payload
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return
-	}
-
-	var claim types.ClaimTurretPayload
-	if err := json.Unmarshal(data, &claim); err != nil {
-		return
-	}
-
-	// Get the game room for this client
-	room := h.gameManager.GetRoomByClient(client.ID)
+func (h *Hub) handleClaimTurret(client *Client, claim *types.ClaimTurretPayload) error {
+	room, seat := h.playerSeat(client)
 	if room == nil {
-		return
-	}
-
-	// Get player ID
-	playerID := h.gameManager.GetPlayerIDInRoom(client.ID)
-	if playerID < 0 {
-		return
+		return nil
 	}
 
 	// Forward to game room
-	room.HandleClaimTurret(playerID, claim.TurretID, client)
+	room.HandleClaimTurret(seat, claim.TurretID, client)
+	return nil
 }
 
 // handleClaimBuyZone processes a buy zone claiming request
-func (h *Hub) handleClaimBuyZone(client *Client, payload interface{}) {
-	// Parse payload
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return
-	}
-
-	var claim types.ClaimBuyZonePayload
-	if err := json.Unmarshal(data, &claim); err != nil {
-		return
-	}
-
-	// Get the game room for this client
-	room := h.gameManager.GetRoomByClient(client.ID)
+func (h *Hub) handleClaimBuyZone(client *Client, claim *types.ClaimBuyZonePayload) error {
+	room, seat := h.playerSeat(client)
 	if room == nil {
-		return
-	}
-
-	// Get player ID
-	playerID := h.gameManager.GetPlayerIDInRoom(client.ID)
-	if playerID < 0 {
-		return
+		return nil
 	}
 
 	// Forward to game room
-	room.HandleClaimBuyZone(playerID, claim.ZoneID, client)
+	room.HandleClaimBuyZone(seat, claim.ZoneID, client)
+	return nil
 }
 
 // handleClaimBarracks processes a barracks claiming request
-func (h *Hub) handleClaimBarracks(client *Client, payload interface{}) {
-	// Parse payload
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return
+func (h *Hub) handleClaimBarracks(client *Client, claim *types.ClaimBarracksPayload) error {
+	room, seat := h.playerSeat(client)
+	if room == nil {
+		return nil
 	}
 
-	var claim types.ClaimBarracksPayload
-	if err := json.Unmarshal(data, &claim); err != nil {
-		return
-	}
+	// Forward to game room
+	room.HandleClaimBarracks(seat, claim.BarracksID, client)
+	return nil
+}
 
-	// Get the game room for this client
+// playerSeat returns the game room a client is playing in and the seat they control, or a nil
+// room if they aren't playing
+func (h *Hub) playerSeat(client *Client) (*game.GameRoom, int) {
 	room := h.gameManager.GetRoomByClient(client.ID)
 	if room == nil {
-		return
+		return nil, -1
 	}
 
-	// Get player ID
-	playerID := h.gameManager.GetPlayerIDInRoom(client.ID)
-	if playerID < 0 {
-		return
+	seat := h.gameManager.GetSeatInRoom(client.ID)
+	if seat < 0 {
+		return nil, -1
 	}
-
-	// Forward to game room
-	room.HandleClaimBarracks(playerID, claim.BarracksID, client)
+	return room, seat
 }
 
 // handleLeaveGame removes a client from their current game
-func (h *Hub) handleLeaveGame(client *Client) {
+func (h *Hub) handleLeaveGame(client *Client) error {
 	// Verbose: log.Printf("Client %s leaving game", client.ID)
 	h.gameManager.RemoveClient(client.ID)
+	return nil
 }
 
 // handleGetLobbyStatus returns queue size and active games
-func (h *Hub) handleGetLobbyStatus(client *Client) {
+func (h *Hub) handleGetLobbyStatus(client *Client) error {
 	queueSize := h.gameManager.GetQueueSize()
 	activeGames := h.gameManager.GetActiveGames()
 
@@ -449,27 +350,33 @@ func (h *Hub) handleGetLobbyStatus(client *Client) {
 		QueueSize:   queueSize,
 		ActiveGames: games,
 	})
+	return nil
 }
 
 // handleSpectateGame handles a request to spectate a game
-func (h *Hub) handleSpectateGame(client *Client, payload interface{}) {
-	// Parse payload
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return
+func (h *Hub) handleSpectateGame(client *Client, spectate *types.SpectateGamePayload) error {
+	// Check if client is already in a game or spectating
+	if h.gameManager.GetRoomByClient(client.ID) != nil {
+		return errAlreadyInGame
 	}
 
-	var spectate types.SpectateGamePayload
-	if err := json.Unmarshal(data, &spectate); err != nil {
-		return
+	if h.gameManager.IsSpectating(client.ID) {
+		// Stop current spectating first
+		h.gameManager.RemoveSpectator(client.ID)
 	}
 
-	// Check if client is already in a game or spectating
+	// Add as spectator
+	if !h.gameManager.AddSpectator(client.ID, spectate.GameID, client) {
+		return newMessageError(types.ErrorCodeNotFound, "Game not found or already ended")
+	}
+	return nil
+}
+
+// handleSpectateReplay handles a request to watch a recorded replay
+func (h *Hub) handleSpectateReplay(client *Client, spectate *types.SpectateReplayPayload) error {
+	// Check if client is already in a game
 	if h.gameManager.GetRoomByClient(client.ID) != nil {
-		client.SendMessage("error", types.ErrorPayload{
-			Message: "Already in a game",
-		})
-		return
+		return errAlreadyInGame
 	}
 
 	if h.gameManager.IsSpectating(client.ID) {
@@ -477,40 +384,79 @@ func (h *Hub) handleSpectateGame(client *Client, payload interface{}) {
 		h.gameManager.RemoveSpectator(client.ID)
 	}
 
-	// Add as spectator
-	if !h.gameManager.AddSpectator(client.ID, spectate.GameID, client) {
-		client.SendMessage("error", types.ErrorPayload{
-			Message: "Game not found or already ended",
-		})
+	if err := h.gameManager.SpectateReplay(client.ID, spectate.ReplayID, spectate.Speed, client); err != nil {
+		return newMessageError(types.ErrorCodeNotFound, "Replay not found")
 	}
+	return nil
 }
 
-// handleStopSpectating stops spectating a game
-func (h *Hub) handleStopSpectating(client *Client) {
-	h.gameManager.RemoveSpectator(client.ID)
-	client.SendMessage("spectate_stopped", nil)
+// handleCreateLobby creates a private lobby hosted by the client, with optional initial settings
+func (h *Hub) handleCreateLobby(client *Client, createLobby *types.CreateLobbyPayload) error {
+	// Check if client is already in a game
+	if h.gameManager.GetRoomByClient(client.ID) != nil {
+		return errAlreadyInGame
+	}
+	if h.gameManager.IsSpectating(client.ID) {
+		return errSpectating
+	}
+
+	_, err := h.gameManager.CreateLobby(client.ID, client.UserID, client, client.DisplayName, client.IsGuest, createLobby.Settings)
+	return err
 }
 
-// Broadcast sends a message to all clients
-func (h *Hub) Broadcast(msgType string, payload interface{}) {
-	msg := types.Message{
-		Type:    msgType,
-		Payload: payload,
+// handleJoinLobby adds the client to a private lobby by its join code
+func (h *Hub) handleJoinLobby(client *Client, joinLobby *types.JoinLobbyPayload) error {
+	// Check if client is already in a game
+	if h.gameManager.GetRoomByClient(client.ID) != nil {
+		return errAlreadyInGame
+	}
+	if h.gameManager.IsSpectating(client.ID) {
+		return errSpectating
 	}
 
-	data, err := json.Marshal(msg)
-	if err != nil {
-		log.Printf("error marshaling broadcast message: %v", err)
-		return
+	return h.gameManager.JoinLobby(joinLobby.Code, client.ID, client.UserID, client, client.DisplayName, client.IsGuest)
+}
+
+// handleUpdateLobby changes the settings of the client's private lobby (host only)
+func (h *Hub) handleUpdateLobby(client *Client, updateLobby *types.UpdateLobbyPayload) error {
+	return h.gameManager.UpdateLobbySettings(client.ID, updateLobby.Settings)
+}
+
+// handleSetReady marks the client as ready (or not) in their private lobby
+func (h *Hub) handleSetReady(client *Client, setReady *types.SetReadyPayload) error {
+	if h.gameManager.IsSpectating(client.ID) {
+		return errSpectating
 	}
 
-	for client := range h.clients {
-		select {
-		case client.send <- data:
-		default:
-			// Buffer full - drop the message for this client.
-			// The client will be cleaned up via ping/pong timeout if unresponsive.
-			log.Printf("client %s: broadcast buffer full, dropping message type %s", client.ID, msgType)
-		}
+	return h.gameManager.SetLobbyReady(client.ID, setReady.Ready)
+}
+
+// handleStartLobby starts the match for the client's private lobby (host only)
+func (h *Hub) handleStartLobby(client *Client) error {
+	return h.gameManager.StartLobby(client.ID)
+}
+
+// handleLeaveLobby removes the client from their private lobby
+func (h *Hub) handleLeaveLobby(client *Client) error {
+	h.gameManager.LeaveLobby(client.ID)
+	client.SendMessage("lobby_left", nil)
+	return nil
+}
+
+// handleStateAck records the newest game_update snapshot a client has applied
+func (h *Hub) handleStateAck(client *Client, ack *types.StateAckPayload) error {
+	// Clients that can't apply deltas keep getting keyframes
+	if !client.Supports(types.FeatureDeltaUpdates) {
+		return nil
 	}
+
+	h.gameManager.AcknowledgeSnapshot(client.ID, ack.Snapshot)
+	return nil
+}
+
+// handleStopSpectating stops spectating a game
+func (h *Hub) handleStopSpectating(client *Client) error {
+	h.gameManager.RemoveSpectator(client.ID)
+	client.SendMessage("spectate_stopped", nil)
+	return nil
 }
@@ -0,0 +1,99 @@
+package websocket
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+
+	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
+)
+
+// messageRoute decodes one type of message and handles it
+type messageRoute func(h *Hub, client *Client, msg *IncomingMessage)
+
+// messageRegistry maps each message type a protocol version handles to its route
+type messageRegistry map[string]messageRoute
+
+// registerMessage adds a message type whose payload is decoded into a P. The payload is decoded
+// and its required fields (those tagged `required:"true"`) are checked before the handler is
+// called; if either fails, or the handler returns an error, the client is sent an error.
+func registerMessage[P any](registry messageRegistry, msgType string, handler func(h *Hub, client *Client, payload *P) error) {
+	registry[msgType] = func(h *Hub, client *Client, msg *IncomingMessage) {
+		payload := new(P)
+		if err := msg.Payload.Decode(payload); err != nil {
+			log.Printf("error decoding %s from %s: %v", msg.Type, client.ID, err)
+			sendError(client, msg.Type, newMessageError(types.ErrorCodeInvalidPayload, "Invalid "+msg.Type+" payload"))
+			return
+		}
+		if field := missingField(reflect.ValueOf(payload).Elem()); field != "" {
+			sendError(client, msg.Type, newMessageError(types.ErrorCodeMissingField, fmt.Sprintf("Missing %s in %s", field, msg.Type)))
+			return
+		}
+		if err := handler(h, client, payload); err != nil {
+			sendError(client, msg.Type, err)
+		}
+	}
+}
+
+// registerSignal adds a message type that doesn't have a payload
+func registerSignal(registry messageRegistry, msgType string, handler func(h *Hub, client *Client) error) {
+	registry[msgType] = func(h *Hub, client *Client, msg *IncomingMessage) {
+		if err := handler(h, client); err != nil {
+			sendError(client, msg.Type, err)
+		}
+	}
+}
+
+// messageError is an error handling a client's message, which is sent back to them
+type messageError struct {
+	code    string // One of the types.ErrorCode constants
+	message string // Shown to the player
+}
+
+func (e *messageError) Error() string {
+	return e.message
+}
+
+// newMessageError creates an error to send back to a client
+func newMessageError(code string, message string) error {
+	return &messageError{code: code, message: message}
+}
+
+// sendError tells a client why their message failed. Errors other than messageErrors (such as
+// the game manager's) are sent as types.ErrorCodeRejected, with the error as the message.
+func sendError(client *Client, msgType string, err error) {
+	var msgErr *messageError
+	if !errors.As(err, &msgErr) {
+		msgErr = &messageError{code: types.ErrorCodeRejected, message: err.Error()}
+	}
+	client.SendMessage("error", types.ErrorPayload{
+		Message:     msgErr.message,
+		Code:        msgErr.code,
+		MessageType: msgType,
+	})
+}
+
+// missingField returns the JSON name of the first field tagged `required:"true"` that's left
+// unset in a decoded payload, or an empty string if they're all set
+func missingField(payload reflect.Value) string {
+	if payload.Kind() != reflect.Struct {
+		return ""
+	}
+
+	for i := 0; i < payload.NumField(); i++ {
+		field := payload.Type().Field(i)
+		if field.Anonymous {
+			if name := missingField(payload.Field(i)); name != "" {
+				return name
+			}
+			continue
+		}
+		if field.Tag.Get("required") == "true" && payload.Field(i).IsZero() {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,258 @@
+package websocket
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/tombuildsstuff/web-arena-game/server/internal/types"
+)
+
+const (
+	// tooManyMessagesReason is shown to players who are kicked for flooding the server
+	tooManyMessagesReason = "Disconnected for sending too many messages"
+
+	// suspiciousInputReason is shown to players who are kicked for inputs no unmodified client sends
+	suspiciousInputReason = "Disconnected for sending invalid inputs"
+
+	// unknownMessageType is the limit every message type the server doesn't handle shares, so a
+	// client can't make the validator keep a bucket for each type it makes up
+	unknownMessageType = ""
+
+	// Dropped messages a client is allowed before they're kicked, and how quickly that allowance recovers
+	droppedMessageBurst = 100
+	droppedMessageRate  = 10.0 // Per second
+
+	// Shots arriving faster than the player unit can fire are suspicious once they go beyond this
+	// many, which allows for messages bunched together by the network
+	suspiciousShotBurst = 3
+
+	// Directions are allowed to be this much longer than 1 before they're suspicious, for rounding
+	directionTolerance = 0.01
+
+	// How often each kind of suspicious input is logged for a client
+	suspicionLogInterval = 30 * time.Second
+)
+
+// Kinds of suspicious input
+const (
+	suspiciousInvalidMove = "invalid move"
+	suspiciousMoveSpeed   = "moving faster than PlayerUnitSpeed"
+	suspiciousInvalidShot = "invalid shot"
+	suspiciousFireRate    = "shooting faster than PlayerUnitAttackSpeed"
+)
+
+// suspicionLimits are the kinds of suspicious input a client is kicked for once they keep sending
+// them: more than burst at once, or more than rate per second for long enough to use that up
+var suspicionLimits = map[string]messageLimit{
+	suspiciousMoveSpeed: {rate: 2, burst: 60}, // Joysticks send a move for every touch event
+	suspiciousFireRate:  {rate: 1, burst: 20},
+}
+
+// messageLimit is how often a client can send one type of message
+type messageLimit struct {
+	rate  float64 // Messages per second
+	burst float64 // Messages that can be sent at once
+}
+
+// messageLimits are the limits for each message type, well above what the game's client sends
+var messageLimits = map[string]messageLimit{
+	"player_move":        {rate: 120, burst: 120}, // Joysticks send a move for every touch event
+	"player_shoot":       {rate: 10, burst: 10},
+	"state_ack":          {rate: 2 * types.TickRate, burst: 2 * types.TickRate},
+	"purchase_unit":      {rate: 5, burst: 10},
+	"buy_from_zone":      {rate: 5, burst: 10},
+	"bulk_buy_from_zone": {rate: 5, burst: 10},
+	"claim_turret":       {rate: 5, burst: 10},
+	"claim_buy_zone":     {rate: 5, burst: 10},
+	"claim_barracks":     {rate: 5, burst: 10},
+}
+
+// defaultMessageLimit is the limit for message types without their own (lobbies, queueing,
+// spectating), and the limit shared by every message type the server doesn't handle
+var defaultMessageLimit = messageLimit{rate: 2, burst: 10}
+
+// tokenBucket allows an average rate of events with bursts of up to its size
+type tokenBucket struct {
+	tokens float64
+	rate   float64 // Tokens added per second
+	burst  float64 // Most tokens held
+	last   time.Time
+}
+
+// newTokenBucket creates a full bucket
+func newTokenBucket(rate, burst float64, now time.Time) *tokenBucket {
+	return &tokenBucket{tokens: burst, rate: rate, burst: burst, last: now}
+}
+
+// take uses up a token, returning false if there aren't any left
+func (b *tokenBucket) take(now time.Time) bool {
+	b.tokens = min(b.tokens+now.Sub(b.last).Seconds()*b.rate, b.burst)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// suspicion is how often a client has sent one kind of suspicious input since it was last logged
+type suspicion struct {
+	count      int
+	lastLogged time.Time
+	allowance  *tokenBucket // How many more the client can send before they're kicked (nil = never kicked)
+}
+
+// inputValidator checks a client's messages against the message limits and flags inputs no
+// unmodified client would send. It's only used from the hub's goroutine, so isn't locked.
+type inputValidator struct {
+	limits     map[string]*tokenBucket // Message type -> bucket, created on first use
+	dropped    *tokenBucket            // Allowance of dropped messages before the client is kicked
+	shots      *tokenBucket            // Shots at the rate the player unit can fire
+	suspicions map[string]*suspicion   // Kind of suspicious input -> occurrences
+}
+
+// newInputValidator creates the validator for a newly connected client
+func newInputValidator(now time.Time) *inputValidator {
+	return &inputValidator{
+		limits:     make(map[string]*tokenBucket),
+		dropped:    newTokenBucket(droppedMessageRate, droppedMessageBurst, now),
+		shots:      newTokenBucket(types.PlayerUnitAttackSpeed, suspiciousShotBurst, now),
+		suspicions: make(map[string]*suspicion),
+	}
+}
+
+// allow returns true if a message is within its type's limit
+func (v *inputValidator) allow(msgType string, now time.Time) bool {
+	if !knownMessageType(msgType) {
+		msgType = unknownMessageType
+	}
+
+	bucket, exists := v.limits[msgType]
+	if !exists {
+		limit, limited := messageLimits[msgType]
+		if !limited {
+			limit = defaultMessageLimit
+		}
+		bucket = newTokenBucket(limit.rate, limit.burst, now)
+		v.limits[msgType] = bucket
+	}
+	return bucket.take(now)
+}
+
+// knownMessageType returns true if any protocol version handles a message type
+func knownMessageType(msgType string) bool {
+	if msgType == "hello" {
+		return true
+	}
+	for _, registry := range messageRegistries {
+		if _, exists := registry[msgType]; exists {
+			return true
+		}
+	}
+	return false
+}
+
+// checkMessage applies the message limits, dropping messages over them and kicking clients that
+// keep sending them. Returns true if the message should be handled.
+func (h *Hub) checkMessage(client *Client, msg *IncomingMessage, now time.Time) bool {
+	if client.validator.allow(msg.Type, now) {
+		return true
+	}
+
+	if !client.validator.dropped.take(now) {
+		log.Printf("Kicking client %s (user %s) for sending too many messages (last was %s)", client.ID, client.UserID, msg.Type)
+		h.rejectClient(client, closeTooManyMessages, types.ErrorCodeTooManyMessages, tooManyMessagesReason)
+	}
+	return false
+}
+
+// flagSuspicious records an input no unmodified client would send. Each kind of input is logged
+// when it's first seen, then at most once per suspicionLogInterval with how often it was seen.
+// Clients that keep sending a kind with a suspicionLimit are kicked, in which case it returns true.
+func (h *Hub) flagSuspicious(client *Client, kind string, detail string, now time.Time) bool {
+	s, exists := client.validator.suspicions[kind]
+	if !exists {
+		s = &suspicion{}
+		if limit, limited := suspicionLimits[kind]; limited {
+			s.allowance = newTokenBucket(limit.rate, limit.burst, now)
+		}
+		client.validator.suspicions[kind] = s
+	}
+	s.count++
+
+	if s.allowance != nil && !s.allowance.take(now) {
+		log.Printf("Kicking client %s (user %s, build %q) for suspicious input: %s (%s)", client.ID, client.UserID, client.ClientBuild, kind, detail)
+		h.rejectClient(client, closeSuspiciousInput, types.ErrorCodeSuspiciousInput, suspiciousInputReason)
+		return true
+	}
+
+	if now.Sub(s.lastLogged) < suspicionLogInterval {
+		return false
+	}
+	log.Printf("Suspicious input from client %s (user %s, build %q): %s x%d (%s)", client.ID, client.UserID, client.ClientBuild, kind, s.count, detail)
+	s.count = 0
+	s.lastLogged = now
+	return false
+}
+
+// normaliseMove makes a move's direction a flat vector no longer than 1, flagging directions that
+// ask to move faster than the player unit can. Returns false if the move can't be used (or the
+// client was kicked for it).
+func (h *Hub) normaliseMove(client *Client, move *types.PlayerMovePayload, now time.Time) bool {
+	dir := move.Direction
+	if !isFinite(dir.X) || !isFinite(dir.Y) || !isFinite(dir.Z) {
+		h.flagSuspicious(client, suspiciousInvalidMove, fmt.Sprintf("direction %v", dir), now)
+		return false
+	}
+
+	dir.Y = 0
+	length := math.Sqrt(dir.X*dir.X + dir.Z*dir.Z)
+	if length > 1 {
+		if length > 1+directionTolerance && h.flagSuspicious(client, suspiciousMoveSpeed, fmt.Sprintf("direction length %.2f", length), now) {
+			return false
+		}
+		dir.X /= length
+		dir.Z /= length
+	}
+	move.Direction = dir
+	normaliseInputSequence(&move.InputSequence)
+	return true
+}
+
+// normaliseShot keeps a shot's target within the arena, flagging shots that come faster than the
+// player unit can fire. Returns false if the shot can't be used (or the client was kicked for it).
+func (h *Hub) normaliseShot(client *Client, shoot *types.PlayerShootPayload, now time.Time) bool {
+	if !isFinite(shoot.TargetX) || !isFinite(shoot.TargetZ) {
+		h.flagSuspicious(client, suspiciousInvalidShot, fmt.Sprintf("target (%v, %v)", shoot.TargetX, shoot.TargetZ), now)
+		return false
+	}
+
+	if !client.validator.shots.take(now) && h.flagSuspicious(client, suspiciousFireRate, "more shots than the player unit can fire", now) {
+		return false
+	}
+
+	shoot.TargetX = clamp(shoot.TargetX, -types.ArenaHalfSize, types.ArenaHalfSize)
+	shoot.TargetZ = clamp(shoot.TargetZ, -types.ArenaHalfSize, types.ArenaHalfSize)
+	shoot.ViewSnapshot = max(shoot.ViewSnapshot, 0)
+	normaliseInputSequence(&shoot.InputSequence)
+	return true
+}
+
+// normaliseInputSequence drops input numbers that can't be right
+func normaliseInputSequence(input *types.InputSequence) {
+	if input.Sequence < 0 || input.ClientTime < 0 {
+		*input = types.InputSequence{}
+	}
+}
+
+// isFinite returns true if a value is a number other than infinity
+func isFinite(value float64) bool {
+	return !math.IsNaN(value) && !math.IsInf(value, 0)
+}
+
+// clamp limits a value to a range
+func clamp(value, low, high float64) float64 {
+	return max(low, min(value, high))
+}
@@ -3,15 +3,22 @@ package main
 import (
 	"embed"
 	"encoding/json"
+	"errors"
 	"io/fs"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"github.com/tombuildsstuff/web-arena-game/server/internal/auth"
 	"github.com/tombuildsstuff/web-arena-game/server/internal/game"
+	"github.com/tombuildsstuff/web-arena-game/server/internal/storage"
 	"github.com/tombuildsstuff/web-arena-game/server/internal/websocket"
 )
 
@@ -19,12 +26,36 @@ import (
 var staticContent embed.FS
 
 func main() {
+	// Open the embedded database holding sessions, the leaderboard and match history
+	databaseFile := os.Getenv("DATABASE_FILE")
+	if databaseFile == "" {
+		databaseFile = "arena.db"
+	}
+	store, err := storage.OpenBolt(databaseFile)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer store.Close()
+
 	// Create auth handler
 	authConfig := auth.LoadConfig()
-	authHandler := auth.NewHandler(authConfig)
+	authHandler := auth.NewHandler(authConfig, store)
+	go authHandler.RunSessionSweeper()
+
+	// Results, unlocks and history are saved in the background, and flushed on shutdown
+	writer := storage.NewWriter(store)
 
 	// Create game manager
-	gameManager := game.NewManager()
+	gameManager := game.NewManager(store, writer)
+
+	// Guests' sessions are only saved once they play
+	gameManager.SetOnGuestPlaying(authHandler.SaveGuestSession)
+
+	go gameManager.RunMatchmaking()
+	go gameManager.RunSeasonArchiver()
+
+	// Show signed-in players their achievements on /api/me
+	authHandler.SetAchievementLookup(gameManager.GetAchievements)
 
 	// Create WebSocket hub
 	hub := websocket.NewHub(gameManager)
@@ -58,19 +89,106 @@ func main() {
 	})
 
 	r.Get("/api/leaderboard", func(w http.ResponseWriter, r *http.Request) {
-		leaderboard := gameManager.GetLeaderboard()
-		entries := leaderboard.GetTopPlayers(50) // Top 50 players
-
-		response := struct {
-			TotalMatches int         `json:"totalMatches"`
-			Entries      interface{} `json:"entries"`
-		}{
-			TotalMatches: leaderboard.GetTotalMatches(),
-			Entries:      entries,
+		params := r.URL.Query()
+		offset, _ := strconv.Atoi(params.Get("offset"))
+		limit, _ := strconv.Atoi(params.Get("limit"))
+		query := game.LeaderboardQuery{
+			Window:   params.Get("window"),
+			SeasonID: params.Get("season"),
+			MapID:    params.Get("map"),
+			SortBy:   params.Get("sort"),
+			Offset:   offset,
+			Limit:    limit,
+		}
+
+		page, err := gameManager.QueryLeaderboard(query, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(page)
+	})
+
+	r.Get("/api/seasons", func(w http.ResponseWriter, r *http.Request) {
+		seasons := gameManager.ListSeasons(time.Now())
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(seasons)
+	})
+
+	r.Get("/api/achievements", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(game.ListAchievements())
+	})
+
+	r.Post("/api/me/color", func(w http.ResponseWriter, r *http.Request) {
+		user := authHandler.GetUserFromRequest(r)
+		if user == nil || user.IsGuest {
+			http.Error(w, "Sign in to choose a colour", http.StatusUnauthorized)
+			return
+		}
+
+		var req struct {
+			Color string `json:"color"` // Empty = back to the map's colour
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if err := gameManager.SetPlayerColor(user.UserID, req.Color); err != nil {
+			if errors.Is(err, game.ErrColorLocked) {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(gameManager.GetAchievements(user.UserID))
+	})
+
+	r.Get("/api/replays", func(w http.ResponseWriter, r *http.Request) {
+		replays := gameManager.GetReplayStore().List(50) // 50 most recent replays
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(replays)
+	})
+
+	r.Get("/api/replays/{id}", func(w http.ResponseWriter, r *http.Request) {
+		replay, err := gameManager.GetReplayStore().Get(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Replay not found", http.StatusNotFound)
+			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(response)
+		json.NewEncoder(w).Encode(replay)
+	})
+
+	r.Get("/api/players/{id}", func(w http.ResponseWriter, r *http.Request) {
+		profile := gameManager.GetPlayerProfile(chi.URLParam(r, "id"))
+		if profile == nil {
+			http.Error(w, "Player not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(profile)
+	})
+
+	r.Get("/api/matches/{id}", func(w http.ResponseWriter, r *http.Request) {
+		match, err := gameManager.GetMatchHistory().Get(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Match not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(match)
 	})
 
 	r.Get("/ws", func(w http.ResponseWriter, r *http.Request) {
@@ -92,7 +210,16 @@ func main() {
 	log.Printf("Server starting on %s", port)
 	log.Printf("WebSocket endpoint: ws://localhost%s/ws", port)
 	log.Printf("Web UI: http://localhost%s/", port)
-	if err := http.ListenAndServe(port, r); err != nil {
-		log.Fatal(err)
-	}
+	go func() {
+		if err := http.ListenAndServe(port, r); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	// Save everything that's been queued before the store is closed
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM)
+	<-shutdown
+	log.Printf("Shutting down, saving queued writes")
+	writer.Close()
 }